@@ -8,6 +8,26 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+)
+
+// Значения по умолчанию для пользовательских алиасов.
+const (
+	defaultAliasCharset   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+	defaultAliasMinLength = 3
+	defaultAliasMaxLength = 32
+)
+
+// defaultDedupMode — область дедупликации оригинальных URL по умолчанию.
+const defaultDedupMode = "user"
+
+// defaultFileSync — политика сброса журнала файлового хранилища по умолчанию.
+const defaultFileSync = "always"
+
+// Значения по умолчанию для кеша коротких ссылок.
+const (
+	defaultCacheSize = 10000
+	defaultCacheTTL  = "1m"
 )
 
 // Flags представляет конфигурационные параметры приложения.
@@ -16,16 +36,34 @@ type Flags struct {
 	BaseShortAddr      string `json:"base_url"`          // Базовый URL для сокращенных ссылок.
 	URLStorageFilePath string `json:"file_storage_path"` // Путь к файлу для хранения URL (если используется файловое хранилище).
 	DataBaseDSN        string `json:"database_dsn"`      // DSN (Data Source Name) для подключения к базе данных.
+	BoltStoragePath    string `json:"bolt_storage_path"` // Путь к файлу встроенной базы bbolt (если используется хранилище bbolt).
 	HTTPSEnabled       bool   `json:"enable_https"`      // Возможность подключения к HTTPS-серверу
 	TrustedSubnet      string `json:"trusted_subnet"`    // Доверенная подсеть сервера
 	GRPCAddr           string `json:"grpc_addr"`         // Адрес и порт для запуска grpc сервера.
+	AliasCharset       string `json:"alias_charset"`     // Допустимые символы пользовательских алиасов.
+	AliasMinLength     int    `json:"alias_min_length"`  // Минимальная длина пользовательского алиаса.
+	AliasMaxLength     int    `json:"alias_max_length"`  // Максимальная длина пользовательского алиаса.
+	GeoIPFile          string `json:"geoip_file"`        // CSV-файл диапазонов IP-адресов по странам для статистики переходов.
+	CookieSecret       string `json:"cookie_secret"`     // Секрет подписи кук, если не задан файл ключей.
+	CookieKeysFile     string `json:"cookie_keys_file"`  // JSON-файл с набором ключей подписи кук.
+	RateShorten        string `json:"rate_shorten"`      // Лимит сокращения "rate:burst" на пользователя или IP; пусто — без лимита.
+	RateBatch          string `json:"rate_batch"`        // Лимит пакетного сокращения "rate:burst".
+	RateRedirect       string `json:"rate_redirect"`     // Лимит переходов по ссылкам "rate:burst".
+	URLPolicyFile      string `json:"url_policy_file"`   // JSON-файл со списками разрешённых и запрещённых адресов.
+	DedupMode          string `json:"dedup_mode"`        // Область дедупликации оригинальных URL: user, global или none.
+	FileSync           string `json:"file_storage_sync"` // Сброс журнала файлового хранилища на диск: always, never или период, например 1s.
+	CacheSize          int    `json:"cache_size"`        // Число коротких ссылок в кеше переходов, 0 — без кеша.
+	CacheTTL           string `json:"cache_ttl"`         // Время жизни записи кеша переходов, например 1m.
 }
 
 // String возвращает строковое представление текущих параметров конфигурации.
 func (f *Flags) String() string {
-	return fmt.Sprintf(`RunAddr: %s, BaseShortAddr: %s, URLStorageFileName: %s, DataBaseDSN: %s,
-		 HTTPSEnabled: %t, TrustedSubnet: %s, GRPCAddr: %s`,
-		f.RunAddr, f.BaseShortAddr, f.URLStorageFilePath, f.DataBaseDSN, f.HTTPSEnabled, f.TrustedSubnet, f.GRPCAddr)
+	return fmt.Sprintf(`RunAddr: %s, BaseShortAddr: %s, URLStorageFileName: %s, DataBaseDSN: %s, BoltStoragePath: %s,
+		 HTTPSEnabled: %t, TrustedSubnet: %s, GRPCAddr: %s, AliasCharset: %s, AliasMinLength: %d, AliasMaxLength: %d, GeoIPFile: %s, CookieKeysFile: %s,
+		 RateShorten: %s, RateBatch: %s, RateRedirect: %s, URLPolicyFile: %s, DedupMode: %s, FileSync: %s, CacheSize: %d, CacheTTL: %s`,
+		f.RunAddr, f.BaseShortAddr, f.URLStorageFilePath, f.DataBaseDSN, f.BoltStoragePath, f.HTTPSEnabled, f.TrustedSubnet, f.GRPCAddr,
+		f.AliasCharset, f.AliasMinLength, f.AliasMaxLength, f.GeoIPFile, f.CookieKeysFile,
+		f.RateShorten, f.RateBatch, f.RateRedirect, f.URLPolicyFile, f.DedupMode, f.FileSync, f.CacheSize, f.CacheTTL)
 }
 
 // Init инициализирует параметры конфигурации из флагов командной строки, переменных окружения и значений по умолчанию.
@@ -40,10 +78,25 @@ func Init() *Flags {
 	base := flag.String("b", "http://localhost:8080", "Base shorten url")
 	storagePath := flag.String("f", "", "URL storage path")
 	dataBase := flag.String("d", "", "Database connection address")
+	boltPath := flag.String("bolt", "", "Embedded bbolt database path")
 	httpsEnabled := flag.Bool("s", false, "Enable HTTPS")
 	trustSubnet := flag.String("t", "", "Trusted server subnet")
 	configFile := flag.String("c", "", "Path to JSON config file")
 	grpcAddr := flag.String("g", ":50051", "Address and port to run grpc server")
+	aliasCharset := flag.String("alias-charset", defaultAliasCharset, "Allowed characters for custom aliases")
+	aliasMinLength := flag.Int("alias-min", defaultAliasMinLength, "Minimum custom alias length")
+	aliasMaxLength := flag.Int("alias-max", defaultAliasMaxLength, "Maximum custom alias length")
+	geoIPFile := flag.String("geoip", "", "Path to CSV file with IP ranges by country")
+	cookieSecret := flag.String("cookie-secret", "", "Secret for signing user cookies")
+	cookieKeysFile := flag.String("cookie-keys", "", "Path to JSON keyring for signing user cookies")
+	rateShorten := flag.String("rate-shorten", "", "Shorten rate limit per user or IP as rate:burst")
+	rateBatch := flag.String("rate-batch", "", "Batch shorten rate limit per user or IP as rate:burst")
+	rateRedirect := flag.String("rate-redirect", "", "Redirect rate limit per user or IP as rate:burst")
+	urlPolicyFile := flag.String("url-policy", "", "Path to JSON file with allowed and blocked destinations")
+	dedupMode := flag.String("dedup", defaultDedupMode, "Original URL deduplication scope: user, global or none")
+	fileSync := flag.String("file-sync", defaultFileSync, "File storage fsync policy: always, never or an interval such as 1s")
+	cacheSize := flag.Int("cache-size", defaultCacheSize, "Number of short links in the redirect cache, 0 disables the cache")
+	cacheTTL := flag.String("cache-ttl", defaultCacheTTL, "Redirect cache entry lifetime such as 1m")
 	flag.Parse()
 
 	// Переопределение значений из переменных окружения, если они заданы.
@@ -59,6 +112,9 @@ func Init() *Flags {
 	if envDataBase := os.Getenv("DATABASE_DSN"); envDataBase != "" {
 		*dataBase = envDataBase
 	}
+	if envBoltPath := os.Getenv("BOLT_STORAGE_PATH"); envBoltPath != "" {
+		*boltPath = envBoltPath
+	}
 	if envHTTPSEnabled := os.Getenv("ENABLE_HTTPS"); envHTTPSEnabled == "true" {
 		*httpsEnabled = true
 	}
@@ -71,15 +127,72 @@ func Init() *Flags {
 	if envGRPC := os.Getenv("GRPC_ADDRESS"); envGRPC != "" {
 		*grpcAddr = envGRPC
 	}
+	if envAliasCharset := os.Getenv("ALIAS_CHARSET"); envAliasCharset != "" {
+		*aliasCharset = envAliasCharset
+	}
+	if n, err := strconv.Atoi(os.Getenv("ALIAS_MIN_LENGTH")); err == nil {
+		*aliasMinLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("ALIAS_MAX_LENGTH")); err == nil {
+		*aliasMaxLength = n
+	}
+	if envGeoIPFile := os.Getenv("GEOIP_FILE"); envGeoIPFile != "" {
+		*geoIPFile = envGeoIPFile
+	}
+	if envCookieSecret := os.Getenv("COOKIE_SECRET"); envCookieSecret != "" {
+		*cookieSecret = envCookieSecret
+	}
+	if envCookieKeysFile := os.Getenv("COOKIE_KEYS_FILE"); envCookieKeysFile != "" {
+		*cookieKeysFile = envCookieKeysFile
+	}
+	if envRateShorten := os.Getenv("RATE_LIMIT_SHORTEN"); envRateShorten != "" {
+		*rateShorten = envRateShorten
+	}
+	if envRateBatch := os.Getenv("RATE_LIMIT_BATCH"); envRateBatch != "" {
+		*rateBatch = envRateBatch
+	}
+	if envRateRedirect := os.Getenv("RATE_LIMIT_REDIRECT"); envRateRedirect != "" {
+		*rateRedirect = envRateRedirect
+	}
+	if envURLPolicyFile := os.Getenv("URL_POLICY_FILE"); envURLPolicyFile != "" {
+		*urlPolicyFile = envURLPolicyFile
+	}
+	if envDedupMode := os.Getenv("DEDUP_MODE"); envDedupMode != "" {
+		*dedupMode = envDedupMode
+	}
+	if envFileSync := os.Getenv("FILE_STORAGE_SYNC"); envFileSync != "" {
+		*fileSync = envFileSync
+	}
+	if n, err := strconv.Atoi(os.Getenv("CACHE_SIZE")); err == nil {
+		*cacheSize = n
+	}
+	if envCacheTTL := os.Getenv("CACHE_TTL"); envCacheTTL != "" {
+		*cacheTTL = envCacheTTL
+	}
 
 	config := Flags{
 		RunAddr:            *addr,
 		BaseShortAddr:      *base,
 		URLStorageFilePath: *storagePath,
 		DataBaseDSN:        *dataBase,
+		BoltStoragePath:    *boltPath,
 		HTTPSEnabled:       *httpsEnabled,
 		TrustedSubnet:      *trustSubnet,
 		GRPCAddr:           *grpcAddr,
+		AliasCharset:       *aliasCharset,
+		AliasMinLength:     *aliasMinLength,
+		AliasMaxLength:     *aliasMaxLength,
+		GeoIPFile:          *geoIPFile,
+		CookieSecret:       *cookieSecret,
+		CookieKeysFile:     *cookieKeysFile,
+		RateShorten:        *rateShorten,
+		RateBatch:          *rateBatch,
+		RateRedirect:       *rateRedirect,
+		URLPolicyFile:      *urlPolicyFile,
+		DedupMode:          *dedupMode,
+		FileSync:           *fileSync,
+		CacheSize:          *cacheSize,
+		CacheTTL:           *cacheTTL,
 	}
 
 	if *configFile != "" {
@@ -87,7 +200,10 @@ func Init() *Flags {
 		if err == nil {
 			defer file.Close()
 			decoder := json.NewDecoder(file)
-			var fileConfig Flags
+			var fileConfig struct {
+				Flags
+				CacheSize *int `json:"cache_size"` // nil, если размер кеша в файле не задан.
+			}
 			if err := decoder.Decode(&fileConfig); err == nil {
 				if *addr == "localhost:8080" && fileConfig.RunAddr != "" {
 					config.RunAddr = fileConfig.RunAddr
@@ -101,6 +217,9 @@ func Init() *Flags {
 				if *dataBase == "" && fileConfig.DataBaseDSN != "" {
 					config.DataBaseDSN = fileConfig.DataBaseDSN
 				}
+				if *boltPath == "" && fileConfig.BoltStoragePath != "" {
+					config.BoltStoragePath = fileConfig.BoltStoragePath
+				}
 				if !*httpsEnabled && fileConfig.HTTPSEnabled {
 					config.HTTPSEnabled = true
 				}
@@ -110,6 +229,48 @@ func Init() *Flags {
 				if *grpcAddr == ":50051" && fileConfig.GRPCAddr != "" {
 					config.GRPCAddr = fileConfig.GRPCAddr
 				}
+				if *aliasCharset == defaultAliasCharset && fileConfig.AliasCharset != "" {
+					config.AliasCharset = fileConfig.AliasCharset
+				}
+				if *aliasMinLength == defaultAliasMinLength && fileConfig.AliasMinLength != 0 {
+					config.AliasMinLength = fileConfig.AliasMinLength
+				}
+				if *aliasMaxLength == defaultAliasMaxLength && fileConfig.AliasMaxLength != 0 {
+					config.AliasMaxLength = fileConfig.AliasMaxLength
+				}
+				if *geoIPFile == "" && fileConfig.GeoIPFile != "" {
+					config.GeoIPFile = fileConfig.GeoIPFile
+				}
+				if *cookieSecret == "" && fileConfig.CookieSecret != "" {
+					config.CookieSecret = fileConfig.CookieSecret
+				}
+				if *cookieKeysFile == "" && fileConfig.CookieKeysFile != "" {
+					config.CookieKeysFile = fileConfig.CookieKeysFile
+				}
+				if *rateShorten == "" && fileConfig.RateShorten != "" {
+					config.RateShorten = fileConfig.RateShorten
+				}
+				if *rateBatch == "" && fileConfig.RateBatch != "" {
+					config.RateBatch = fileConfig.RateBatch
+				}
+				if *rateRedirect == "" && fileConfig.RateRedirect != "" {
+					config.RateRedirect = fileConfig.RateRedirect
+				}
+				if *urlPolicyFile == "" && fileConfig.URLPolicyFile != "" {
+					config.URLPolicyFile = fileConfig.URLPolicyFile
+				}
+				if *dedupMode == defaultDedupMode && fileConfig.DedupMode != "" {
+					config.DedupMode = fileConfig.DedupMode
+				}
+				if *fileSync == defaultFileSync && fileConfig.FileSync != "" {
+					config.FileSync = fileConfig.FileSync
+				}
+				if *cacheSize == defaultCacheSize && fileConfig.CacheSize != nil {
+					config.CacheSize = *fileConfig.CacheSize
+				}
+				if *cacheTTL == defaultCacheTTL && fileConfig.CacheTTL != "" {
+					config.CacheTTL = fileConfig.CacheTTL
+				}
 			}
 		}
 	}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/cmd/config"
+	"github.com/mi4r/go-url-shortener/internal/auth"
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// keysUsage описывает подкоманду управления ключами подписи кук.
+const keysUsage = "usage: shortener keys rotate|list|retire <id> -cookie-keys <file>"
+
+// loadKeyring возвращает набор ключей подписи кук из конфигурации: файл ключей,
+// затем секрет, иначе случайный ключ, действующий до перезапуска сервиса.
+func loadKeyring(flags *config.Flags) (*auth.Keyring, error) {
+	switch {
+	case flags.CookieKeysFile != "":
+		return auth.LoadKeyring(flags.CookieKeysFile)
+	case flags.CookieSecret != "":
+		return auth.NewSecretKeyring(flags.CookieSecret)
+	default:
+		logger.Sugar.Warn("Cookie signing key is not configured, user cookies will not survive a restart")
+		return auth.NewRandomKeyring()
+	}
+}
+
+// splitKeyID отделяет ID ключа, следующий за действием retire, от флагов.
+func splitKeyID(action string, args []string) (string, []string) {
+	if action != "retire" || len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		return "", args
+	}
+	return args[1], append([]string{args[0]}, args[2:]...)
+}
+
+// runKeys выполняет действие над файлом ключей path и выводит результат в out.
+// Ротация добавляет новый активный ключ и сохраняет прежние, поэтому выданные
+// ранее куки остаются действительными; retire удаляет неактивный ключ id.
+// Запущенный сервис перечитывает файл по SIGHUP.
+func runKeys(path, action, id string, now time.Time, out io.Writer) error {
+	if path == "" {
+		return errors.New(keysUsage)
+	}
+
+	keys, err := auth.LoadKeyring(path)
+	if errors.Is(err, os.ErrNotExist) && action == "rotate" {
+		keys, err = auth.NewRandomKeyring()
+		if err == nil {
+			err = keys.Save(path)
+		}
+		if err == nil {
+			fmt.Fprintf(out, "Created keyring with active key %s\n", keys.Active())
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "rotate":
+		id, err := keys.Rotate(now)
+		if err != nil {
+			return err
+		}
+		if err := keys.Save(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Active key is now %s\n", id)
+	case "retire":
+		if id == "" {
+			return errors.New(keysUsage)
+		}
+		if err := keys.Retire(id); err != nil {
+			return err
+		}
+		if err := keys.Save(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Retired key %s\n", id)
+	case "list":
+		for _, id := range keys.IDs() {
+			marker := ""
+			if id == keys.Active() {
+				marker = " (active)"
+			}
+			fmt.Fprintf(out, "%s%s\n", id, marker)
+		}
+	default:
+		return errors.New(keysUsage)
+	}
+	return nil
+}
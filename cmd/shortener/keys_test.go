@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/cmd/config"
+	"github.com/mi4r/go-url-shortener/internal/auth"
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRunKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	var out bytes.Buffer
+
+	require.NoError(t, runKeys(path, "rotate", "", time.Now(), &out))
+	first, err := auth.LoadKeyring(path)
+	require.NoError(t, err)
+
+	now := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, runKeys(path, "rotate", "", now, &out))
+	rotated, err := auth.LoadKeyring(path)
+	require.NoError(t, err)
+	assert.Equal(t, "20300102T030405", rotated.Active())
+	assert.Contains(t, rotated.IDs(), first.Active())
+
+	out.Reset()
+	require.NoError(t, runKeys(path, "list", "", now, &out))
+	assert.Contains(t, out.String(), "20300102T030405 (active)")
+
+	assert.ErrorIs(t, runKeys(path, "retire", "20300102T030405", now, &out), auth.ErrActiveKey)
+	assert.Error(t, runKeys(path, "retire", "", now, &out))
+	require.NoError(t, runKeys(path, "retire", first.Active(), now, &out))
+	retired, err := auth.LoadKeyring(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20300102T030405"}, retired.IDs())
+
+	assert.Error(t, runKeys(path, "drop", "", now, &out))
+	assert.Error(t, runKeys("", "rotate", "", now, &out))
+	assert.Error(t, runKeys(filepath.Join(t.TempDir(), "missing.json"), "list", "", now, &out))
+}
+
+func TestSplitKeyID(t *testing.T) {
+	id, args := splitKeyID("retire", []string{"shortener", "old", "-cookie-keys", "keys.json"})
+	assert.Equal(t, "old", id)
+	assert.Equal(t, []string{"shortener", "-cookie-keys", "keys.json"}, args)
+
+	id, args = splitKeyID("retire", []string{"shortener", "-cookie-keys", "keys.json"})
+	assert.Empty(t, id)
+	assert.Equal(t, []string{"shortener", "-cookie-keys", "keys.json"}, args)
+
+	id, _ = splitKeyID("rotate", []string{"shortener", "old"})
+	assert.Empty(t, id)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+
+	keys, err := loadKeyring(&config.Flags{CookieSecret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "default", keys.Active())
+
+	keys, err = loadKeyring(&config.Flags{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, keys.Active())
+
+	_, err = loadKeyring(&config.Flags{CookieKeysFile: filepath.Join(t.TempDir(), "missing.json")})
+	assert.Error(t, err)
+}
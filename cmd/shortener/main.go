@@ -10,15 +10,22 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/mi4r/go-url-shortener/cmd/config"
 	httpsconf "github.com/mi4r/go-url-shortener/cmd/https_conf"
 	"go.uber.org/zap"
 
+	"github.com/mi4r/go-url-shortener/internal/analytics"
+	"github.com/mi4r/go-url-shortener/internal/auth"
 	"github.com/mi4r/go-url-shortener/internal/handlers"
 	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/policy"
 	"github.com/mi4r/go-url-shortener/internal/server"
+	"github.com/mi4r/go-url-shortener/internal/service"
 	"github.com/mi4r/go-url-shortener/internal/storage"
 
 	_ "net/http/pprof"
@@ -26,6 +33,9 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// reaperInterval задаёт период удаления ссылок с истёкшим сроком действия.
+const reaperInterval = time.Minute
+
 var (
 	// buildVersion содержит версию приложения
 	buildVersion string
@@ -71,30 +81,108 @@ func main() {
 
 	logger.Sugar = *lgr.Sugar()
 
+	// Подкоманды: shortener migrate up|down|status [flags]
+	// и shortener keys rotate|list|retire <id> [flags].
+	migrateAction, args, isMigrate := splitCommand(os.Args, "migrate")
+	keysAction, args, isKeys := splitCommand(args, "keys")
+	keyID, args := splitKeyID(keysAction, args)
+	os.Args = args
+
 	// Загрузка конфигурации.
 	handlers.Flags = config.Init()
 
+	switch {
+	case isMigrate:
+		if err := runMigrate(context.Background(), handlers.Flags.DataBaseDSN, migrateAction, os.Stdout); err != nil {
+			logger.Sugar.Fatal("Migration failed: ", err)
+		}
+		return
+	case isKeys:
+		if err := runKeys(handlers.Flags.CookieKeysFile, keysAction, keyID, time.Now(), os.Stdout); err != nil {
+			logger.Sugar.Fatal("Keys command failed: ", err)
+		}
+		return
+	}
+
+	// Ключи подписи кук.
+	keyring, err := loadKeyring(handlers.Flags)
+	if err != nil {
+		logger.Sugar.Fatal("Failed to load cookie signing keys: ", err)
+	}
+	auth.SetKeyring(keyring)
+	// Файл ключей перечитывается по SIGHUP, чтобы ротация и вывод ключей из
+	// набора не требовали перезапуска.
+	if handlers.Flags.CookieKeysFile != "" {
+		keysReload := make(chan os.Signal, 1)
+		signal.Notify(keysReload, syscall.SIGHUP)
+		keysCtx, stopKeys := context.WithCancel(context.Background())
+		defer stopKeys()
+		go auth.WatchKeyring(keysCtx, handlers.Flags.CookieKeysFile, keysReload)
+	}
+
+	// Ограничение частоты запросов.
+	limits, err := loadRateLimits(handlers.Flags)
+	if err != nil {
+		logger.Sugar.Fatal("Invalid rate limit: ", err)
+	}
+
+	dedupMode, err := storage.ParseDedupMode(handlers.Flags.DedupMode)
+	if err != nil {
+		logger.Sugar.Fatal("Invalid dedup mode: ", err)
+	}
+	syncPolicy, err := storage.ParseSyncPolicy(handlers.Flags.FileSync)
+	if err != nil {
+		logger.Sugar.Fatal("Invalid file storage sync policy: ", err)
+	}
+	cacheTTL, err := time.ParseDuration(handlers.Flags.CacheTTL)
+	if err != nil {
+		logger.Sugar.Fatal("Invalid cache TTL: ", err)
+	}
+
 	var storageImpl storage.Storage
 
-	// Настройка хранилища с приоритетом: база данных > файл > память.
+	// Настройка хранилища с приоритетом: база данных > bbolt > файл > память.
 	if handlers.Flags.DataBaseDSN != "" {
-		storageImpl, err = storage.NewDBStorage(handlers.Flags.DataBaseDSN)
+		dbStorage, err := storage.NewDBStorage(handlers.Flags.DataBaseDSN, storage.WithDedupMode(dedupMode))
 		if err != nil {
-			logger.Sugar.Warn("Falling back to file storage due to DB error: ", err)
+			logger.Sugar.Warn("Falling back to next storage due to DB error: ", err)
+		} else {
+			storageImpl = dbStorage
+		}
+	}
+	if storageImpl == nil && handlers.Flags.BoltStoragePath != "" {
+		boltStorage, err := storage.NewBoltStorage(handlers.Flags.BoltStoragePath, storage.WithDedupMode(dedupMode))
+		if err != nil {
+			logger.Sugar.Warn("Falling back to next storage due to bbolt error: ", err)
+		} else {
+			storageImpl = boltStorage
 		}
 	}
 	if storageImpl == nil && handlers.Flags.URLStorageFilePath != "" {
-		storageImpl, err = storage.NewFileStorage(handlers.Flags.URLStorageFilePath)
+		storageImpl, err = storage.NewFileStorage(handlers.Flags.URLStorageFilePath,
+			storage.WithDedupMode(dedupMode), storage.WithSyncPolicy(syncPolicy))
 		if err != nil {
 			logger.Sugar.Warn("Falling back to memory storage due to file error: ", err)
 		}
 	}
 	if storageImpl == nil {
-		storageImpl = storage.NewMemoryStorage()
+		storageImpl = storage.NewMemoryStorage(storage.WithDedupMode(dedupMode))
 		logger.Sugar.Info("Using in-memory storage")
 	}
 	defer storageImpl.Close()
 
+	// Кеш переходов поверх выбранного хранилища, счётчики — в /debug/vars.
+	if handlers.Flags.CacheSize > 0 {
+		cached := storage.NewCachedStorage(storageImpl, handlers.Flags.CacheSize, cacheTTL)
+		// Изменения ссылок на других экземплярах сервиса приходят через LISTEN/NOTIFY.
+		if _, ok := storageImpl.(*storage.DBStorage); ok {
+			listenerCtx, stopListener := context.WithCancel(context.Background())
+			defer stopListener()
+			go storage.NewChangeListener(handlers.Flags.DataBaseDSN, cached).Run(listenerCtx)
+		}
+		storageImpl = cached
+	}
+
 	var trustedSubnet *net.IPNet
 	if handlers.Flags.TrustedSubnet != "" {
 		_, subnet, err := net.ParseCIDR(handlers.Flags.TrustedSubnet)
@@ -103,9 +191,58 @@ func main() {
 		}
 		trustedSubnet = subnet
 	}
+	limits.TrustedSubnet = trustedSubnet
+
+	// Сервисный слой, общий для HTTP и gRPC.
+	svc := service.NewShortener(storageImpl, handlers.Flags.BaseShortAddr, trustedSubnet)
+	svc.AliasPolicy = service.AliasPolicy{
+		Charset:   handlers.Flags.AliasCharset,
+		MinLength: handlers.Flags.AliasMinLength,
+		MaxLength: handlers.Flags.AliasMaxLength,
+		Reserved:  service.DefaultReservedAliases,
+	}
+
+	// Политика допустимых адресов, перечитывается по SIGHUP.
+	if handlers.Flags.URLPolicyFile != "" {
+		destinations, err := policy.NewReloader(handlers.Flags.URLPolicyFile)
+		if err != nil {
+			logger.Sugar.Fatal("Failed to load destination policy: ", err)
+		}
+		svc.Destinations = destinations
+		reloadChan := make(chan os.Signal, 1)
+		signal.Notify(reloadChan, syscall.SIGHUP)
+		policyCtx, stopPolicy := context.WithCancel(context.Background())
+		defer stopPolicy()
+		go destinations.Watch(policyCtx, reloadChan)
+	}
+
+	// Асинхронный сбор статистики переходов.
+	var geo analytics.GeoResolver
+	if handlers.Flags.GeoIPFile != "" {
+		ranges, err := analytics.LoadIPRangesFile(handlers.Flags.GeoIPFile)
+		if err != nil {
+			logger.Sugar.Warn("Click countries are disabled due to GeoIP file error: ", err)
+		} else {
+			geo = ranges
+		}
+	}
+	collector := analytics.NewCollector(storageImpl, geo, analytics.DefaultBufferSize)
+	svc.Clicks = collector
+	collectorCtx, stopCollector := context.WithCancel(context.Background())
+	defer stopCollector()
+	collectorDone := make(chan struct{})
+	go func() {
+		collector.Run(collectorCtx)
+		close(collectorDone)
+	}()
+
+	// Фоновое удаление ссылок с истёкшим сроком действия.
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	defer stopReaper()
+	go svc.RunReaper(reaperCtx, reaperInterval)
 
 	// Инициализация маршрутизатора.
-	r := server.NewRouter(storageImpl, trustedSubnet)
+	r := server.NewRouter(svc, limits)
 	srv := server.NewServer(handlers.Flags.RunAddr, r)
 
 	signalChan := httpsconf.MakeSigChan()
@@ -126,12 +263,12 @@ func main() {
 		}
 	}()
 
-	grpcServer := server.NewServerGRPC(storageImpl, trustedSubnet)
+	grpcServer := server.NewServerGRPC(svc, limits)
 	go server.StartGRPC(grpcServer)
 
 	<-signalChan
 	logger.Sugar.Info("Shutting down server...")
-	storageImpl.Close()
+	stopReaper()
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	grpcServer.GracefulStop()
@@ -140,5 +277,9 @@ func main() {
 		logger.Sugar.Fatal("Server forced to shutdown:", err)
 	}
 
+	// Сохранение оставшихся переходов до закрытия хранилища.
+	stopCollector()
+	<-collectorDone
+
 	logger.Sugar.Info("Server exited properly")
 }
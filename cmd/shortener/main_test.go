@@ -14,6 +14,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/mi4r/go-url-shortener/cmd/config"
 	"github.com/mi4r/go-url-shortener/internal/handlers"
+	"github.com/mi4r/go-url-shortener/internal/service"
 	"github.com/mi4r/go-url-shortener/internal/storage"
 	"github.com/mi4r/go-url-shortener/internal/storage/mocks"
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,7 @@ func TestRedirectHandler(t *testing.T) {
 	type want struct {
 		statusCode int
 		origin     string
+		body       string
 	}
 	tests := []struct {
 		name          string
@@ -49,8 +51,9 @@ func TestRedirectHandler(t *testing.T) {
 			shorten:       "/invalid",
 			existedURLMap: map[string]storage.URL{"abc": {ShortURL: "abc", OriginalURL: "http://example.com"}},
 			want: want{
-				statusCode: http.StatusBadRequest,
+				statusCode: http.StatusNotFound,
 				origin:     "",
+				body:       "Not Found",
 			},
 		},
 		{
@@ -61,6 +64,7 @@ func TestRedirectHandler(t *testing.T) {
 			want: want{
 				statusCode: http.StatusBadRequest,
 				origin:     "",
+				body:       "Invalid request",
 			},
 		},
 	}
@@ -68,14 +72,14 @@ func TestRedirectHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			storage := storage.NewMemoryStorage()
 			for _, url := range tt.existedURLMap {
-				_, _ = storage.Save(url)
+				_, _ = storage.Save(context.Background(), url)
 			}
 			req := httptest.NewRequest(tt.method, tt.shorten, nil)
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", strings.TrimPrefix(tt.shorten, "/"))
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 			w := httptest.NewRecorder()
-			handler := http.HandlerFunc(handlers.RedirectHandler(storage))
+			handler := http.HandlerFunc(handlers.RedirectHandler(service.NewShortener(storage, "", nil), nil))
 			handler.ServeHTTP(w, req)
 
 			res := w.Result()
@@ -88,7 +92,7 @@ func TestRedirectHandler(t *testing.T) {
 				assert.Equal(t, tt.want.origin, location)
 			} else {
 				respBody := w.Body.String()
-				assert.Contains(t, respBody, "Invalid request")
+				assert.Contains(t, respBody, tt.want.body)
 			}
 		})
 	}
@@ -146,7 +150,7 @@ func TestShortenURLHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.originalURL))
 			w := httptest.NewRecorder()
-			handler := http.HandlerFunc(handlers.ShortenURLHandler(storage))
+			handler := http.HandlerFunc(handlers.ShortenURLHandler(service.NewShortener(storage, handlers.Flags.BaseShortAddr, nil)))
 			handler.ServeHTTP(w, req)
 
 			res := w.Result()
@@ -235,7 +239,7 @@ func TestAPIShortenURLHandler(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 
 			w := httptest.NewRecorder()
-			handler := http.HandlerFunc(handlers.APIShortenURLHandler(storage))
+			handler := http.HandlerFunc(handlers.APIShortenURLHandler(service.NewShortener(storage, handlers.Flags.BaseShortAddr, nil)))
 			handler.ServeHTTP(w, req)
 
 			res := w.Result()
@@ -288,11 +292,11 @@ func TestBatchShortenURLHandler_Success(t *testing.T) {
 		t.Fatalf("Failed to marshal request body: %v", err)
 	}
 
-	mockStorage.On("SaveBatch", mock.Anything).Return([]string{"abc123", "def456"}, nil)
+	mockStorage.On("SaveBatch", mock.Anything, mock.Anything).Return([]storage.BatchResult{{ShortURL: "abc123"}, {ShortURL: "def456"}}, nil)
 	req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewReader(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
-	handler := http.HandlerFunc(handlers.BatchShortenURLHandler(mockStorage))
+	handler := http.HandlerFunc(handlers.BatchShortenURLHandler(service.NewShortener(mockStorage, handlers.Flags.BaseShortAddr, nil)))
 	handler.ServeHTTP(w, req)
 
 	resp := w.Result()
@@ -360,7 +364,7 @@ func TestBatchShortenURLHandler_Fail(t *testing.T) {
 			mockStorage := new(mocks.MockStorage)
 			tt.mockBehavior(mockStorage)
 
-			handler := handlers.BatchShortenURLHandler(mockStorage)
+			handler := handlers.BatchShortenURLHandler(service.NewShortener(mockStorage, handlers.Flags.BaseShortAddr, nil))
 
 			var reqBody []byte
 			var err error
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// migrateUsage описывает подкоманду управления миграциями.
+const migrateUsage = "usage: shortener migrate up|down|status [flags]"
+
+// splitCommand отделяет подкоманду "<name> <action>" от флагов.
+// Возвращает действие, оставшиеся аргументы и признак того, что подкоманда задана.
+func splitCommand(args []string, name string) (string, []string, bool) {
+	if len(args) < 2 || args[1] != name {
+		return "", args, false
+	}
+	if len(args) < 3 {
+		return "", args[:1], true
+	}
+	rest := append([]string{args[0]}, args[3:]...)
+	return args[2], rest, true
+}
+
+// runMigrate выполняет действие над миграциями базы данных dsn и выводит результат в out.
+func runMigrate(ctx context.Context, dsn, action string, out io.Writer) error {
+	if dsn == "" {
+		return errors.New("database DSN is required for migrations")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := storage.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	return migrate(ctx, migrator, action, out)
+}
+
+// migrate выполняет действие action с помощью migrator.
+func migrate(ctx context.Context, migrator *storage.Migrator, action string, out io.Writer) error {
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Applied %d migrations\n", applied)
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Rolled back %04d_%s\n", migration.Version, migration.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommand(t *testing.T) {
+	action, args, ok := splitCommand([]string{"shortener", "migrate", "up", "-d", "dsn"}, "migrate")
+	assert.True(t, ok)
+	assert.Equal(t, "up", action)
+	assert.Equal(t, []string{"shortener", "-d", "dsn"}, args)
+
+	action, args, ok = splitCommand([]string{"shortener", "keys"}, "keys")
+	assert.True(t, ok)
+	assert.Empty(t, action)
+	assert.Equal(t, []string{"shortener"}, args)
+
+	_, args, ok = splitCommand([]string{"shortener", "-a", ":8080"}, "migrate")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"shortener", "-a", ":8080"}, args)
+}
+
+func TestRunMigrate_RequiresDSN(t *testing.T) {
+	err := runMigrate(context.Background(), "", "up", nil)
+	assert.Error(t, err)
+}
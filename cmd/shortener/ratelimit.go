@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mi4r/go-url-shortener/cmd/config"
+	"github.com/mi4r/go-url-shortener/internal/ratelimit"
+)
+
+// loadRateLimits создаёт ограничители частоты запросов из конфигурации.
+// Для класса маршрутов без заданного лимита ограничение отключено.
+func loadRateLimits(flags *config.Flags) (ratelimit.Limits, error) {
+	var limits ratelimit.Limits
+	for _, class := range []struct {
+		name    string
+		value   string
+		limiter **ratelimit.Limiter
+	}{
+		{"shorten", flags.RateShorten, &limits.Shorten},
+		{"batch", flags.RateBatch, &limits.Batch},
+		{"redirect", flags.RateRedirect, &limits.Redirect},
+	} {
+		if class.value == "" {
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(class.value)
+		if err != nil {
+			return ratelimit.Limits{}, fmt.Errorf("%s: %w", class.name, err)
+		}
+		*class.limiter = ratelimit.NewLimiter(limit)
+	}
+	return limits, nil
+}
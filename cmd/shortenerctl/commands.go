@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// usage описывает команды утилиты.
+const usage = `usage: shortenerctl <command> [flags] [args]
+
+commands:
+  lookup <id>                     show a link
+  disable <id>...                 disable links (mark them deleted)
+  enable <id>...                  re-enable disabled links
+  reassign <from> <to> [<id>...]  move all or listed links of user <from> to user <to>
+  list <user>                     list links of a user
+  purge [<older-than>]            permanently remove deleted links, e.g. "purge 720h"
+  stats [<id>]                    print totals or click stats of a link
+
+flags are the same as for the shortener service, e.g. -d <dsn>, -bolt <db> or -f <file>`
+
+// errUsage возвращается для неизвестной команды или неверных аргументов.
+var errUsage = errors.New(usage)
+
+// run выполняет команду command с аргументами args над хранилищем store и
+// выводит результат в out. now — текущий момент для команды purge.
+func run(ctx context.Context, store storage.Storage, command string, args []string, now time.Time, out io.Writer) error {
+	switch command {
+	case "lookup":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return lookup(ctx, store, args[0], now, out)
+	case "disable", "enable":
+		if len(args) == 0 {
+			return errUsage
+		}
+		admin, err := adminOf(store)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, id := range args {
+			if err := admin.SetURLDeleted(ctx, id, command == "disable"); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", id, err))
+				continue
+			}
+			fmt.Fprintf(out, "%sd %s\n", command, id)
+		}
+		return errors.Join(errs...)
+	case "reassign":
+		if len(args) < 2 {
+			return errUsage
+		}
+		admin, err := adminOf(store)
+		if err != nil {
+			return err
+		}
+		moved, err := admin.ReassignURLs(ctx, args[0], args[1], args[2:])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "reassigned %d links from %s to %s\n", moved, args[0], args[1])
+	case "list":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return list(ctx, store, args[0], now, out)
+	case "purge":
+		if len(args) > 1 {
+			return errUsage
+		}
+		var olderThan time.Duration
+		if len(args) == 1 {
+			d, err := time.ParseDuration(args[0])
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid age %q: want a non-negative duration such as 720h", args[0])
+			}
+			olderThan = d
+		}
+		admin, err := adminOf(store)
+		if err != nil {
+			return err
+		}
+		purged, err := admin.PurgeDeleted(ctx, now.Add(-olderThan))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "purged %d links\n", purged)
+	case "stats":
+		switch len(args) {
+		case 0:
+			return totals(ctx, store, out)
+		case 1:
+			return linkStats(ctx, store, args[0], out)
+		default:
+			return errUsage
+		}
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+// adminOf возвращает операции обслуживания хранилища.
+func adminOf(store storage.Storage) (storage.Admin, error) {
+	admin, ok := store.(storage.Admin)
+	if !ok {
+		return nil, fmt.Errorf("storage %T does not support maintenance commands", store)
+	}
+	return admin, nil
+}
+
+// lookup выводит ссылку id, в том числе отключённую.
+func lookup(ctx context.Context, store storage.Storage, id string, now time.Time, out io.Writer) error {
+	url, err := store.Get(ctx, id)
+	if err != nil && !errors.Is(err, storage.ErrGone) {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "short_url:\t%s\n", url.ShortURL)
+	fmt.Fprintf(w, "original_url:\t%s\n", url.OriginalURL)
+	fmt.Fprintf(w, "user_id:\t%s\n", url.UserID)
+	fmt.Fprintf(w, "state:\t%s\n", state(url, now))
+	fmt.Fprintf(w, "created_at:\t%s\n", formatTime(url.CreatedAt))
+	fmt.Fprintf(w, "updated_at:\t%s\n", formatTime(url.UpdatedAt))
+	fmt.Fprintf(w, "expires_at:\t%s\n", formatTime(url.ExpiresAt))
+	fmt.Fprintf(w, "deleted_at:\t%s\n", formatTime(url.DeletedAt))
+	return w.Flush()
+}
+
+// list выводит ссылки пользователя в порядке создания.
+func list(ctx context.Context, store storage.Storage, userID string, now time.Time, out io.Writer) error {
+	urls, err := store.GetURLsByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if !urls[i].CreatedAt.Equal(urls[j].CreatedAt) {
+			return urls[i].CreatedAt.Before(urls[j].CreatedAt)
+		}
+		return urls[i].ShortURL < urls[j].ShortURL
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SHORT_URL\tSTATE\tCREATED_AT\tORIGINAL_URL")
+	for _, url := range urls {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", url.ShortURL, state(url, now), formatTime(url.CreatedAt), url.OriginalURL)
+	}
+	return w.Flush()
+}
+
+// totals выводит число ссылок и пользователей.
+func totals(ctx context.Context, store storage.Storage, out io.Writer) error {
+	urls, err := store.URLCount(ctx)
+	if err != nil {
+		return err
+	}
+	users, err := store.UserCount(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "urls: %d\nusers: %d\n", urls, users)
+	return nil
+}
+
+// linkStats выводит статистику переходов по ссылке id.
+func linkStats(ctx context.Context, store storage.Storage, id string, out io.Writer) error {
+	if _, err := store.Get(ctx, id); err != nil && !errors.Is(err, storage.ErrGone) {
+		return err
+	}
+	stats, err := store.GetLinkStats(ctx, id)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "clicks:\t%d\n", stats.Clicks)
+	fmt.Fprintf(w, "first_click:\t%s\n", formatTime(stats.FirstClick))
+	fmt.Fprintf(w, "last_click:\t%s\n", formatTime(stats.LastClick))
+	for _, breakdown := range []struct {
+		name   string
+		counts map[string]int
+	}{
+		{"referrers", stats.Referrers},
+		{"user_agents", stats.UserAgents},
+		{"countries", stats.Countries},
+	} {
+		fmt.Fprintf(w, "%s:\n", breakdown.name)
+		keys := make([]string, 0, len(breakdown.counts))
+		for key := range breakdown.counts {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			ci, cj := breakdown.counts[keys[i]], breakdown.counts[keys[j]]
+			if ci != cj {
+				return ci > cj
+			}
+			return keys[i] < keys[j]
+		})
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s\t%d\n", key, breakdown.counts[key])
+		}
+	}
+	return w.Flush()
+}
+
+// state возвращает состояние ссылки: active, disabled или expired.
+func state(url storage.URL, now time.Time) string {
+	switch {
+	case url.DeletedFlag:
+		return "disabled"
+	case url.Expired(now):
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
+// formatTime форматирует время в RFC 3339, нулевое время — как "-".
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/cmd/config"
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+func newTestStorage(t *testing.T) storage.Storage {
+	logger.Sugar = *zap.NewNop().Sugar()
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	for _, url := range []storage.URL{
+		{ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"},
+		{ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"},
+		{ShortURL: "b1", OriginalURL: "https://c.com", UserID: "bob"},
+	} {
+		_, err := store.Save(ctx, url)
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.SaveClicks(ctx, []storage.Click{
+		{ShortURL: "a1", ClickedAt: time.Now(), Referrer: "https://news.example"},
+		{ShortURL: "a1", ClickedAt: time.Now(), Country: "DE"},
+	}))
+	return store
+}
+
+// runCommand выполняет команду и возвращает её вывод.
+func runCommand(t *testing.T, store storage.Storage, command string, args ...string) (string, error) {
+	var out bytes.Buffer
+	err := run(context.Background(), store, command, args, time.Now(), &out)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	store := newTestStorage(t)
+
+	out, err := runCommand(t, store, "lookup", "a1")
+	require.NoError(t, err)
+	assert.Contains(t, out, "https://a.com")
+	assert.Regexp(t, `state:\s+active`, out)
+
+	out, err = runCommand(t, store, "disable", "a1", "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Equal(t, "disabled a1\n", out)
+	out, err = runCommand(t, store, "lookup", "a1")
+	require.NoError(t, err)
+	assert.Regexp(t, `state:\s+disabled`, out)
+
+	out, err = runCommand(t, store, "list", "alice")
+	require.NoError(t, err)
+	assert.Regexp(t, `a1\s+disabled`, out)
+	assert.Regexp(t, `a2\s+active`, out)
+
+	_, err = runCommand(t, store, "enable", "a1")
+	require.NoError(t, err)
+	_, err = store.Get(context.Background(), "a1")
+	require.NoError(t, err)
+
+	out, err = runCommand(t, store, "reassign", "alice", "bob", "a2")
+	require.NoError(t, err)
+	assert.Equal(t, "reassigned 1 links from alice to bob\n", out)
+	url, err := store.Get(context.Background(), "a2")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", url.UserID)
+
+	_, err = runCommand(t, store, "disable", "b1")
+	require.NoError(t, err)
+	out, err = runCommand(t, store, "purge", "1h")
+	require.NoError(t, err)
+	assert.Equal(t, "purged 0 links\n", out)
+	out, err = runCommand(t, store, "purge")
+	require.NoError(t, err)
+	assert.Equal(t, "purged 1 links\n", out)
+
+	out, err = runCommand(t, store, "stats")
+	require.NoError(t, err)
+	assert.Equal(t, "urls: 2\nusers: 2\n", out)
+
+	out, err = runCommand(t, store, "stats", "a1")
+	require.NoError(t, err)
+	assert.Regexp(t, `clicks:\s+2`, out)
+	assert.Regexp(t, `https://news.example\s+1`, out)
+	assert.Regexp(t, `DE\s+1`, out)
+
+	_, err = runCommand(t, store, "stats", "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestRun_Usage(t *testing.T) {
+	store := newTestStorage(t)
+	for _, args := range [][]string{
+		{"unknown"},
+		{"lookup"},
+		{"disable"},
+		{"reassign", "alice"},
+		{"list"},
+		{"stats", "a1", "a2"},
+	} {
+		_, err := runCommand(t, store, args[0], args[1:]...)
+		assert.ErrorIs(t, err, errUsage, "args %v", args)
+	}
+	_, err := runCommand(t, store, "purge", "soon")
+	assert.ErrorContains(t, err, "invalid age")
+}
+
+func TestOpenStorage(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+
+	_, err := openStorage(&config.Flags{})
+	assert.ErrorContains(t, err, "no storage configured")
+
+	_, err = openStorage(&config.Flags{URLStorageFilePath: filepath.Join(t.TempDir(), "urls.json"), DedupMode: "tenant"})
+	assert.Error(t, err)
+
+	store, err := openStorage(&config.Flags{URLStorageFilePath: filepath.Join(t.TempDir(), "urls.json")})
+	require.NoError(t, err)
+	defer store.Close()
+	assert.IsType(t, &storage.FileStorage{}, store)
+
+	store, err = openStorage(&config.Flags{BoltStoragePath: filepath.Join(t.TempDir(), "urls.db")})
+	require.NoError(t, err)
+	defer store.Close()
+	assert.IsType(t, &storage.BoltStorage{}, store)
+}
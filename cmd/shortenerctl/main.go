@@ -0,0 +1,88 @@
+// Package main реализует shortenerctl — утилиту обслуживания сервиса сокращения URL.
+// Утилита работает напрямую с хранилищем, которое выбирается теми же флагами,
+// переменными окружения и файлом конфигурации, что и у сервиса: база данных
+// (-d, DATABASE_DSN), база bbolt (-bolt, BOLT_STORAGE_PATH) или файл
+// (-f, FILE_STORAGE_PATH). Базу bbolt нельзя открыть, пока её использует сервис.
+//
+// Использование:
+//
+//	shortenerctl <command> [flags] [args]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/cmd/config"
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func main() {
+	logger.Sugar = *zap.NewNop().Sugar()
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	os.Args = append([]string{os.Args[0]}, os.Args[2:]...)
+	flags := config.Init()
+
+	store, err := openStorage(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "shortenerctl:", err)
+		os.Exit(1)
+	}
+
+	err = run(context.Background(), store, command, flag.Args(), time.Now(), os.Stdout)
+	store.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "shortenerctl:", err)
+		os.Exit(1)
+	}
+}
+
+// openStorage открывает хранилище из конфигурации с тем же приоритетом, что
+// у сервиса: база данных, bbolt, затем файл. В отличие от сервиса, при ошибке не
+// переключается на другое хранилище, а хранилище в памяти не используется.
+func openStorage(flags *config.Flags) (storage.Storage, error) {
+	mode, err := storage.ParseDedupMode(flags.DedupMode)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case flags.DataBaseDSN != "":
+		db, err := storage.NewDBStorage(flags.DataBaseDSN, storage.WithDedupMode(mode))
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case flags.BoltStoragePath != "":
+		bolt, err := storage.NewBoltStorage(flags.BoltStoragePath, storage.WithDedupMode(mode))
+		if err != nil {
+			return nil, err
+		}
+		return bolt, nil
+	case flags.URLStorageFilePath != "":
+		policy, err := storage.ParseSyncPolicy(flags.FileSync)
+		if err != nil {
+			return nil, err
+		}
+		file, err := storage.NewFileStorage(flags.URLStorageFilePath, storage.WithDedupMode(mode), storage.WithSyncPolicy(policy))
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	default:
+		return nil, errors.New("no storage configured: set -d (DATABASE_DSN), -bolt (BOLT_STORAGE_PATH) or -f (FILE_STORAGE_PATH)")
+	}
+}
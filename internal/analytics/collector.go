@@ -0,0 +1,132 @@
+// Package analytics собирает статистику переходов по коротким ссылкам.
+// Переходы принимаются через буферизованный канал и сохраняются в хранилище
+// пакетами в фоновой горутине, поэтому обработка редиректа не ждёт записи.
+package analytics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// Значения Collector по умолчанию.
+const (
+	DefaultBufferSize    = 1024
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+
+	// drainTimeout ограничивает время сохранения оставшихся переходов при остановке.
+	drainTimeout = 5 * time.Second
+)
+
+// ClickStore сохраняет пакеты переходов. Реализуется storage.Storage.
+type ClickStore interface {
+	SaveClicks(ctx context.Context, clicks []storage.Click) error
+}
+
+// Collector асинхронно собирает переходы и сохраняет их в ClickStore.
+type Collector struct {
+	store         ClickStore
+	geo           GeoResolver
+	clicks        chan storage.Click
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Int64
+}
+
+// NewCollector создаёт сборщик с буфером на bufferSize переходов.
+// geo может быть nil, тогда страна не определяется.
+func NewCollector(store ClickStore, geo GeoResolver, bufferSize int) *Collector {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Collector{
+		store:         store,
+		geo:           geo,
+		clicks:        make(chan storage.Click, bufferSize),
+		batchSize:     DefaultBatchSize,
+		flushInterval: DefaultFlushInterval,
+	}
+}
+
+// Track ставит переход в очередь на сохранение. Не блокируется: если буфер
+// заполнен, переход отбрасывается и учитывается в Dropped.
+func (c *Collector) Track(click storage.Click) {
+	if click.ClickedAt.IsZero() {
+		click.ClickedAt = time.Now()
+	}
+	select {
+	case c.clicks <- click:
+	default:
+		c.dropped.Add(1)
+	}
+}
+
+// Dropped возвращает число переходов, отброшенных из-за переполнения буфера.
+func (c *Collector) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Run сохраняет переходы пакетами по batchSize или раз в flushInterval.
+// После отмены ctx сохраняет оставшиеся в буфере переходы и возвращает управление.
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]storage.Click, 0, c.batchSize)
+	for {
+		select {
+		case click := <-c.clicks:
+			batch = append(batch, c.resolve(click))
+			if len(batch) >= c.batchSize {
+				batch = c.flush(ctx, batch)
+			}
+		case <-ticker.C:
+			batch = c.flush(ctx, batch)
+		case <-ctx.Done():
+			c.drain(batch)
+			return
+		}
+	}
+}
+
+// drain сохраняет пакет и все переходы, оставшиеся в буфере.
+func (c *Collector) drain(batch []storage.Click) {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case click := <-c.clicks:
+			batch = append(batch, c.resolve(click))
+			if len(batch) >= c.batchSize {
+				batch = c.flush(ctx, batch)
+			}
+		default:
+			c.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+// flush сохраняет пакет и возвращает пустой срез для следующего пакета.
+func (c *Collector) flush(ctx context.Context, batch []storage.Click) []storage.Click {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := c.store.SaveClicks(ctx, batch); err != nil {
+		logger.Sugar.Error("Failed to save clicks: ", err)
+	}
+	return make([]storage.Click, 0, c.batchSize)
+}
+
+// resolve определяет страну перехода по IP-адресу.
+func (c *Collector) resolve(click storage.Click) storage.Click {
+	if c.geo != nil && click.Country == "" && click.IP != nil {
+		click.Country = c.geo.Country(click.IP)
+	}
+	return click
+}
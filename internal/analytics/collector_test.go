@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+	"go.uber.org/zap"
+)
+
+type recordingStore struct {
+	mu      sync.Mutex
+	batches [][]storage.Click
+}
+
+func (s *recordingStore) SaveClicks(_ context.Context, clicks []storage.Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, clicks)
+	return nil
+}
+
+func (s *recordingStore) clicks() []storage.Click {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []storage.Click
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+type staticGeo string
+
+func (g staticGeo) Country(net.IP) string { return string(g) }
+
+func TestCollector_FlushesOnStop(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	store := &recordingStore{}
+	c := NewCollector(store, staticGeo("DE"), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 5; i++ {
+		c.Track(storage.Click{ShortURL: "abc", IP: net.ParseIP("10.0.0.1")})
+	}
+	cancel()
+	<-done
+
+	clicks := store.clicks()
+	require.Len(t, clicks, 5)
+	assert.Equal(t, "DE", clicks[0].Country)
+	assert.False(t, clicks[0].ClickedAt.IsZero())
+}
+
+func TestCollector_FlushesByBatchSize(t *testing.T) {
+	store := &recordingStore{}
+	c := NewCollector(store, nil, 10)
+	c.batchSize = 2
+	c.flushInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	c.Track(storage.Click{ShortURL: "a"})
+	c.Track(storage.Click{ShortURL: "b"})
+
+	assert.Eventually(t, func() bool { return len(store.clicks()) == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestCollector_DropsWhenFull(t *testing.T) {
+	c := NewCollector(&recordingStore{}, nil, 1)
+
+	c.Track(storage.Click{ShortURL: "a"})
+	c.Track(storage.Click{ShortURL: "b"})
+
+	assert.Equal(t, int64(1), c.Dropped())
+}
@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GeoResolver определяет код страны по IP-адресу.
+type GeoResolver interface {
+	// Country возвращает код страны или пустую строку, если страна неизвестна.
+	Country(ip net.IP) string
+}
+
+// ipRange описывает диапазон адресов [start, end], относящийся к стране.
+type ipRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	country string
+}
+
+// IPRanges определяет страну по таблице диапазонов IP-адресов.
+type IPRanges struct {
+	ranges []ipRange // Отсортированы по start и не пересекаются.
+}
+
+// LoadIPRanges читает таблицу диапазонов в формате CSV: "start_ip,end_ip,country".
+// Строки, начинающиеся с #, пропускаются.
+func LoadIPRanges(r io.Reader) (*IPRanges, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var ranges []ipRange
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, err := netip.ParseAddr(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start %q: %w", record[0], err)
+		}
+		end, err := netip.ParseAddr(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range end %q: %w", record[1], err)
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("invalid range %s-%s", start, end)
+		}
+		ranges = append(ranges, ipRange{start: start, end: end, country: strings.ToUpper(record[2])})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Less(ranges[j].start) })
+	for i := 1; i < len(ranges); i++ {
+		if !ranges[i-1].end.Less(ranges[i].start) {
+			return nil, fmt.Errorf("overlapping ranges %s-%s and %s-%s",
+				ranges[i-1].start, ranges[i-1].end, ranges[i].start, ranges[i].end)
+		}
+	}
+	return &IPRanges{ranges: ranges}, nil
+}
+
+// LoadIPRangesFile читает таблицу диапазонов из файла.
+func LoadIPRangesFile(path string) (*IPRanges, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadIPRanges(file)
+}
+
+// Country возвращает код страны для ip или пустую строку.
+func (g *IPRanges) Country(ip net.IP) string {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// Первый диапазон, который заканчивается не раньше addr.
+	i := sort.Search(len(g.ranges), func(i int) bool { return !g.ranges[i].end.Less(addr) })
+	if i < len(g.ranges) && !addr.Less(g.ranges[i].start) {
+		return g.ranges[i].country
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRanges = `# start,end,country
+5.0.0.0,5.255.255.255,ru
+1.0.0.0,1.0.0.255,AU
+2001:db8::,2001:db8::ffff,DE
+`
+
+func TestIPRanges_Country(t *testing.T) {
+	geo, err := LoadIPRanges(strings.NewReader(testRanges))
+	require.NoError(t, err)
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"5.1.2.3", "RU"},
+		{"1.0.0.0", "AU"},
+		{"1.0.0.255", "AU"},
+		{"1.0.1.0", ""},
+		{"::ffff:5.0.0.1", "RU"},
+		{"2001:db8::1", "DE"},
+		{"2001:db9::1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, geo.Country(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestLoadIPRanges_Invalid(t *testing.T) {
+	_, err := LoadIPRanges(strings.NewReader("5.0.0.10,5.0.0.1,RU\n"))
+	assert.Error(t, err)
+
+	_, err = LoadIPRanges(strings.NewReader("5.0.0.0,5.0.0.10,RU\n5.0.0.5,5.0.0.20,DE\n"))
+	assert.Error(t, err)
+
+	_, err = LoadIPRanges(strings.NewReader("not-an-ip,5.0.0.1,RU\n"))
+	assert.Error(t, err)
+}
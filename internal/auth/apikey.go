@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// apiKeyPrefix отличает API-ключи от других токенов в логах и конфигурации.
+	apiKeyPrefix = "usk_"
+	// apiKeyBytes — число случайных байт в API-ключе.
+	apiKeyBytes = 32
+)
+
+// userIDKey — ключ контекста для идентификатора пользователя, установленного аутентификацией.
+type userIDKey struct{}
+
+// GenerateAPIKey создаёт новый случайный API-ключ.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAPIKey возвращает хеш API-ключа, под которым ключ хранится в хранилище.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken извлекает токен из значения заголовка Authorization вида "Bearer <token>".
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// WithUserID возвращает контекст с идентификатором аутентифицированного пользователя.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext возвращает идентификатор пользователя, установленный WithUserID.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	return userID, ok && userID != ""
+}
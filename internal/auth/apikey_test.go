@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	first, err := GenerateAPIKey()
+	require.NoError(t, err)
+	second, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, apiKeyPrefix))
+	assert.NotEqual(t, first, second)
+	assert.Len(t, HashAPIKey(first), 64)
+	assert.Equal(t, HashAPIKey(first), HashAPIKey(first))
+	assert.NotEqual(t, HashAPIKey(first), HashAPIKey(second))
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{header: "Bearer abc", token: "abc", ok: true},
+		{header: "bearer  abc ", token: "abc", ok: true},
+		{header: "Basic abc"},
+		{header: "Bearer"},
+		{header: ""},
+	}
+	for _, tt := range tests {
+		token, ok := BearerToken(tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		assert.Equal(t, tt.token, token, tt.header)
+	}
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	_, ok := UserIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	userID, ok := UserIDFromContext(WithUserID(context.Background(), "user1"))
+	assert.True(t, ok)
+	assert.Equal(t, "user1", userID)
+}
@@ -1,10 +1,8 @@
-// Package auth предоставляет функциональность для работы с аутентификацией пользователей через куки.
+// Package auth предоставляет функциональность для работы с аутентификацией пользователей
+// через подписанные куки и API-ключи.
 package auth
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"net/http"
 	"strings"
 	"time"
@@ -12,32 +10,54 @@ import (
 	"github.com/google/uuid"
 )
 
-const (
-	// cookieName - название куки для хранения идентификатора пользователя.
-	cookieName = "user_id"
-	// secretKey - секретный ключ для подписи идентификатора пользователя.
-	secretKey = "super-secret-key"
-)
+// cookieName - название куки для хранения идентификатора пользователя.
+const cookieName = "user_id"
 
 // GenerateUserID генерирует новый уникальный идентификатор пользователя.
 func GenerateUserID() string {
 	return uuid.New().String()
 }
 
-// SignUserID создает подпись для идентификатора пользователя.
+// SignUserID создает подпись для идентификатора пользователя активным ключом.
 func SignUserID(userID string) string {
-	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(userID))
-	return hex.EncodeToString(h.Sum(nil))
+	_, signature := CurrentKeyring().Sign(userID)
+	return signature
+}
+
+// IssueToken возвращает подписанный активным ключом токен пользователя вида
+// "userID|keyID|signature". Тот же формат используется в куке и в метаданных gRPC.
+func IssueToken(userID string) string {
+	keyID, signature := CurrentKeyring().Sign(userID)
+	return userID + "|" + keyID + "|" + signature
+}
+
+// VerifyToken проверяет токен и возвращает идентификатор пользователя.
+// current сообщает, подписан ли токен активным ключом. Токены старого
+// формата "userID|signature" проверяются всеми ключами набора.
+func VerifyToken(token string) (userID string, current, ok bool) {
+	var keyID, signature string
+	parts := strings.Split(token, "|")
+	switch len(parts) {
+	case 2:
+		userID, signature = parts[0], parts[1]
+	case 3:
+		userID, keyID, signature = parts[0], parts[1], parts[2]
+	default:
+		return "", false, false
+	}
+
+	keys := CurrentKeyring()
+	if userID == "" || !keys.Verify(userID, keyID, signature) {
+		return "", false, false
+	}
+	return userID, keyID == keys.Active(), true
 }
 
 // SetUserCookie устанавливает пользователю подписанную куку с его идентификатором.
 func SetUserCookie(w http.ResponseWriter, userID string) {
-	signature := SignUserID(userID)
-	cookieValue := userID + "|" + signature
 	cookie := &http.Cookie{
 		Name:     cookieName,
-		Value:    cookieValue,
+		Value:    IssueToken(userID),
 		Expires:  time.Now().Add(24 * time.Hour * 365), // Кука действует 1 год
 		HttpOnly: true,
 		Secure:   false,
@@ -47,32 +67,31 @@ func SetUserCookie(w http.ResponseWriter, userID string) {
 
 // ValidateUserCookie проверяет подлинность куки и возвращает идентификатор пользователя.
 func ValidateUserCookie(r *http.Request) (string, bool) {
+	userID, _, ok := parseUserCookie(r)
+	return userID, ok
+}
+
+// parseUserCookie проверяет куку и сообщает, подписана ли она активным ключом.
+func parseUserCookie(r *http.Request) (userID string, current, ok bool) {
 	cookie, err := r.Cookie(cookieName)
 	if err != nil {
-		return "", false
-	}
-
-	parts := strings.Split(cookie.Value, "|")
-	if len(parts) != 2 {
-		return "", false
+		return "", false, false
 	}
-
-	userID := parts[0]
-	signature := parts[1]
-
-	expectedSignature := SignUserID(userID)
-	if hmac.Equal([]byte(expectedSignature), []byte(signature)) {
-		return userID, true
-	}
-
-	return "", false
+	return VerifyToken(cookie.Value)
 }
 
-// UpdateCookie создает или обновляет куки
+// UpdateCookie создает или обновляет куки. Если пользователь уже
+// аутентифицирован API-ключом, кука не используется. Куки, подписанные
+// неактивным ключом, переподписываются активным.
 func UpdateCookie(w http.ResponseWriter, r *http.Request) string {
-	userID, valid := ValidateUserCookie(r)
+	if userID, ok := UserIDFromContext(r.Context()); ok {
+		return userID
+	}
+	userID, current, valid := parseUserCookie(r)
 	if !valid {
 		userID = GenerateUserID()
+	}
+	if !current {
 		SetUserCookie(w, userID)
 	}
 	return userID
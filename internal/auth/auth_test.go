@@ -21,6 +21,13 @@ func TestGenerateUserID(t *testing.T) {
 }
 
 func TestSignUserID(t *testing.T) {
+	keys, err := NewSecretKeyring("super-secret-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer SetKeyring(CurrentKeyring())
+	SetKeyring(keys)
+
 	userID := "test-user-id"
 	signature := SignUserID(userID)
 
@@ -51,13 +58,16 @@ func TestSetUserCookie(t *testing.T) {
 	}
 
 	parts := strings.Split(cookie[0].Value, "|")
-	if len(parts) != 2 {
-		t.Errorf("Cookie value should contain two parts separated by '|', got: %s", cookie[0].Value)
+	if len(parts) != 3 {
+		t.Fatalf("Cookie value should contain three parts separated by '|', got: %s", cookie[0].Value)
 	}
 
+	if parts[1] != CurrentKeyring().Active() {
+		t.Errorf("Unexpected key ID in cookie: got %s, want %s", parts[1], CurrentKeyring().Active())
+	}
 	signature := SignUserID(parts[0])
-	if parts[1] != signature {
-		t.Errorf("Invalid signature in cookie: got %s, want %s", parts[1], signature)
+	if parts[2] != signature {
+		t.Errorf("Invalid signature in cookie: got %s, want %s", parts[2], signature)
 	}
 }
 
@@ -134,3 +144,13 @@ func TestUpdateCookie(t *testing.T) {
 	updatedUserID := UpdateCookie(httptest.NewRecorder(), reqWithCookie)
 	assert.Equal(t, userID, updatedUserID, "userID должен совпадать при валидной куке")
 }
+
+func TestUpdateCookie_APIKeyUser(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(WithUserID(req.Context(), "key-owner"))
+
+	userID := UpdateCookie(recorder, req)
+	assert.Equal(t, "key-owner", userID)
+	assert.Empty(t, recorder.Result().Cookies(), "кука не должна устанавливаться для API-ключа")
+}
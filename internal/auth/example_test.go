@@ -24,6 +24,9 @@ func ExampleSignUserID() {
 }
 
 func ExampleSetUserCookie() {
+	keys, _ := auth.NewSecretKeyring("super-secret-key")
+	auth.SetKeyring(keys)
+
 	userID := "example-user-id"
 	w := httptest.NewRecorder()
 	auth.SetUserCookie(w, userID)
@@ -40,7 +43,7 @@ func ExampleSetUserCookie() {
 	}
 	// Output:
 	// user_id
-	// example-user-id|64a3d29d7b002efbfa93eb795869918e97b3251874aded4b7735ee22443273c8
+	// example-user-id|default|64a3d29d7b002efbfa93eb795869918e97b3251874aded4b7735ee22443273c8
 }
 
 func ExampleValidateUserCookie() {
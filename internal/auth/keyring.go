@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// keySize — длина ключей, создаваемых при ротации.
+const keySize = 32
+
+// ErrInvalidKeyring возвращается для пустого или противоречивого набора ключей.
+var ErrInvalidKeyring = errors.New("invalid keyring")
+
+// ErrActiveKey возвращается при попытке вывести из набора активный ключ.
+var ErrActiveKey = errors.New("cannot retire the active key")
+
+// ErrUnknownKey возвращается, если ключа с указанным ID нет в наборе.
+var ErrUnknownKey = errors.New("unknown key")
+
+// Keyring хранит ключи подписи кук. Новые куки подписываются активным ключом,
+// а проверяются любым ключом набора, поэтому после ротации старые куки
+// остаются действительными, пока их ключ не удалён из набора.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// keyringFile — формат файла с набором ключей. Ключи кодируются в base64.
+type keyringFile struct {
+	Active string            `json:"active"`
+	Keys   map[string][]byte `json:"keys"`
+}
+
+// NewKeyring создаёт набор ключей с активным ключом active.
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("%w: active key %q is missing", ErrInvalidKeyring, active)
+	}
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, "|") || len(key) == 0 {
+			return nil, fmt.Errorf("%w: bad key %q", ErrInvalidKeyring, id)
+		}
+		copied[id] = append([]byte(nil), key...)
+	}
+	return &Keyring{active: active, keys: copied}, nil
+}
+
+// NewSecretKeyring создаёт набор из одного ключа, заданного в конфигурации.
+func NewSecretKeyring(secret string) (*Keyring, error) {
+	return NewKeyring("default", map[string][]byte{"default": []byte(secret)})
+}
+
+// NewRandomKeyring создаёт набор из одного случайного ключа. Куки, подписанные
+// им, становятся недействительными после перезапуска сервиса.
+func NewRandomKeyring() (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte)}
+	if _, err := k.Rotate(time.Now()); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// LoadKeyring читает набор ключей из JSON-файла.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeyring, err)
+	}
+	return NewKeyring(file.Active, file.Keys)
+}
+
+// Save атомарно записывает набор ключей в файл, доступный только владельцу.
+func (k *Keyring) Save(path string) error {
+	data, err := json.MarshalIndent(keyringFile{Active: k.active, Keys: k.keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Rotate добавляет новый случайный ключ, делает его активным и возвращает его ID.
+// Прежние ключи остаются в наборе для проверки выданных ранее кук.
+func (k *Keyring) Rotate(now time.Time) (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	id := now.UTC().Format("20060102T150405")
+	for suffix := 2; ; suffix++ {
+		if _, exists := k.keys[id]; !exists {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.UTC().Format("20060102T150405"), suffix)
+	}
+	k.keys[id] = key
+	k.active = id
+	return id, nil
+}
+
+// Retire удаляет ключ id из набора. Куки, подписанные им, перестают
+// проверяться. Активный ключ удалить нельзя: сначала нужна ротация.
+func (k *Keyring) Retire(id string) error {
+	if id == k.active {
+		return fmt.Errorf("%w %q", ErrActiveKey, id)
+	}
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Active возвращает ID активного ключа.
+func (k *Keyring) Active() string {
+	return k.active
+}
+
+// IDs возвращает отсортированные ID всех ключей набора.
+func (k *Keyring) IDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Sign подписывает идентификатор пользователя активным ключом.
+func (k *Keyring) Sign(userID string) (keyID, signature string) {
+	return k.active, sign(k.keys[k.active], userID)
+}
+
+// Verify проверяет подпись ключом keyID. Если keyID пуст (куки старого
+// формата), подпись проверяется всеми ключами набора.
+func (k *Keyring) Verify(userID, keyID, signature string) bool {
+	if keyID != "" {
+		key, ok := k.keys[keyID]
+		return ok && hmac.Equal([]byte(sign(key, userID)), []byte(signature))
+	}
+	for _, key := range k.keys {
+		if hmac.Equal([]byte(sign(key, userID)), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign вычисляет HMAC-SHA256 идентификатора пользователя.
+func sign(key []byte, userID string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(userID))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// keyring — набор ключей, которым подписываются и проверяются куки.
+var keyring atomic.Pointer[Keyring]
+
+func init() {
+	k, err := NewRandomKeyring()
+	if err != nil {
+		panic(err)
+	}
+	keyring.Store(k)
+}
+
+// SetKeyring задаёт набор ключей для подписи кук.
+func SetKeyring(k *Keyring) {
+	keyring.Store(k)
+}
+
+// CurrentKeyring возвращает набор ключей, которым подписываются куки.
+func CurrentKeyring() *Keyring {
+	return keyring.Load()
+}
+
+// WatchKeyring перечитывает набор ключей из файла path при каждом сигнале из
+// signals, пока не отменён ctx. При ошибке загрузки продолжает действовать
+// прежний набор.
+func WatchKeyring(ctx context.Context, path string, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			k, err := LoadKeyring(path)
+			if err != nil {
+				logger.Sugar.Error("Failed to reload cookie signing keys, keeping the previous ones: ", err)
+				continue
+			}
+			SetKeyring(k)
+			logger.Sugar.Info("Cookie signing keys reloaded from ", path)
+		}
+	}
+}
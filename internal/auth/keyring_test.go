@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// useKeyring подменяет набор ключей на время теста.
+func useKeyring(t *testing.T, k *Keyring) {
+	t.Helper()
+	previous := CurrentKeyring()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(previous) })
+}
+
+func TestNewKeyring(t *testing.T) {
+	_, err := NewKeyring("missing", map[string][]byte{"k1": []byte("secret")})
+	assert.ErrorIs(t, err, ErrInvalidKeyring)
+	_, err = NewKeyring("k|1", map[string][]byte{"k|1": []byte("secret")})
+	assert.ErrorIs(t, err, ErrInvalidKeyring)
+	_, err = NewKeyring("k1", map[string][]byte{"k1": nil})
+	assert.ErrorIs(t, err, ErrInvalidKeyring)
+}
+
+func TestKeyring_RotateKeepsOldCookies(t *testing.T) {
+	keys, err := NewSecretKeyring("old-secret")
+	require.NoError(t, err)
+	useKeyring(t, keys)
+
+	w := httptest.NewRecorder()
+	SetUserCookie(w, "user1")
+	oldCookie := w.Result().Cookies()[0]
+
+	// Кука старого формата без ID ключа.
+	legacyCookie := &http.Cookie{Name: cookieName, Value: "user2|" + SignUserID("user2")}
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	id, err := keys.Rotate(now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240506T070809", id)
+	assert.Equal(t, id, keys.Active())
+
+	second, err := keys.Rotate(now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240506T070809-2", second)
+
+	for _, tt := range []struct {
+		cookie *http.Cookie
+		userID string
+	}{
+		{oldCookie, "user1"},
+		{legacyCookie, "user2"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(tt.cookie)
+		w := httptest.NewRecorder()
+
+		// Пользователь сохраняется, а кука переподписывается активным ключом.
+		assert.Equal(t, tt.userID, UpdateCookie(w, req))
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, tt.userID+"|"+second+"|"+SignUserID(tt.userID), cookies[0].Value)
+	}
+
+	// Кука, подписанная активным ключом, не переустанавливается.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: cookieName, Value: "user1|" + second + "|" + SignUserID("user1")})
+	w = httptest.NewRecorder()
+	assert.Equal(t, "user1", UpdateCookie(w, req))
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestKeyring_UnknownKey(t *testing.T) {
+	keys, err := NewSecretKeyring("secret")
+	require.NoError(t, err)
+	useKeyring(t, keys)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: cookieName, Value: "user1|removed|" + SignUserID("user1")})
+	_, ok := ValidateUserCookie(req)
+	assert.False(t, ok)
+}
+
+func TestKeyring_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	keys, err := NewRandomKeyring()
+	require.NoError(t, err)
+	_, err = keys.Rotate(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, keys.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadKeyring(path)
+	require.NoError(t, err)
+	assert.Equal(t, keys.Active(), loaded.Active())
+	assert.Equal(t, keys.IDs(), loaded.IDs())
+	_, signature := keys.Sign("user1")
+	assert.True(t, loaded.Verify("user1", keys.Active(), signature))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"active":"x","keys":{}}`), 0600))
+	_, err = LoadKeyring(path)
+	assert.ErrorIs(t, err, ErrInvalidKeyring)
+}
+
+func TestKeyring_Retire(t *testing.T) {
+	keys, err := NewKeyring("new", map[string][]byte{"old": []byte("old-secret"), "new": []byte("new-secret")})
+	require.NoError(t, err)
+	oldID, oldSig := "old", sign([]byte("old-secret"), "user")
+
+	assert.ErrorIs(t, keys.Retire("new"), ErrActiveKey)
+	assert.ErrorIs(t, keys.Retire("missing"), ErrUnknownKey)
+
+	require.NoError(t, keys.Retire("old"))
+	assert.Equal(t, []string{"new"}, keys.IDs())
+	assert.False(t, keys.Verify("user", oldID, oldSig))
+}
+
+func TestWatchKeyring(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	first, err := NewSecretKeyring("first")
+	require.NoError(t, err)
+	useKeyring(t, first)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	go WatchKeyring(ctx, path, signals)
+
+	// Пока файла нет, действует прежний набор.
+	signals <- syscall.SIGHUP
+	reloaded, err := NewKeyring("next", map[string][]byte{"next": []byte("next-secret")})
+	require.NoError(t, err)
+	require.NoError(t, reloaded.Save(path))
+	signals <- syscall.SIGHUP
+	assert.Eventually(t, func() bool { return CurrentKeyring().Active() == "next" }, time.Second, 10*time.Millisecond)
+}
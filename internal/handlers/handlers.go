@@ -2,35 +2,34 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"strconv"
-	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/mi4r/go-url-shortener/cmd/config"
 	"go.uber.org/zap"
-	"golang.org/x/exp/rand"
 
 	"github.com/mi4r/go-url-shortener/internal/auth"
 	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/ratelimit"
+	"github.com/mi4r/go-url-shortener/internal/service"
 	"github.com/mi4r/go-url-shortener/internal/storage"
 )
 
-const (
-	// idLength задаёт длину генерируемого короткого идентификатора.
-	idLength = 8
-	// charset определяет набор символов, используемый для генерации коротких идентификаторов.
-	charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-)
-
 // Flags содержит глобальные настройки приложения, такие как базовый адрес.
 var Flags *config.Flags
 
 // ShortenRequest представляет запрос на создание короткого URL.
 type ShortenRequest struct {
-	URL string `json:"url"`
+	URL       string    `json:"url"`
+	Alias     string    `json:"alias,omitempty"`      // Необязательный пользовательский короткий идентификатор.
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Необязательный момент истечения срока действия (RFC 3339).
+	TTL       int64     `json:"ttl,omitempty"`        // Необязательный срок действия в секундах.
 }
 
 // ShortenResponse представляет ответ с коротким URL.
@@ -40,20 +39,54 @@ type ShortenResponse struct {
 
 // BatchRequestItem описывает элемент в пакетном запросе для сокращения URL.
 type BatchRequestItem struct {
-	CorrelationID string `json:"correlation_id"`
-	OriginalURL   string `json:"original_url"`
+	CorrelationID string    `json:"correlation_id"`
+	OriginalURL   string    `json:"original_url"`
+	Alias         string    `json:"alias,omitempty"`      // Необязательный пользовательский короткий идентификатор.
+	ExpiresAt     time.Time `json:"expires_at,omitempty"` // Необязательный момент истечения срока действия (RFC 3339).
+	TTL           int64     `json:"ttl,omitempty"`        // Необязательный срок действия в секундах.
 }
 
 // BatchResponseItem описывает элемент в пакетном ответе на запрос сокращения URL.
 type BatchResponseItem struct {
 	CorrelationID string `json:"correlation_id"`
-	ShortURL      string `json:"short_url"`
+	ShortURL      string `json:"short_url,omitempty"` // Отсутствует у отклонённых элементов.
+	Status        string `json:"status"`              // created, existing или invalid.
+	Error         string `json:"error,omitempty"`     // Причина отказа для invalid.
 }
 
 // URLResponseItem представляет пару "короткий URL - оригинальный URL" для ответа.
 type URLResponseItem struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"` // Отсутствует у записей, созданных до появления временных меток.
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // Присутствует только у удалённых URL.
+}
+
+// LinkStatsResponse представляет статистику переходов по короткой ссылке.
+type LinkStatsResponse struct {
+	ShortURL   string         `json:"short_url"`
+	Clicks     int            `json:"clicks"`
+	FirstClick *time.Time     `json:"first_click,omitempty"`
+	LastClick  *time.Time     `json:"last_click,omitempty"`
+	Referrers  map[string]int `json:"referrers"`
+	UserAgents map[string]int `json:"user_agents"`
+	Countries  map[string]int `json:"countries"`
+}
+
+// APIKeyRequest представляет запрос на создание API-ключа.
+type APIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// APIKeyResponse описывает API-ключ пользователя. Сам ключ возвращается
+// только при создании.
+type APIKeyResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
 }
 
 // StatsResponse представляет ответ в виде количества сокращённых URL и пользователей в сервисе
@@ -62,17 +95,54 @@ type StatsResponse struct {
 	UserCnt int `json:"users"`
 }
 
-// generateShortID генерирует уникальный идентификатор для сокращённого URL.
-func generateShortID() string {
-	b := make([]byte, idLength)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+// writeError отображает ошибки сервисного слоя на HTTP-статусы.
+// Все обработчики сообщают об ошибках сервиса только через эту функцию.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, "Not Found", http.StatusNotFound)
+	case errors.Is(err, service.ErrDeleted):
+		http.Error(w, "Gone", http.StatusGone)
+	case errors.Is(err, service.ErrInvalidAlias), errors.Is(err, service.ErrInvalidExpiry),
+		errors.Is(err, service.ErrInvalidQuery), errors.Is(err, service.ErrInvalidKeyName),
+		errors.Is(err, service.ErrInvalidURL), errors.Is(err, service.ErrInvalidImport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrAliasTaken):
+		http.Error(w, "Alias already taken", http.StatusConflict)
+	case errors.Is(err, service.ErrConflict):
+		http.Error(w, "Conflict", http.StatusConflict)
+	case errors.Is(err, service.ErrUnauthorized):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.Is(err, service.ErrBlocked):
+		http.Error(w, err.Error(), http.StatusUnavailableForLegalReasons)
+	case errors.Is(err, service.ErrUnavailable):
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		logger.Sugar.Error("Storage unavailable: ", zap.Error(err))
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		logger.Sugar.Error("Request failed: ", zap.Error(err))
+	}
+}
+
+// shortenStatus возвращает статус ответа для результата сокращения URL:
+// 201 для нового URL и 409 для уже существующего. Для прочих ошибок
+// ответ записывается через writeError, а ok равен false.
+func shortenStatus(w http.ResponseWriter, err error) (status int, ok bool) {
+	switch {
+	case err == nil:
+		return http.StatusCreated, true
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, true
+	default:
+		writeError(w, err)
+		return 0, false
 	}
-	return string(b)
 }
 
 // ShortenURLHandler обрабатывает запросы на сокращение URL и возвращает короткий URL в текстовом формате.
-func ShortenURLHandler(storageImpl storage.Storage) http.HandlerFunc {
+func ShortenURLHandler(svc service.ShortenerInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			http.Error(w, "Invalid request method", http.StatusBadRequest)
@@ -86,55 +156,22 @@ func ShortenURLHandler(storageImpl storage.Storage) http.HandlerFunc {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
-		originalURL := string(body)
-
-		var shortID string
-		for {
-			shortID = generateShortID()
-			if _, exists := storageImpl.Get(shortID); !exists {
-				nextID, err := storageImpl.GetNextID()
-				if err != nil {
-					http.Error(w, "Failed to generate UUID", http.StatusInternalServerError)
-					return
-				}
-				url := storage.URL{
-					CorrelationID: strconv.Itoa(nextID),
-					ShortURL:      shortID,
-					OriginalURL:   originalURL,
-					UserID:        userID,
-				}
-				existingURL, err := storageImpl.Save(url)
-				if err != nil {
-					http.Error(w, "Failed to save data", http.StatusInternalServerError)
-					logger.Sugar.Error("Failed to save data:", zap.Error(err))
-					return
-				}
-				if existingURL != "" {
-					shortURL := Flags.BaseShortAddr + "/" + existingURL
-					w.WriteHeader(http.StatusConflict)
-					_, err = w.Write([]byte(shortURL))
-					if err != nil {
-						http.Error(w, "Failed to write response", http.StatusBadRequest)
-						logger.Sugar.Error("Failed to write response", zap.Error(err))
-					}
-					return
-				}
-				break
-			}
 
+		shortURL, err := svc.Shorten(req.Context(), storage.URL{OriginalURL: string(body), UserID: userID})
+		status, ok := shortenStatus(w, err)
+		if !ok {
+			return
 		}
-		shortURL := Flags.BaseShortAddr + "/" + shortID
-		w.WriteHeader(http.StatusCreated)
-		_, err = w.Write([]byte(shortURL))
-		if err != nil {
-			http.Error(w, "Failed to write response", http.StatusBadRequest)
+
+		w.WriteHeader(status)
+		if _, err = w.Write([]byte(shortURL)); err != nil {
 			logger.Sugar.Error("Failed to write response", zap.Error(err))
 		}
 	}
 }
 
 // APIShortenURLHandler обрабатывает запросы API на сокращение URL и возвращает короткий URL в формате JSON.
-func APIShortenURLHandler(storageImpl storage.Storage) http.HandlerFunc {
+func APIShortenURLHandler(svc service.ShortenerInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			http.Error(w, "Invalid request method", http.StatusBadRequest)
@@ -151,65 +188,40 @@ func APIShortenURLHandler(storageImpl storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		originalURL := requestBody.URL
-
-		var shortID string
-		for {
-			shortID = generateShortID()
-			if _, exists := storageImpl.Get(shortID); !exists {
-				nextID, err := storageImpl.GetNextID()
-				if err != nil {
-					http.Error(w, "Failed to generate UUID", http.StatusInternalServerError)
-					return
-				}
-				url := storage.URL{
-					CorrelationID: strconv.Itoa(nextID),
-					ShortURL:      shortID,
-					OriginalURL:   originalURL,
-					UserID:        userID,
-				}
-				existingURL, err := storageImpl.Save(url)
-				if err != nil {
-					http.Error(w, "Failed to save data", http.StatusInternalServerError)
-					logger.Sugar.Error("Failed to save data:", zap.Error(err))
-					return
-				}
-				if existingURL != "" {
-					shortURL := Flags.BaseShortAddr + "/" + existingURL
-
-					responseBody := ShortenResponse{
-						Result: shortURL,
-					}
-
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusConflict)
-					if err := json.NewEncoder(w).Encode(responseBody); err != nil {
-						http.Error(w, "Failed to write response", http.StatusBadRequest)
-						logger.Sugar.Error("Failed to write response", zap.Error(err))
-					}
-					return
-				}
-				break
-			}
+		expiresAt, err := service.ResolveExpiry(requestBody.ExpiresAt, time.Duration(requestBody.TTL)*time.Second)
+		if err != nil {
+			writeError(w, err)
+			return
 		}
 
-		shortURL := Flags.BaseShortAddr + "/" + shortID
+		shortURL, err := svc.Shorten(req.Context(), storage.URL{
+			OriginalURL: requestBody.URL,
+			ShortURL:    requestBody.Alias,
+			UserID:      userID,
+			ExpiresAt:   expiresAt,
+		})
+		status, ok := shortenStatus(w, err)
+		if !ok {
+			return
+		}
 
 		responseBody := ShortenResponse{
 			Result: shortURL,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(status)
 		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
-			http.Error(w, "Failed to write response", http.StatusBadRequest)
 			logger.Sugar.Error("Failed to write response", zap.Error(err))
 		}
 	}
 }
 
 // BatchShortenURLHandler обрабатывает пакетные запросы на сокращение URL.
-func BatchShortenURLHandler(storageImpl storage.Storage) http.HandlerFunc {
+// Для каждого элемента возвращает статус: created, existing или invalid с
+// причиной; отклонённые элементы не мешают сохранению остальных. Отвечает 201,
+// если создана хотя бы одна ссылка, иначе 200.
+func BatchShortenURLHandler(svc service.ShortenerInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			http.Error(w, "Invalid request method", http.StatusBadRequest)
@@ -236,37 +248,72 @@ func BatchShortenURLHandler(storageImpl storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		urls := make([]storage.URL, len(batchRequest))
+		// Элементы с некорректным сроком действия отклоняются сразу,
+		// остальные передаются в сервисный слой.
+		batchResponse := make([]BatchResponseItem, len(batchRequest))
+		urls := make([]storage.URL, 0, len(batchRequest))
+		positions := make([]int, 0, len(batchRequest))
 		for i, item := range batchRequest {
-			urls[i] = storage.URL{CorrelationID: item.CorrelationID, OriginalURL: item.OriginalURL, UserID: userID}
+			expiresAt, err := service.ResolveExpiry(item.ExpiresAt, time.Duration(item.TTL)*time.Second)
+			if err != nil {
+				batchResponse[i] = newBatchResponseItem(storage.BatchResult{
+					CorrelationID: item.CorrelationID,
+					Status:        storage.BatchInvalid,
+					Err:           err,
+				})
+				continue
+			}
+			urls = append(urls, storage.URL{
+				CorrelationID: item.CorrelationID,
+				OriginalURL:   item.OriginalURL,
+				ShortURL:      item.Alias,
+				UserID:        userID,
+				ExpiresAt:     expiresAt,
+			})
+			positions = append(positions, i)
 		}
 
-		shortIDs, err := storageImpl.SaveBatch(urls)
+		result, err := svc.BatchShorten(req.Context(), urls)
 		if err != nil {
-			http.Error(w, "Failed to save URL batch", http.StatusInternalServerError)
-			logger.Sugar.Error("Failed to save URL batch: ", zap.Error(err))
+			writeError(w, err)
 			return
 		}
+		for j, item := range result {
+			batchResponse[positions[j]] = newBatchResponseItem(item)
+		}
 
-		batchResponse := make([]BatchResponseItem, len(batchRequest))
-		for i, shortID := range shortIDs {
-			batchResponse[i] = BatchResponseItem{
-				CorrelationID: batchRequest[i].CorrelationID,
-				ShortURL:      Flags.BaseShortAddr + "/" + shortID,
+		status := http.StatusOK
+		for _, item := range result {
+			if item.Status == storage.BatchCreated {
+				status = http.StatusCreated
+				break
 			}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(status)
 		if err := json.NewEncoder(w).Encode(batchResponse); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			logger.Sugar.Error("Failed to encode response: ", zap.Error(err))
 		}
 	}
 }
 
-// RedirectHandler обрабатывает перенаправления по коротким URL.
-func RedirectHandler(storageImpl storage.Storage) http.HandlerFunc {
+// newBatchResponseItem преобразует результат элемента пакета в элемент ответа.
+func newBatchResponseItem(result storage.BatchResult) BatchResponseItem {
+	item := BatchResponseItem{
+		CorrelationID: result.CorrelationID,
+		ShortURL:      result.ShortURL,
+		Status:        result.Status.String(),
+	}
+	if result.Err != nil {
+		item.Error = result.Err.Error()
+	}
+	return item
+}
+
+// RedirectHandler обрабатывает перенаправления по коротким URL. Адрес для
+// статистики переходов берётся из X-Real-IP только у прокси из trusted.
+func RedirectHandler(svc service.ShortenerInterface, trusted *net.IPNet) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		shortID := chi.URLParam(req, "id")
 		if len(shortID) == 0 {
@@ -274,31 +321,32 @@ func RedirectHandler(storageImpl storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		url, exists := storageImpl.Get(shortID)
-		if !exists {
-			http.Error(w, "Invalid request", http.StatusBadRequest)
+		originalURL, err := svc.GetOriginal(req.Context(), shortID)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
 
-		if url.DeletedFlag {
-			http.Error(w, "Gone", http.StatusGone)
-			return
-		}
+		svc.RecordClick(req.Context(), storage.Click{
+			ShortURL:  shortID,
+			Referrer:  req.Referer(),
+			UserAgent: req.UserAgent(),
+			IP:        ratelimit.ClientIP(req, trusted),
+		})
 
-		http.Redirect(w, req, url.OriginalURL, http.StatusTemporaryRedirect)
+		http.Redirect(w, req, originalURL, http.StatusTemporaryRedirect)
 	}
 }
 
 // PingHandler проверяет соединение с базой данных и возвращает статус.
-func PingHandler(storageImpl storage.Storage) http.HandlerFunc {
+func PingHandler(svc service.ShortenerInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		pinger, ok := storageImpl.(storage.Pinger)
-		if !ok {
-			http.Error(w, "Connection to the database is not verified", http.StatusInternalServerError)
-			logger.Sugar.Error("Connection to the database is not verified")
+		ok, err := svc.Ping(req.Context())
+		if errors.Is(err, service.ErrUnavailable) {
+			writeError(w, err)
 			return
 		}
-		if err := pinger.Ping(); err != nil {
+		if !ok || err != nil {
 			http.Error(w, "Connection to the database is not verified", http.StatusInternalServerError)
 			logger.Sugar.Error("Connection to the database is not verified: ", zap.Error(err))
 			return
@@ -309,16 +357,23 @@ func PingHandler(storageImpl storage.Storage) http.HandlerFunc {
 }
 
 // UserURLsHandler возвращает все URL, сокращенные текущим пользователем.
-func UserURLsHandler(storageImpl storage.Storage) http.HandlerFunc {
+func UserURLsHandler(svc service.ShortenerInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		userID := auth.UpdateCookie(w, req)
 
-		// Получаем URL'ы пользователя из хранилища
-		urls, err := storageImpl.GetURLsByUserID(userID)
+		query, err := listQueryFromRequest(req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// Получаем страницу URL'ов пользователя из сервиса
+		page, err := svc.GetUserURLs(req.Context(), userID, query)
 		if err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
+		urls := page.URLs
 
 		// Проверяем, есть ли сокращенные URL'ы
 		if len(urls) == 0 {
@@ -330,12 +385,18 @@ func UserURLsHandler(storageImpl storage.Storage) http.HandlerFunc {
 		response := make([]URLResponseItem, len(urls))
 		for i, url := range urls {
 			response[i] = URLResponseItem{
-				ShortURL:    Flags.BaseShortAddr + "/" + url.ShortURL,
+				ShortURL:    url.ShortURL,
 				OriginalURL: url.OriginalURL,
+				CreatedAt:   timePtr(url.CreatedAt),
+				UpdatedAt:   timePtr(url.UpdatedAt),
+				DeletedAt:   timePtr(url.DeletedAt),
 			}
 		}
 
 		// Отправляем ответ
+		if page.NextCursor != "" {
+			w.Header().Set("Link", nextPageLink(req, page.NextCursor))
+		}
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -344,8 +405,57 @@ func UserURLsHandler(storageImpl storage.Storage) http.HandlerFunc {
 	}
 }
 
+// listQueryFromRequest читает параметры limit, cursor, order, state и q.
+func listQueryFromRequest(req *http.Request) (storage.ListQuery, error) {
+	params := req.URL.Query()
+	limit := 0
+	if raw := params.Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			return storage.ListQuery{}, fmt.Errorf("%w: limit must be a number", service.ErrInvalidQuery)
+		}
+	}
+	return service.NewListQuery(limit, params.Get("cursor"), params.Get("order"), params.Get("state"), params.Get("q"))
+}
+
+// nextPageLink формирует заголовок Link со ссылкой на следующую страницу.
+func nextPageLink(req *http.Request, cursor string) string {
+	params := req.URL.Query()
+	params.Set("cursor", cursor)
+	return fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, params.Encode())
+}
+
+// LinkStatsHandler возвращает статистику переходов по короткой ссылке текущего пользователя.
+func LinkStatsHandler(svc service.ShortenerInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		userID := auth.UpdateCookie(w, req)
+		shortID := chi.URLParam(req, "id")
+
+		stats, err := svc.GetLinkStats(req.Context(), userID, shortID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		response := LinkStatsResponse{
+			ShortURL:   stats.ShortURL,
+			Clicks:     stats.Clicks,
+			Referrers:  stats.Referrers,
+			UserAgents: stats.UserAgents,
+			Countries:  stats.Countries,
+		}
+		response.FirstClick = timePtr(stats.FirstClick)
+		response.LastClick = timePtr(stats.LastClick)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logger.Sugar.Error("Failed to encode response: ", zap.Error(err))
+		}
+	}
+}
+
 // DeleteUserURLsHandler удаляет (логически) список URL, принадлежащих пользователю.
-func DeleteUserURLsHandler(storageImpl storage.Storage) http.HandlerFunc {
+func DeleteUserURLsHandler(svc service.ShortenerInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		userID := auth.UpdateCookie(w, req)
 
@@ -356,88 +466,141 @@ func DeleteUserURLsHandler(storageImpl storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		idChan := make(chan string)
-		var wg sync.WaitGroup
+		if err := svc.DeleteUserURLs(req.Context(), userID, ids); err != nil {
+			logger.Sugar.Errorf("Error marking URLs as deleted: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
 
-		go func() {
-			defer close(idChan)
-			for _, id := range ids {
-				idChan <- id
-			}
-		}()
+// InternalStatsHandler возвращает количество пользователей и сокращенных URL в сервисе.
+// Доступ к статистике проверяет сервис по адресу из заголовка X-Real-IP.
+func InternalStatsHandler(svc service.ShortenerInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := net.ParseIP(r.Header.Get("X-Real-IP"))
 
-		updateBatch := func(urls []string) {
-			if len(urls) == 0 {
-				return
-			}
-			if err := storageImpl.MarkURLsAsDeleted(userID, urls); err != nil {
-				logger.Sugar.Errorf("Error marking URLs as deleted: %v", err)
-			}
+		urlCnt, userCnt, err := svc.InternalStats(r.Context(), ip)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		stats := StatsResponse{
+			URLCnt:  urlCnt,
+			UserCnt: userCnt,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
 		}
+	}
+}
+
+// timePtr возвращает nil для нулевого времени, чтобы поле не попадало в JSON.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
 
-		numWorkers := 5
-		batchSize := 10
-		urlsBatch := make([]string, 0, batchSize)
-		urlsChan := make(chan []string, len(urlsBatch))
-
-		for i := 0; i < numWorkers; i++ {
-			wg.Add(1)
-			go func(inputCh chan []string, wg *sync.WaitGroup) {
-				defer wg.Done()
-				for batch := range inputCh {
-					updateBatch(batch)
-				}
-			}(urlsChan, &wg)
-		}
-
-		go func() {
-			defer close(urlsChan)
-			for id := range idChan {
-				urlsBatch = append(urlsBatch, id)
-				if len(urlsBatch) >= batchSize {
-					urlsChan <- urlsBatch
-					urlsBatch = make([]string, 0, batchSize)
-				}
+// APIKeyMiddleware аутентифицирует запросы с заголовком "Authorization: Bearer <key>".
+// Владелец ключа становится пользователем запроса вместо идентификатора из куки.
+// Запросы с неизвестным или отозванным ключом отклоняются со статусом 401.
+func APIKeyMiddleware(svc service.ShortenerInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key, ok := auth.BearerToken(req.Header.Get("Authorization"))
+			if !ok {
+				next.ServeHTTP(w, req)
+				return
 			}
-			if len(urlsBatch) > 0 {
-				urlsChan <- urlsBatch
+			userID, err := svc.AuthenticateAPIKey(req.Context(), key)
+			if err != nil {
+				writeError(w, err)
+				return
 			}
-		}()
-
-		wg.Wait()
-		w.WriteHeader(http.StatusAccepted)
+			next.ServeHTTP(w, req.WithContext(auth.WithUserID(req.Context(), userID)))
+		})
 	}
 }
 
-// InternalStatsHandler возвращает количество пользователей и сокращенных URL в сервисе.
-func InternalStatsHandler(storageImpl storage.Storage, trustedSubnet *net.IPNet) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		ipStr := r.Header.Get("X-Real-IP")
-		ip := net.ParseIP(ipStr)
-		if ip == nil || trustedSubnet == nil || !trustedSubnet.Contains(ip) {
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
+// CreateAPIKeyHandler создаёт API-ключ текущего пользователя.
+func CreateAPIKeyHandler(svc service.ShortenerInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		userID := auth.UpdateCookie(w, req)
+
+		var request APIKeyRequest
+		if req.ContentLength != 0 {
+			if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
 		}
-		urlCnt, err := storageImpl.URLCount()
+
+		key, secret, err := svc.CreateAPIKey(req.Context(), userID, request.Name)
 		if err != nil {
-			http.Error(w, "Failed to get URL count", http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
-		userCnt, err := storageImpl.UserCount()
+
+		response := apiKeyResponse(key)
+		response.Key = secret
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+// ListAPIKeysHandler возвращает API-ключи текущего пользователя без самих ключей.
+func ListAPIKeysHandler(svc service.ShortenerInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		userID := auth.UpdateCookie(w, req)
+
+		keys, err := svc.ListAPIKeys(req.Context(), userID)
 		if err != nil {
-			http.Error(w, "Failed to get user count", http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
-
-		stats := StatsResponse{
-			URLCnt:  urlCnt,
-			UserCnt: userCnt,
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
 		}
 
+		response := make([]APIKeyResponse, len(keys))
+		for i, key := range keys {
+			response[i] = apiKeyResponse(key)
+		}
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(stats); err != nil {
+		if err := json.NewEncoder(w).Encode(response); err != nil {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+// RevokeAPIKeyHandler отзывает API-ключ текущего пользователя.
+func RevokeAPIKeyHandler(svc service.ShortenerInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		userID := auth.UpdateCookie(w, req)
+
+		if err := svc.RevokeAPIKey(req.Context(), userID, chi.URLParam(req, "id")); err != nil {
+			writeError(w, err)
 			return
 		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// apiKeyResponse преобразует API-ключ в ответ без самого ключа.
+func apiKeyResponse(key storage.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: timePtr(key.RevokedAt),
 	}
 }
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/auth"
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/service"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// maxImportLine ограничивает длину строки NDJSON при импорте.
+const maxImportLine = 1 << 20
+
+// ImportResponse описывает итог импорта.
+type ImportResponse struct {
+	Created  int                 `json:"created"`
+	Existing int                 `json:"existing"`
+	Invalid  int                 `json:"invalid"`
+	Rejected []BatchResponseItem `json:"rejected,omitempty"` // Первые service.MaxImportRejected отклонённых записей.
+}
+
+// ImportURLsHandler обрабатывает массовый импорт URL. Тело запроса читается
+// потоком в формате NDJSON (по объекту BatchRequestItem в строке) или CSV
+// (Content-Type: text/csv) с заголовком из имён полей BatchRequestItem, где
+// обязателен только original_url. Записи без correlation_id получают номер
+// строки. Отвечает 201, если создана хотя бы одна ссылка, иначе 200.
+func ImportURLsHandler(svc service.ShortenerInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		userID := auth.UpdateCookie(w, req)
+
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		var (
+			source service.URLSource
+			err    error
+		)
+		switch mediaType {
+		case "text/csv":
+			source, err = csvSource(req.Body)
+		case "", "application/x-ndjson", "application/jsonl", "application/json":
+			source = ndjsonSource(req.Body)
+		default:
+			http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		summary, err := svc.Import(req.Context(), userID, source)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		response := ImportResponse{
+			Created:  summary.Created,
+			Existing: summary.Existing,
+			Invalid:  summary.Invalid,
+		}
+		for _, result := range summary.Rejected {
+			response.Rejected = append(response.Rejected, newBatchResponseItem(result))
+		}
+		status := http.StatusOK
+		if summary.Created > 0 {
+			status = http.StatusCreated
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logger.Sugar.Error("Failed to encode response: ", zap.Error(err))
+		}
+	}
+}
+
+// ndjsonSource читает записи импорта построчно. Пустые строки пропускаются.
+func ndjsonSource(r io.Reader) service.URLSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+	line := 0
+	return func() (storage.URL, error) {
+		for scanner.Scan() {
+			line++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var item BatchRequestItem
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+				return storage.URL{CorrelationID: strconv.Itoa(line)},
+					fmt.Errorf("%w: line %d: %v", service.ErrInvalidRecord, line, err)
+			}
+			return importURL(item, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return storage.URL{}, err
+		}
+		return storage.URL{}, io.EOF
+	}
+}
+
+// csvSource читает записи импорта из CSV. Первая строка задаёт порядок полей.
+func csvSource(r io.Reader) (service.URLSource, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	if _, ok := columns["original_url"]; !ok {
+		return nil, errors.New("invalid csv header: original_url column is required")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	return func() (storage.URL, error) {
+		record, err := reader.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return storage.URL{CorrelationID: strconv.Itoa(parseErr.StartLine)},
+				fmt.Errorf("%w: %w", service.ErrInvalidRecord, err)
+		}
+		if err != nil {
+			return storage.URL{}, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		item := BatchRequestItem{
+			CorrelationID: field(record, "correlation_id"),
+			OriginalURL:   field(record, "original_url"),
+			Alias:         field(record, "alias"),
+		}
+		if item.CorrelationID == "" {
+			item.CorrelationID = strconv.Itoa(line)
+		}
+		if value := field(record, "expires_at"); value != "" {
+			if item.ExpiresAt, err = time.Parse(time.RFC3339, value); err != nil {
+				return storage.URL{CorrelationID: item.CorrelationID},
+					fmt.Errorf("%w: line %d: expires_at: %v", service.ErrInvalidRecord, line, err)
+			}
+		}
+		if value := field(record, "ttl"); value != "" {
+			if item.TTL, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return storage.URL{CorrelationID: item.CorrelationID},
+					fmt.Errorf("%w: line %d: ttl: %v", service.ErrInvalidRecord, line, err)
+			}
+		}
+		return importURL(item, line)
+	}, nil
+}
+
+// importURL преобразует запись импорта со строки line в URL.
+func importURL(item BatchRequestItem, line int) (storage.URL, error) {
+	if item.CorrelationID == "" {
+		item.CorrelationID = strconv.Itoa(line)
+	}
+	url := storage.URL{
+		CorrelationID: item.CorrelationID,
+		OriginalURL:   item.OriginalURL,
+		ShortURL:      item.Alias,
+	}
+	expiresAt, err := service.ResolveExpiry(item.ExpiresAt, time.Duration(item.TTL)*time.Second)
+	if err != nil {
+		return url, fmt.Errorf("%w: %w", service.ErrInvalidRecord, err)
+	}
+	url.ExpiresAt = expiresAt
+	return url, nil
+}
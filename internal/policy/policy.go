@@ -0,0 +1,144 @@
+// Package policy ограничивает адреса, на которые можно создавать короткие
+// ссылки. Правила читаются из JSON-файла и могут перечитываться без
+// перезапуска сервиса, например по сигналу SIGHUP.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrBlocked возвращается для адреса, запрещённого политикой.
+var ErrBlocked = errors.New("destination is blocked")
+
+// Rules описывает файл политики. Каждое правило задаётся строкой:
+//   - "example.com" совпадает только с хостом example.com;
+//   - ".example.com" совпадает с example.com и всеми его поддоменами;
+//   - "/regexp/" совпадает с URL, удовлетворяющим регулярному выражению.
+//
+// Адрес запрещён, если он совпадает с правилом Block либо если список Allow
+// не пуст и адрес не совпадает ни с одним его правилом.
+type Rules struct {
+	Allow []string `json:"allow"`
+	Block []string `json:"block"`
+}
+
+// matcher проверяет одно правило.
+type matcher struct {
+	rule   string
+	host   string         // Точное совпадение хоста.
+	suffix string         // Совпадение домена и поддоменов, без ведущей точки.
+	re     *regexp.Regexp // Совпадение всего URL.
+}
+
+func (m matcher) match(host, rawURL string) bool {
+	switch {
+	case m.re != nil:
+		return m.re.MatchString(rawURL)
+	case m.suffix != "":
+		return host == m.suffix || strings.HasSuffix(host, "."+m.suffix)
+	default:
+		return host == m.host
+	}
+}
+
+// Policy — разобранный набор правил. nil разрешает любые адреса.
+type Policy struct {
+	allow []matcher
+	block []matcher
+}
+
+// New разбирает правила.
+func New(rules Rules) (*Policy, error) {
+	allow, err := compile(rules.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("allow: %w", err)
+	}
+	block, err := compile(rules.Block)
+	if err != nil {
+		return nil, fmt.Errorf("block: %w", err)
+	}
+	return &Policy{allow: allow, block: block}, nil
+}
+
+// Load читает правила из JSON-файла path.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return New(rules)
+}
+
+func compile(rules []string) ([]matcher, error) {
+	matchers := make([]matcher, 0, len(rules))
+	for _, rule := range rules {
+		m := matcher{rule: rule}
+		switch {
+		case len(rule) > 1 && strings.HasPrefix(rule, "/") && strings.HasSuffix(rule, "/"):
+			re, err := regexp.Compile(rule[1 : len(rule)-1])
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", rule, err)
+			}
+			m.re = re
+		case strings.HasPrefix(rule, "."):
+			m.suffix = normalizeHost(rule[1:])
+		default:
+			m.host = normalizeHost(rule)
+		}
+		if m.re == nil && m.host == "" && m.suffix == "" {
+			return nil, fmt.Errorf("rule %q: empty host", rule)
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// normalizeHost приводит хост к виду, в котором он хранится в нормализованных
+// URL: нижний регистр, punycode, без завершающей точки.
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		return ascii
+	}
+	return host
+}
+
+// Check возвращает ошибку, оборачивающую ErrBlocked, если адрес rawURL
+// запрещён политикой.
+func (p *Policy) Check(rawURL string) error {
+	if p == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	host := normalizeHost(u.Hostname())
+
+	for _, m := range p.block {
+		if m.match(host, rawURL) {
+			return fmt.Errorf("%w by rule %q", ErrBlocked, m.rule)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, m := range p.allow {
+		if m.match(host, rawURL) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowlist", ErrBlocked, host)
+}
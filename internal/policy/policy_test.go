@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	p, err := New(Rules{
+		Block: []string{"evil.com", ".phish.net", `/\.zip$/`, "Пример.рф"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://evil.com/login", true},
+		{"https://EVIL.com./", true},
+		{"https://sub.evil.com/", false},
+		{"https://phish.net/", true},
+		{"https://a.b.phish.net/", true},
+		{"https://notphish.net/", false},
+		{"https://example.com/archive.zip", true},
+		{"https://xn--e1afmkfd.xn--p1ai/", true},
+		{"https://example.com/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := p.Check(tt.url)
+			if tt.blocked {
+				assert.ErrorIs(t, err, ErrBlocked)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPolicy_Allowlist(t *testing.T) {
+	p, err := New(Rules{
+		Allow: []string{".corp.example"},
+		Block: []string{"old.corp.example"},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.Check("https://wiki.corp.example/page"))
+	assert.ErrorIs(t, p.Check("https://old.corp.example/"), ErrBlocked)
+	assert.ErrorIs(t, p.Check("https://example.com/"), ErrBlocked)
+
+	var none *Policy
+	assert.NoError(t, none.Check("https://anything.example/"))
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	_, err := New(Rules{Block: []string{"/(/"}})
+	assert.Error(t, err)
+	_, err = New(Rules{Allow: []string{"."}})
+	assert.Error(t, err)
+}
+
+func TestReloader(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"block": ["evil.com"]}`), 0o600))
+
+	r, err := NewReloader(path)
+	require.NoError(t, err)
+	assert.ErrorIs(t, r.Check("https://evil.com/"), ErrBlocked)
+	assert.NoError(t, r.Check("https://bad.org/"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal)
+	go r.Watch(ctx, signals)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"block": ["bad.org"]}`), 0o600))
+	signals <- syscall.SIGHUP
+	assert.Eventually(t, func() bool { return r.Check("https://bad.org/") != nil }, time.Second, 10*time.Millisecond)
+	assert.NoError(t, r.Check("https://evil.com/"))
+
+	// Некорректный файл не заменяет действующую политику.
+	require.NoError(t, os.WriteFile(path, []byte(`{"block": [`), 0o600))
+	assert.Error(t, r.Reload())
+	assert.ErrorIs(t, r.Check("https://bad.org/"), ErrBlocked)
+
+	_, err = NewReloader(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// Reloader хранит политику, загруженную из файла, и перечитывает его по
+// запросу. Безопасен для одновременного использования.
+type Reloader struct {
+	path    string
+	current atomic.Pointer[Policy]
+}
+
+// NewReloader загружает политику из файла path.
+func NewReloader(path string) (*Reloader, error) {
+	r := &Reloader{path: path}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload перечитывает файл политики. При ошибке продолжает действовать
+// прежняя политика.
+func (r *Reloader) Reload() error {
+	p, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	r.current.Store(p)
+	return nil
+}
+
+// Check проверяет адрес по текущей политике.
+func (r *Reloader) Check(rawURL string) error {
+	return r.current.Load().Check(rawURL)
+}
+
+// Watch перечитывает политику при каждом сигнале из signals, пока не
+// отменён ctx. Ошибки загрузки записываются в лог.
+func (r *Reloader) Watch(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := r.Reload(); err != nil {
+				logger.Sugar.Error("Failed to reload destination policy, keeping the previous one: ", err)
+				continue
+			}
+			logger.Sugar.Info("Destination policy reloaded from ", r.path)
+		}
+	}
+}
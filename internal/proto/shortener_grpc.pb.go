@@ -26,6 +26,8 @@ const (
 	Shortener_DeleteUserURLs_FullMethodName = "/shortener.Shortener/DeleteUserURLs"
 	Shortener_Ping_FullMethodName           = "/shortener.Shortener/Ping"
 	Shortener_InternalStats_FullMethodName  = "/shortener.Shortener/InternalStats"
+	Shortener_GetLinkStats_FullMethodName   = "/shortener.Shortener/GetLinkStats"
+	Shortener_Import_FullMethodName         = "/shortener.Shortener/Import"
 )
 
 // ShortenerClient is the client API for Shortener service.
@@ -35,10 +37,12 @@ type ShortenerClient interface {
 	Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error)
 	GetOriginal(ctx context.Context, in *GetOriginalRequest, opts ...grpc.CallOption) (*GetOriginalResponse, error)
 	BatchShorten(ctx context.Context, in *BatchShortenRequest, opts ...grpc.CallOption) (*BatchShortenResponse, error)
-	GetUserURLs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GetUserURLsResponse, error)
+	GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error)
 	DeleteUserURLs(ctx context.Context, in *DeleteUserURLsRequest, opts ...grpc.CallOption) (*Empty, error)
 	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 	InternalStats(ctx context.Context, in *InternalStatsRequest, opts ...grpc.CallOption) (*InternalStatsResponse, error)
+	GetLinkStats(ctx context.Context, in *GetLinkStatsRequest, opts ...grpc.CallOption) (*GetLinkStatsResponse, error)
+	Import(ctx context.Context, opts ...grpc.CallOption) (Shortener_ImportClient, error)
 }
 
 type shortenerClient struct {
@@ -79,7 +83,7 @@ func (c *shortenerClient) BatchShorten(ctx context.Context, in *BatchShortenRequ
 	return out, nil
 }
 
-func (c *shortenerClient) GetUserURLs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GetUserURLsResponse, error) {
+func (c *shortenerClient) GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetUserURLsResponse)
 	err := c.cc.Invoke(ctx, Shortener_GetUserURLs_FullMethodName, in, out, cOpts...)
@@ -119,6 +123,51 @@ func (c *shortenerClient) InternalStats(ctx context.Context, in *InternalStatsRe
 	return out, nil
 }
 
+func (c *shortenerClient) GetLinkStats(ctx context.Context, in *GetLinkStatsRequest, opts ...grpc.CallOption) (*GetLinkStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLinkStatsResponse)
+	err := c.cc.Invoke(ctx, Shortener_GetLinkStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) Import(ctx context.Context, opts ...grpc.CallOption) (Shortener_ImportClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[0], Shortener_Import_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerImportClient{ClientStream: stream}
+	return x, nil
+}
+
+type Shortener_ImportClient interface {
+	Send(*BatchShortenRequestItem) error
+	CloseAndRecv() (*ImportResponse, error)
+	grpc.ClientStream
+}
+
+type shortenerImportClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerImportClient) Send(m *BatchShortenRequestItem) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *shortenerImportClient) CloseAndRecv() (*ImportResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ShortenerServer is the server API for Shortener service.
 // All implementations must embed UnimplementedShortenerServer
 // for forward compatibility
@@ -126,10 +175,12 @@ type ShortenerServer interface {
 	Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error)
 	GetOriginal(context.Context, *GetOriginalRequest) (*GetOriginalResponse, error)
 	BatchShorten(context.Context, *BatchShortenRequest) (*BatchShortenResponse, error)
-	GetUserURLs(context.Context, *Empty) (*GetUserURLsResponse, error)
+	GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error)
 	DeleteUserURLs(context.Context, *DeleteUserURLsRequest) (*Empty, error)
 	Ping(context.Context, *Empty) (*Empty, error)
 	InternalStats(context.Context, *InternalStatsRequest) (*InternalStatsResponse, error)
+	GetLinkStats(context.Context, *GetLinkStatsRequest) (*GetLinkStatsResponse, error)
+	Import(Shortener_ImportServer) error
 	mustEmbedUnimplementedShortenerServer()
 }
 
@@ -146,7 +197,7 @@ func (UnimplementedShortenerServer) GetOriginal(context.Context, *GetOriginalReq
 func (UnimplementedShortenerServer) BatchShorten(context.Context, *BatchShortenRequest) (*BatchShortenResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchShorten not implemented")
 }
-func (UnimplementedShortenerServer) GetUserURLs(context.Context, *Empty) (*GetUserURLsResponse, error) {
+func (UnimplementedShortenerServer) GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserURLs not implemented")
 }
 func (UnimplementedShortenerServer) DeleteUserURLs(context.Context, *DeleteUserURLsRequest) (*Empty, error) {
@@ -158,6 +209,12 @@ func (UnimplementedShortenerServer) Ping(context.Context, *Empty) (*Empty, error
 func (UnimplementedShortenerServer) InternalStats(context.Context, *InternalStatsRequest) (*InternalStatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method InternalStats not implemented")
 }
+func (UnimplementedShortenerServer) GetLinkStats(context.Context, *GetLinkStatsRequest) (*GetLinkStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLinkStats not implemented")
+}
+func (UnimplementedShortenerServer) Import(Shortener_ImportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
 func (UnimplementedShortenerServer) mustEmbedUnimplementedShortenerServer() {}
 
 // UnsafeShortenerServer may be embedded to opt out of forward compatibility for this service.
@@ -226,7 +283,7 @@ func _Shortener_BatchShorten_Handler(srv interface{}, ctx context.Context, dec f
 }
 
 func _Shortener_GetUserURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+	in := new(GetUserURLsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -238,7 +295,7 @@ func _Shortener_GetUserURLs_Handler(srv interface{}, ctx context.Context, dec fu
 		FullMethod: Shortener_GetUserURLs_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ShortenerServer).GetUserURLs(ctx, req.(*Empty))
+		return srv.(ShortenerServer).GetUserURLs(ctx, req.(*GetUserURLsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -297,6 +354,50 @@ func _Shortener_InternalStats_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_GetLinkStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLinkStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetLinkStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortener_GetLinkStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetLinkStats(ctx, req.(*GetLinkStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ShortenerServer).Import(&shortenerImportServer{ServerStream: stream})
+}
+
+type Shortener_ImportServer interface {
+	SendAndClose(*ImportResponse) error
+	Recv() (*BatchShortenRequestItem, error)
+	grpc.ServerStream
+}
+
+type shortenerImportServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerImportServer) SendAndClose(m *ImportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *shortenerImportServer) Recv() (*BatchShortenRequestItem, error) {
+	m := new(BatchShortenRequestItem)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Shortener_ServiceDesc is the grpc.ServiceDesc for Shortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -332,7 +433,17 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "InternalStats",
 			Handler:    _Shortener_InternalStats_Handler,
 		},
+		{
+			MethodName: "GetLinkStats",
+			Handler:    _Shortener_GetLinkStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Import",
+			Handler:       _Shortener_Import_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "shortener.proto",
 }
@@ -0,0 +1,118 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket.
+// Для каждого ключа (пользователя или IP-адреса) ведётся отдельное ведро,
+// а лимиты задаются отдельно для классов маршрутов.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleTimeout — время, после которого неиспользуемое полное ведро удаляется.
+const idleTimeout = 10 * time.Minute
+
+// ErrInvalidLimit возвращается при разборе некорректного описания лимита.
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit задаёт скорость пополнения ведра и его ёмкость.
+type Limit struct {
+	Rate  float64 // Число запросов в секунду.
+	Burst int     // Максимальное число запросов подряд.
+}
+
+// ParseLimit разбирает лимит вида "rate:burst", например "5:10".
+// Если burst не указан, он равен округлённой вверх скорости.
+func ParseLimit(s string) (Limit, error) {
+	rateStr, burstStr, hasBurst := strings.Cut(s, ":")
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+		}
+	}
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// bucket — ведро токенов одного ключа.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter ограничивает частоту запросов по ключам. Безопасен для
+// одновременного использования.
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter создаёт ограничитель с лимитом limit для каждого ключа.
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow расходует токен ключа key. Если токенов нет, возвращает false и
+// время, через которое появится следующий токен.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// refill возвращает число токенов в ведре к моменту now.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(float64(l.limit.Burst), b.tokens+elapsed*l.limit.Rate)
+}
+
+// sweep удаляет вёдра, которые заполнились и не использовались idleTimeout.
+// Такие вёдра неотличимы от новых, поэтому удаление не меняет поведение.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idleTimeout && l.refill(b, now) >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
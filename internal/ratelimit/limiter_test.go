@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock — управляемые часы для тестов.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestLimiter создаёт ограничитель с управляемыми часами.
+func newTestLimiter(limit Limit) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLimiter(limit)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "5:10", want: Limit{Rate: 5, Burst: 10}},
+		{in: "0.5:2", want: Limit{Rate: 0.5, Burst: 2}},
+		{in: "2.5", want: Limit{Rate: 2.5, Burst: 3}},
+		{in: "", wantErr: true},
+		{in: "0:1", wantErr: true},
+		{in: "-1:1", wantErr: true},
+		{in: "1:0", wantErr: true},
+		{in: "1:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidLimit)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, clock := newTestLimiter(Limit{Rate: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("a")
+		assert.True(t, ok, "request %d within burst", i)
+	}
+	ok, wait := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Другой ключ расходует собственное ведро.
+	ok, _ = l.Allow("b")
+	assert.True(t, ok)
+
+	clock.advance(500 * time.Millisecond)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+
+	// Ведро не наполняется сверх burst.
+	clock.advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ = l.Allow("a")
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	l, clock := newTestLimiter(Limit{Rate: 1, Burst: 1})
+
+	l.Allow("idle")
+	clock.advance(idleTimeout)
+	l.Allow("active")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert.NotContains(t, l.buckets, "idle")
+	assert.Contains(t, l.buckets, "active")
+}
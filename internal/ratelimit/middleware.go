@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/mi4r/go-url-shortener/internal/auth"
+)
+
+// Limits содержит ограничители для классов маршрутов. nil отключает
+// ограничение для класса.
+type Limits struct {
+	Shorten  *Limiter // Сокращение одного URL.
+	Batch    *Limiter // Пакетное сокращение и импорт.
+	Redirect *Limiter // Переход по короткой ссылке.
+
+	// TrustedSubnet — подсеть доверенных прокси, чьему заголовку X-Real-IP
+	// можно верить. nil — заголовок игнорируется.
+	TrustedSubnet *net.IPNet
+}
+
+// Middleware ограничивает частоту HTTP-запросов. Ключом служит идентификатор
+// пользователя из API-ключа, иначе IP-адрес клиента: куку выдаёт любой
+// ответ, поэтому она не отличает одного клиента от другого. X-Real-IP
+// учитывается только для запросов из trusted.
+// При превышении лимита отвечает 429 с заголовком Retry-After.
+func Middleware(l *Limiter, trusted *net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, wait := l.Allow(requestKey(r, trusted)); !ok {
+				w.Header().Set("Retry-After", retryAfter(wait))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestKey возвращает ключ ограничения для HTTP-запроса.
+func requestKey(r *http.Request, trusted *net.IPNet) string {
+	if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+		return "user:" + userID
+	}
+	if ip := ClientIP(r, trusted); ip != nil {
+		return "ip:" + ip.String()
+	}
+	return "ip:" + r.RemoteAddr
+}
+
+// ClientIP возвращает адрес клиента HTTP-запроса. Заголовок X-Real-IP
+// учитывается, только если запрос пришёл от прокси из trusted, иначе
+// используется адрес соединения. nil — адрес соединения не разобран.
+func ClientIP(r *http.Request, trusted *net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && trusted != nil && trusted.Contains(ip) {
+		if realIP := net.ParseIP(r.Header.Get("X-Real-IP")); realIP != nil {
+			return realIP
+		}
+	}
+	return ip
+}
+
+// UnaryInterceptor ограничивает частоту унарных gRPC-вызовов. methods
+// сопоставляет полное имя метода с ограничителем; прочие методы не
+// ограничиваются. Ключом служит пользователь, аутентифицированный API-ключом,
+// поэтому перехватчик ставится после аутентификации; для остальных вызовов,
+// в том числе с токеном user-token, — адрес клиента.
+// При превышении лимита возвращает ResourceExhausted.
+func UnaryInterceptor(methods map[string]*Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		l := methods[info.FullMethod]
+		if l == nil {
+			return handler(ctx, req)
+		}
+		if ok, wait := l.Allow(callKey(ctx)); !ok {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter(wait)))
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", wait.Round(time.Millisecond))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor ограничивает частоту потоковых gRPC-вызовов так же, как
+// UnaryInterceptor: лимит проверяется один раз при открытии потока.
+func StreamInterceptor(methods map[string]*Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		l := methods[info.FullMethod]
+		if l == nil {
+			return handler(srv, ss)
+		}
+		if ok, wait := l.Allow(callKey(ss.Context())); !ok {
+			_ = ss.SetHeader(metadata.Pairs("retry-after", retryAfter(wait)))
+			return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", wait.Round(time.Millisecond))
+		}
+		return handler(srv, ss)
+	}
+}
+
+// callKey возвращает ключ ограничения для gRPC-вызова.
+// Метаданные user-id не используются: для вызова без токена их заполняет
+// только что созданный пользователь.
+func callKey(ctx context.Context) string {
+	if userID, ok := auth.UserIDFromContext(ctx); ok {
+		return "user:" + userID
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "ip:" + host
+	}
+	return "ip:"
+}
+
+// retryAfter возвращает значение Retry-After в целых секундах, не меньше одной.
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds()))))
+}
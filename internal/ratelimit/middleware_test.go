@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/mi4r/go-url-shortener/internal/auth"
+)
+
+func TestMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 0.5, Burst: 1})
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	handler := Middleware(l, trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	fromIP := func(ip string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = ip + ":1234"
+		return r
+	}
+
+	assert.Equal(t, http.StatusCreated, serve(fromIP("192.0.2.1")).Code)
+	w := serve(fromIP("192.0.2.1"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// Клиенты с разных адресов ограничиваются независимо.
+	assert.Equal(t, http.StatusCreated, serve(fromIP("192.0.2.2")).Code)
+
+	// Пользователь, аутентифицированный API-ключом, имеет своё ведро.
+	r := fromIP("192.0.2.1")
+	r = r.WithContext(auth.WithUserID(r.Context(), "user1"))
+	assert.Equal(t, http.StatusCreated, serve(r).Code)
+
+	// Новая подписанная кука не даёт нового ведра: ключом остаётся адрес.
+	for _, userID := range []string{"user2", "user3"} {
+		cookie := httptest.NewRecorder()
+		auth.SetUserCookie(cookie, userID)
+		r = fromIP("192.0.2.2")
+		r.AddCookie(cookie.Result().Cookies()[0])
+		assert.Equal(t, http.StatusTooManyRequests, serve(r).Code)
+	}
+
+	// X-Real-IP от клиента вне доверенной подсети игнорируется.
+	r = fromIP("192.0.2.1")
+	r.Header.Set("X-Real-IP", "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, serve(r).Code)
+
+	// От доверенного прокси ключом служит адрес из X-Real-IP.
+	for _, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+		r = fromIP("10.0.0.1")
+		r.Header.Set("X-Real-IP", ip)
+		assert.Equal(t, http.StatusCreated, serve(r).Code)
+	}
+	r = fromIP("10.0.0.2")
+	r.Header.Set("X-Real-IP", "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, serve(r).Code)
+}
+
+func TestClientIP(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		trusted    *net.IPNet
+		want       string
+	}{
+		{name: "no header", remoteAddr: "192.0.2.1:1234", trusted: trusted, want: "192.0.2.1"},
+		{name: "untrusted proxy", remoteAddr: "192.0.2.1:1234", realIP: "198.51.100.1", trusted: trusted, want: "192.0.2.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", realIP: "198.51.100.1", trusted: trusted, want: "198.51.100.1"},
+		{name: "malformed header", remoteAddr: "10.0.0.1:1234", realIP: "not-an-ip", trusted: trusted, want: "10.0.0.1"},
+		{name: "no trusted subnet", remoteAddr: "10.0.0.1:1234", realIP: "198.51.100.1", want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, ClientIP(r, tt.trusted).String())
+		})
+	}
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := Middleware(nil, nil)(next)
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 1})
+	interceptor := UnaryInterceptor(map[string]*Limiter{"/svc/Limited": l})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	call := func(ctx context.Context, method string) error {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	userCtx := func(userID string) context.Context {
+		return auth.WithUserID(context.Background(), userID)
+	}
+
+	require.NoError(t, call(userCtx("user1"), "/svc/Limited"))
+	err := call(userCtx("user1"), "/svc/Limited")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	require.NoError(t, call(userCtx("user2"), "/svc/Limited"))
+	require.NoError(t, call(userCtx("user1"), "/svc/Other"))
+
+	// Без API-ключа ключом служит адрес клиента, даже если user-id уже заполнен.
+	peerCtx := func(userID string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50000}})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("user-id", userID))
+	}
+	require.NoError(t, call(peerCtx("user3"), "/svc/Limited"))
+	err = call(peerCtx("user4"), "/svc/Limited")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// limitedStream — поток с заданным контекстом, запоминающий заголовки ответа.
+type limitedStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *limitedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *limitedStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 1})
+	interceptor := StreamInterceptor(map[string]*Limiter{"/svc/Import": l})
+	calls := 0
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		calls++
+		return nil
+	}
+	open := func(ctx context.Context, method string) (*limitedStream, error) {
+		stream := &limitedStream{ctx: ctx}
+		return stream, interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: method, IsClientStream: true}, handler)
+	}
+
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50000}})
+	_, err := open(peerCtx, "/svc/Import")
+	require.NoError(t, err)
+	stream, err := open(peerCtx, "/svc/Import")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1"}, stream.header.Get("retry-after"))
+
+	// Прочие методы и пользователи с API-ключом ограничиваются отдельно.
+	_, err = open(peerCtx, "/svc/Other")
+	require.NoError(t, err)
+	_, err = open(auth.WithUserID(peerCtx, "user1"), "/svc/Import")
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
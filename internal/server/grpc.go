@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mi4r/go-url-shortener/internal/auth"
+	"github.com/mi4r/go-url-shortener/internal/logger"
 	pb "github.com/mi4r/go-url-shortener/internal/proto"
 	"github.com/mi4r/go-url-shortener/internal/service"
 	"github.com/mi4r/go-url-shortener/internal/storage"
@@ -24,15 +28,25 @@ type GRPCServer struct {
 }
 
 var (
-	ErrURLNotFound   = errors.New("URL not found")
-	ErrURLDeleted    = errors.New("url deleted")
-	ErrAccessDenied  = errors.New("access denied")
+	ErrURLNotFound   = service.ErrNotFound
+	ErrURLDeleted    = service.ErrDeleted
+	ErrURLConflict   = service.ErrConflict
+	ErrAccessDenied  = service.ErrForbidden
+	ErrUnavailable   = service.ErrUnavailable
+	ErrAliasTaken    = service.ErrAliasTaken
+	ErrInvalidAlias  = service.ErrInvalidAlias
+	ErrInvalidExpiry = service.ErrInvalidExpiry
+	ErrInvalidQuery  = service.ErrInvalidQuery
+	ErrInvalidURL    = service.ErrInvalidURL
+	ErrInvalidImport = service.ErrInvalidImport
+	ErrBlocked       = service.ErrBlocked
+	ErrUnauthorized  = service.ErrUnauthorized
 	ErrMissingUserID = errors.New("missing user ID")
 )
 
-func NewGRPCServer(storage storage.Storage, baseURL string, trustedSubnet *net.IPNet) *GRPCServer {
+func NewGRPCServer(svc service.ShortenerInterface) *GRPCServer {
 	return &GRPCServer{
-		service: service.NewShortener(storage, baseURL, trustedSubnet),
+		service: svc,
 	}
 }
 
@@ -42,7 +56,20 @@ func (s *GRPCServer) Shorten(ctx context.Context, req *pb.ShortenRequest) (*pb.S
 		return nil, status.Error(codes.Unauthenticated, "missing user ID")
 	}
 
-	shortURL, err := s.service.Shorten(ctx, req.GetUrl(), userID)
+	expiresAt, err := expiryFromRequest(req.GetExpiresAt(), req.GetTtl())
+	if err != nil {
+		return nil, status.Error(convertErrorToCode(err), err.Error())
+	}
+
+	shortURL, err := s.service.Shorten(ctx, storage.URL{
+		OriginalURL: req.GetUrl(),
+		ShortURL:    req.GetAlias(),
+		UserID:      userID,
+		ExpiresAt:   expiresAt,
+	})
+	if errors.Is(err, ErrURLConflict) {
+		return nil, status.Errorf(codes.AlreadyExists, "%v: %s", err, shortURL)
+	}
 	if err != nil {
 		return nil, status.Error(convertErrorToCode(err), err.Error())
 	}
@@ -58,6 +85,17 @@ func (s *GRPCServer) GetOriginal(ctx context.Context, req *pb.GetOriginalRequest
 		return nil, status.Error(convertErrorToCode(err), err.Error())
 	}
 
+	click := storage.Click{ShortURL: req.GetId(), IP: peerIP(ctx)}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			click.UserAgent = ua[0]
+		}
+		if ref := md.Get("referer"); len(ref) > 0 {
+			click.Referrer = ref[0]
+		}
+	}
+	s.service.RecordClick(ctx, click)
+
 	return &pb.GetOriginalResponse{
 		Url: originalURL,
 	}, nil
@@ -69,51 +107,127 @@ func (s *GRPCServer) BatchShorten(ctx context.Context, req *pb.BatchShortenReque
 		return nil, status.Error(codes.Unauthenticated, "missing user ID")
 	}
 
-	items := make([]storage.URL, len(req.GetItems()))
+	// Элементы с некорректным сроком действия отклоняются сразу,
+	// остальные передаются в сервисный слой.
+	responseItems := make([]*pb.BatchShortenResponseItem, len(req.GetItems()))
+	items := make([]storage.URL, 0, len(req.GetItems()))
+	positions := make([]int, 0, len(req.GetItems()))
 	for i, item := range req.GetItems() {
-		items[i] = storage.URL{
+		expiresAt, err := expiryFromRequest(item.GetExpiresAt(), item.GetTtl())
+		if err != nil {
+			responseItems[i] = batchResponseItem(storage.BatchResult{
+				CorrelationID: item.GetCorrelationId(),
+				Status:        storage.BatchInvalid,
+				Err:           err,
+			})
+			continue
+		}
+		items = append(items, storage.URL{
 			CorrelationID: item.GetCorrelationId(),
 			OriginalURL:   item.GetOriginalUrl(),
+			ShortURL:      item.GetAlias(),
 			UserID:        userID,
-		}
+			ExpiresAt:     expiresAt,
+		})
+		positions = append(positions, i)
 	}
 
 	result, err := s.service.BatchShorten(ctx, items)
 	if err != nil {
 		return nil, status.Error(convertErrorToCode(err), err.Error())
 	}
+	for j, item := range result {
+		responseItems[positions[j]] = batchResponseItem(item)
+	}
+
+	return &pb.BatchShortenResponse{Items: responseItems}, nil
+}
+
+// batchResponseItem преобразует результат элемента пакета в элемент ответа.
+func batchResponseItem(result storage.BatchResult) *pb.BatchShortenResponseItem {
+	item := &pb.BatchShortenResponseItem{
+		CorrelationId: result.CorrelationID,
+		ShortUrl:      result.ShortURL,
+		Status:        result.Status.String(),
+	}
+	if result.Err != nil {
+		item.Error = result.Err.Error()
+	}
+	return item
+}
 
-	responseItems := make([]*pb.BatchShortenResponseItem, len(result))
-	for i, item := range result {
-		responseItems[i] = &pb.BatchShortenResponseItem{
-			CorrelationId: item.CorrelationID,
-			ShortUrl:      fmt.Sprintf("%s/%s", s.service.(*service.Shortener).BaseURL, item.ShortURL),
+// Import сокращает URL, переданные потоком. Записи без correlation_id
+// получают порядковый номер сообщения, начиная с 1.
+func (s *GRPCServer) Import(stream pb.Shortener_ImportServer) error {
+	ctx := stream.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == "" {
+		return status.Error(codes.Unauthenticated, "missing user ID")
+	}
+
+	n := 0
+	summary, err := s.service.Import(ctx, userID, func() (storage.URL, error) {
+		item, err := stream.Recv()
+		if err != nil {
+			return storage.URL{}, err
+		}
+		n++
+		url := storage.URL{
+			CorrelationID: item.GetCorrelationId(),
+			OriginalURL:   item.GetOriginalUrl(),
+			ShortURL:      item.GetAlias(),
 		}
+		if url.CorrelationID == "" {
+			url.CorrelationID = strconv.Itoa(n)
+		}
+		if url.ExpiresAt, err = expiryFromRequest(item.GetExpiresAt(), item.GetTtl()); err != nil {
+			return url, fmt.Errorf("%w: %w", service.ErrInvalidRecord, err)
+		}
+		return url, nil
+	})
+	if err != nil {
+		return status.Error(convertErrorToCode(err), err.Error())
 	}
 
-	return &pb.BatchShortenResponse{Items: responseItems}, nil
+	response := &pb.ImportResponse{
+		Created:  int64(summary.Created),
+		Existing: int64(summary.Existing),
+		Invalid:  int64(summary.Invalid),
+	}
+	for _, result := range summary.Rejected {
+		response.Rejected = append(response.Rejected, batchResponseItem(result))
+	}
+	return stream.SendAndClose(response)
 }
 
-func (s *GRPCServer) GetUserURLs(ctx context.Context, _ *pb.Empty) (*pb.GetUserURLsResponse, error) {
+func (s *GRPCServer) GetUserURLs(ctx context.Context, req *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error) {
 	userID := getUserIDFromContext(ctx)
 	if userID == "" {
 		return nil, status.Error(codes.Unauthenticated, "missing user ID")
 	}
 
-	urls, err := s.service.GetUserURLs(ctx, userID)
+	query, err := service.NewListQuery(int(req.GetLimit()), req.GetCursor(), req.GetOrder(), req.GetState(), req.GetSearch())
 	if err != nil {
 		return nil, status.Error(convertErrorToCode(err), err.Error())
 	}
+	page, err := s.service.GetUserURLs(ctx, userID, query)
+	if err != nil {
+		return nil, status.Error(convertErrorToCode(err), err.Error())
+	}
+	urls := page.URLs
 
 	responseItems := make([]*pb.URLResponseItem, len(urls))
 	for i, url := range urls {
 		responseItems[i] = &pb.URLResponseItem{
-			ShortUrl:    fmt.Sprintf("%s/%s", s.service.(*service.Shortener).BaseURL, url.ShortURL),
+			ShortUrl:    url.ShortURL,
 			OriginalUrl: url.OriginalURL,
+			CreatedAt:   unixOrZero(url.CreatedAt),
+			UpdatedAt:   unixOrZero(url.UpdatedAt),
+			DeletedAt:   unixOrZero(url.DeletedAt),
 		}
 	}
 
-	return &pb.GetUserURLsResponse{Items: responseItems}, nil
+	return &pb.GetUserURLsResponse{Items: responseItems, NextCursor: page.NextCursor}, nil
 }
 
 func (s *GRPCServer) DeleteUserURLs(ctx context.Context, req *pb.DeleteUserURLsRequest) (*pb.Empty, error) {
@@ -126,16 +240,19 @@ func (s *GRPCServer) DeleteUserURLs(ctx context.Context, req *pb.DeleteUserURLsR
 		return nil, status.Error(convertErrorToCode(err), err.Error())
 	}
 
-	return nil, nil
+	return &pb.Empty{}, nil
 }
 
 func (s *GRPCServer) Ping(ctx context.Context, _ *pb.Empty) (*pb.Empty, error) {
 	ok, err := s.service.Ping(ctx)
-	if !ok || err != nil {
+	if err != nil {
 		return nil, status.Error(convertErrorToCode(err), err.Error())
 	}
+	if !ok {
+		return nil, status.Error(codes.Unavailable, "storage is not available")
+	}
 
-	return nil, nil
+	return &pb.Empty{}, nil
 }
 
 func (s *GRPCServer) InternalStats(ctx context.Context, req *pb.InternalStatsRequest) (*pb.InternalStatsResponse, error) {
@@ -153,13 +270,80 @@ func (s *GRPCServer) InternalStats(ctx context.Context, req *pb.InternalStatsReq
 	}, nil
 }
 
+// expiryFromRequest вычисляет срок действия ссылки по Unix-времени и TTL в секундах из запроса.
+func expiryFromRequest(expiresAt, ttl int64) (time.Time, error) {
+	var t time.Time
+	if expiresAt != 0 {
+		t = time.Unix(expiresAt, 0)
+	}
+	return service.ResolveExpiry(t, time.Duration(ttl)*time.Second)
+}
+
+func (s *GRPCServer) GetLinkStats(ctx context.Context, req *pb.GetLinkStatsRequest) (*pb.GetLinkStatsResponse, error) {
+	userID := getUserIDFromContext(ctx)
+	if userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing user ID")
+	}
+
+	stats, err := s.service.GetLinkStats(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, status.Error(convertErrorToCode(err), err.Error())
+	}
+
+	return &pb.GetLinkStatsResponse{
+		ShortUrl:   stats.ShortURL,
+		Clicks:     int64(stats.Clicks),
+		FirstClick: unixOrZero(stats.FirstClick),
+		LastClick:  unixOrZero(stats.LastClick),
+		Referrers:  statsCounts(stats.Referrers),
+		UserAgents: statsCounts(stats.UserAgents),
+		Countries:  statsCounts(stats.Countries),
+	}, nil
+}
+
+// statsCounts преобразует разбивку статистики в список, упорядоченный по убыванию числа переходов.
+func statsCounts(counts map[string]int) []*pb.StatsCount {
+	items := make([]*pb.StatsCount, 0, len(counts))
+	for key, count := range counts {
+		items = append(items, &pb.StatsCount{Key: key, Count: int64(count)})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// unixOrZero возвращает Unix-время или 0 для нулевого времени.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// peerIP возвращает IP-адрес клиента из контекста gRPC.
+func peerIP(ctx context.Context) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
 func getUserIDFromContext(ctx context.Context) string {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return ""
 	}
 
-	values := md.Get("user-id")
+	values := md.Get(userIDMetadata)
 	if len(values) == 0 {
 		return ""
 	}
@@ -172,39 +356,144 @@ func convertErrorToCode(err error) codes.Code {
 	case errors.Is(err, ErrURLNotFound):
 		return codes.NotFound
 	case errors.Is(err, ErrURLDeleted):
-		return codes.NotFound
-	case errors.Is(err, ErrAccessDenied):
+		// Удалённые и истёкшие ссылки существуют, но недоступны, как 410 Gone в HTTP.
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrURLConflict), errors.Is(err, ErrAliasTaken):
+		return codes.AlreadyExists
+	case errors.Is(err, ErrInvalidAlias), errors.Is(err, ErrInvalidExpiry), errors.Is(err, ErrInvalidQuery),
+		errors.Is(err, ErrInvalidURL), errors.Is(err, ErrInvalidImport):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrAccessDenied), errors.Is(err, ErrBlocked):
 		return codes.PermissionDenied
-	case errors.Is(err, ErrMissingUserID):
+	case errors.Is(err, ErrMissingUserID), errors.Is(err, ErrUnauthorized):
 		return codes.Unauthenticated
+	case errors.Is(err, ErrUnavailable):
+		return codes.Unavailable
 	default:
 		return codes.Internal
 	}
 }
 
-func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	// Всегда инициализируем метаданные
-	md, ok := metadata.FromIncomingContext(ctx)
+// Ключи метаданных gRPC для идентификации пользователя.
+const (
+	// userIDMetadata передаёт обработчикам проверенный идентификатор пользователя.
+	// Значение, полученное от клиента, никогда не используется напрямую.
+	userIDMetadata = "user-id"
+	// userTokenMetadata содержит подписанный токен пользователя в формате куки
+	// auth.SetUserCookie. Клиент передаёт его в запросе, а сервер возвращает
+	// новый токен в заголовке ответа.
+	userTokenMetadata = "user-token"
+)
+
+// APIKeyInterceptor аутентифицирует унарные вызовы с метаданными "authorization: Bearer <key>".
+func APIKeyInterceptor(svc service.ShortenerInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateAPIKey(ctx, svc)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// APIKeyStreamInterceptor аутентифицирует потоковые вызовы с метаданными "authorization: Bearer <key>".
+func APIKeyStreamInterceptor(svc service.ShortenerInterface) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateAPIKey(ss.Context(), svc)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identifiedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticateAPIKey проверяет API-ключ из метаданных и добавляет владельца
+// ключа в контекст. Вызовы без ключа пропускаются без изменений.
+func authenticateAPIKey(ctx context.Context, svc service.ShortenerInterface) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	key, ok := auth.BearerToken(values[0])
 	if !ok {
-		md = metadata.New(nil)
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata")
 	}
+	userID, err := svc.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		return nil, status.Error(convertErrorToCode(err), err.Error())
+	}
+	return auth.WithUserID(ctx, userID), nil
+}
 
-	var userID string
-	userIDs := md.Get("user-id")
-	if len(userIDs) > 0 {
-		userID = userIDs[0]
-	} else {
-		userID = auth.GenerateUserID()
-		md.Set("user-id", userID)
+// AuthInterceptor определяет пользователя унарного вызова по API-ключу или
+// подписанному токену. Новый токен возвращается в заголовке ответа.
+func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, header, err := identify(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			logger.Sugar.Warn("Failed to send user token: ", err)
+		}
 	}
+	return handler(ctx, req)
+}
 
-	// Создаем новый контекст с обновленными метаданными
-	ctx = metadata.NewIncomingContext(ctx, md)
+// StreamAuthInterceptor определяет пользователя потокового вызова так же, как AuthInterceptor.
+func StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, header, err := identify(ss.Context())
+	if err != nil {
+		return err
+	}
+	if header != nil {
+		if err := ss.SetHeader(header); err != nil {
+			logger.Sugar.Warn("Failed to send user token: ", err)
+		}
+	}
+	return handler(srv, &identifiedStream{ServerStream: ss, ctx: ctx})
+}
+
+// identify записывает в метаданные user-id проверенного пользователя. Пользователь
+// берётся из API-ключа, затем из токена user-token; при их отсутствии создаётся
+// новый. header содержит токен для ответа, если клиенту нужно его обновить.
+// Неподписанный user-id и токены с неверной подписью отклоняются.
+func identify(ctx context.Context) (_ context.Context, header metadata.MD, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
 
-	// Добавляем user-id в исходящие заголовки если необходимо
-	if len(userIDs) == 0 {
-		ctx = metadata.AppendToOutgoingContext(ctx, "set-user-id", userID)
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		tokens := md.Get(userTokenMetadata)
+		switch {
+		case len(tokens) > 0:
+			var current bool
+			userID, current, ok = auth.VerifyToken(tokens[0])
+			if !ok {
+				return nil, nil, status.Error(codes.Unauthenticated, "invalid user token")
+			}
+			if !current {
+				header = metadata.Pairs(userTokenMetadata, auth.IssueToken(userID))
+			}
+		case len(md.Get(userIDMetadata)) > 0:
+			return nil, nil, status.Error(codes.Unauthenticated, "unsigned user-id metadata, use user-token")
+		default:
+			userID = auth.GenerateUserID()
+			header = metadata.Pairs(userTokenMetadata, auth.IssueToken(userID))
+		}
 	}
 
-	return handler(ctx, req)
+	md.Set(userIDMetadata, userID)
+	return metadata.NewIncomingContext(ctx, md), header, nil
+}
+
+// identifiedStream подменяет контекст потока контекстом с проверенным пользователем.
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает контекст с проверенным пользователем.
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
 }
@@ -3,24 +3,36 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/mi4r/go-url-shortener/internal/auth"
+	"github.com/mi4r/go-url-shortener/internal/logger"
 	pb "github.com/mi4r/go-url-shortener/internal/proto"
+	"github.com/mi4r/go-url-shortener/internal/ratelimit"
+	"github.com/mi4r/go-url-shortener/internal/service"
 	"github.com/mi4r/go-url-shortener/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) Shorten(ctx context.Context, originalURL, userID string) (string, error) {
-	args := m.Called(ctx, originalURL, userID)
+func (m *MockService) Shorten(ctx context.Context, url storage.URL) (string, error) {
+	args := m.Called(ctx, url)
 	return args.String(0), args.Error(1)
 }
 
@@ -29,14 +41,19 @@ func (m *MockService) GetOriginal(ctx context.Context, shortID string) (string,
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockService) BatchShorten(ctx context.Context, items []storage.URL) ([]storage.URL, error) {
+func (m *MockService) BatchShorten(ctx context.Context, items []storage.URL) ([]storage.BatchResult, error) {
 	args := m.Called(ctx, items)
-	return args.Get(0).([]storage.URL), args.Error(1)
+	return args.Get(0).([]storage.BatchResult), args.Error(1)
 }
 
-func (m *MockService) GetUserURLs(ctx context.Context, userID string) ([]storage.URL, error) {
+func (m *MockService) Import(ctx context.Context, userID string, next service.URLSource) (service.ImportSummary, error) {
 	args := m.Called(ctx, userID)
-	return args.Get(0).([]storage.URL), args.Error(1)
+	return args.Get(0).(service.ImportSummary), args.Error(1)
+}
+
+func (m *MockService) GetUserURLs(ctx context.Context, userID string, query storage.ListQuery) (storage.URLPage, error) {
+	args := m.Called(ctx, userID, query)
+	return args.Get(0).(storage.URLPage), args.Error(1)
 }
 
 func (m *MockService) DeleteUserURLs(ctx context.Context, userID string, ids []string) error {
@@ -54,13 +71,42 @@ func (m *MockService) InternalStats(ctx context.Context, ip net.IP) (int, int, e
 	return args.Int(0), args.Int(1), args.Error(2)
 }
 
+func (m *MockService) RecordClick(ctx context.Context, click storage.Click) {
+	m.Called(ctx, click)
+}
+
+func (m *MockService) GetLinkStats(ctx context.Context, userID, shortID string) (storage.LinkStats, error) {
+	args := m.Called(ctx, userID, shortID)
+	return args.Get(0).(storage.LinkStats), args.Error(1)
+}
+
+func (m *MockService) CreateAPIKey(ctx context.Context, userID, name string) (storage.APIKey, string, error) {
+	args := m.Called(ctx, userID, name)
+	return args.Get(0).(storage.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockService) ListAPIKeys(ctx context.Context, userID string) ([]storage.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.APIKey), args.Error(1)
+}
+
+func (m *MockService) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockService) AuthenticateAPIKey(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
 func TestGRPCServer(t *testing.T) {
 	ctx := context.Background()
 	mockService := new(MockService)
 	server := &GRPCServer{service: mockService}
 
 	t.Run("Shorten Success", func(t *testing.T) {
-		mockService.On("Shorten", mock.Anything, "http://test.com", "user123").
+		mockService.On("Shorten", mock.Anything, storage.URL{OriginalURL: "http://test.com", UserID: "user123"}).
 			Return("http://short/abc", nil)
 
 		md := metadata.New(map[string]string{"user-id": "user123"})
@@ -71,6 +117,27 @@ func TestGRPCServer(t *testing.T) {
 		assert.Equal(t, "http://short/abc", resp.Result)
 	})
 
+	t.Run("Shorten Conflict", func(t *testing.T) {
+		mockService.On("Shorten", mock.Anything, storage.URL{OriginalURL: "http://dup.com", UserID: "user123"}).
+			Return("http://short/dup", ErrURLConflict)
+
+		ctxWithUser := contextWithUser("user123")
+		_, err := server.Shorten(ctxWithUser, &pb.ShortenRequest{Url: "http://dup.com"})
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "http://short/dup")
+	})
+
+	t.Run("Shorten Alias Taken", func(t *testing.T) {
+		mockService.On("Shorten", mock.Anything, storage.URL{OriginalURL: "http://new.com", ShortURL: "promo", UserID: "user123"}).
+			Return("", ErrAliasTaken)
+
+		ctxWithUser := contextWithUser("user123")
+		_, err := server.Shorten(ctxWithUser, &pb.ShortenRequest{Url: "http://new.com", Alias: "promo"})
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
 	t.Run("Shorten Unauthenticated", func(t *testing.T) {
 		_, err := server.Shorten(ctx, &pb.ShortenRequest{Url: "http://test.com"})
 		assert.Equal(t, codes.Unauthenticated, status.Code(err))
@@ -85,10 +152,12 @@ func TestGetOriginal(t *testing.T) {
 	t.Run("GetOriginal Found", func(t *testing.T) {
 		mockService.On("GetOriginal", ctx, "abc").
 			Return("http://original.com", nil)
+		mockService.On("RecordClick", ctx, storage.Click{ShortURL: "abc"}).Return()
 
 		resp, err := server.GetOriginal(ctx, &pb.GetOriginalRequest{Id: "abc"})
 		assert.NoError(t, err)
 		assert.Equal(t, "http://original.com", resp.Url)
+		mockService.AssertCalled(t, "RecordClick", ctx, storage.Click{ShortURL: "abc"})
 	})
 
 	t.Run("GetOriginal NotFound", func(t *testing.T) {
@@ -98,32 +167,199 @@ func TestGetOriginal(t *testing.T) {
 		_, err := server.GetOriginal(ctx, &pb.GetOriginalRequest{Id: "invalid"})
 		assert.Equal(t, codes.NotFound, status.Code(err))
 	})
+
+	t.Run("GetOriginal Deleted", func(t *testing.T) {
+		mockService.On("GetOriginal", ctx, "deleted").
+			Return("", ErrURLDeleted)
+
+		_, err := server.GetOriginal(ctx, &pb.GetOriginalRequest{Id: "deleted"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
 }
 
-func TestAuthInterceptor(t *testing.T) {
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+func TestGetLinkStats(t *testing.T) {
+	mockService := new(MockService)
+	server := &GRPCServer{service: mockService}
+	first := time.Unix(1700000000, 0)
+
+	t.Run("GetLinkStats Success", func(t *testing.T) {
+		stats := storage.NewLinkStats("abc")
+		stats.Clicks = 3
+		stats.FirstClick = first
+		stats.LastClick = first.Add(time.Hour)
+		stats.Countries["RU"] = 1
+		stats.Countries["DE"] = 2
+		mockService.On("GetLinkStats", mock.Anything, "user123", "abc").Return(stats, nil)
+
+		resp, err := server.GetLinkStats(contextWithUser("user123"), &pb.GetLinkStatsRequest{Id: "abc"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), resp.Clicks)
+		assert.Equal(t, first.Unix(), resp.FirstClick)
+		assert.Equal(t, "DE", resp.Countries[0].Key)
+		assert.Equal(t, int64(2), resp.Countries[0].Count)
+	})
+
+	t.Run("GetLinkStats Forbidden", func(t *testing.T) {
+		mockService.On("GetLinkStats", mock.Anything, "user123", "foreign").Return(storage.LinkStats{}, ErrAccessDenied)
+
+		_, err := server.GetLinkStats(contextWithUser("user123"), &pb.GetLinkStatsRequest{Id: "foreign"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("GetLinkStats Unauthenticated", func(t *testing.T) {
+		_, err := server.GetLinkStats(context.Background(), &pb.GetLinkStatsRequest{Id: "abc"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+// headerStream запоминает заголовки ответа, установленные через grpc.SetHeader.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/shortener.Shortener/Test" }
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+// serverStream — серверный поток с заданным контекстом, запоминающий заголовки ответа.
+type serverStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+func (s *serverStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// callWithStream вызывает AuthInterceptor с контекстом, в котором можно устанавливать заголовки.
+func callWithStream(ctx context.Context) (context.Context, metadata.MD, error) {
+	stream := &headerStream{}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+	resp, err := AuthInterceptor(ctx, nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
 		return ctx, nil
+	})
+	if err != nil {
+		return nil, stream.header, err
 	}
+	return resp.(context.Context), stream.header, nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
 
 	t.Run("New UserID Generation", func(t *testing.T) {
-		resp, err := AuthInterceptor(context.Background(), nil, nil, handler)
+		ctx, header, err := callWithStream(context.Background())
 		assert.NoError(t, err)
 
-		ctx := resp.(context.Context)
 		md, _ := metadata.FromIncomingContext(ctx)
 		assert.Len(t, md.Get("user-id"), 1)
-	})
+		tokens := header.Get("user-token")
+		assert.Len(t, tokens, 1)
 
-	t.Run("Existing UserID", func(t *testing.T) {
-		md := metadata.New(map[string]string{"user-id": "existing"})
-		ctx := metadata.NewIncomingContext(context.Background(), md)
+		userID, _, ok := auth.VerifyToken(tokens[0])
+		assert.True(t, ok)
+		assert.Equal(t, md.Get("user-id")[0], userID)
+	})
 
-		resp, err := AuthInterceptor(ctx, nil, nil, handler)
+	t.Run("Signed Token", func(t *testing.T) {
+		md := metadata.New(map[string]string{"user-token": auth.IssueToken("existing")})
+		ctx, header, err := callWithStream(metadata.NewIncomingContext(context.Background(), md))
 		assert.NoError(t, err)
 
-		ctx = resp.(context.Context)
 		md, _ = metadata.FromIncomingContext(ctx)
 		assert.Equal(t, "existing", md.Get("user-id")[0])
+		assert.Empty(t, header.Get("user-token"), "действующий токен не переиздаётся")
+	})
+
+	t.Run("Forged Token", func(t *testing.T) {
+		md := metadata.New(map[string]string{"user-token": "victim|" + auth.CurrentKeyring().Active() + "|deadbeef"})
+		_, _, err := callWithStream(metadata.NewIncomingContext(context.Background(), md))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Unsigned UserID", func(t *testing.T) {
+		md := metadata.New(map[string]string{"user-id": "victim"})
+		_, _, err := callWithStream(metadata.NewIncomingContext(context.Background(), md))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("API Key User", func(t *testing.T) {
+		md := metadata.New(map[string]string{"user-id": "spoofed"})
+		ctx := auth.WithUserID(metadata.NewIncomingContext(context.Background(), md), "owner")
+		ctx, header, err := callWithStream(ctx)
+		assert.NoError(t, err)
+
+		md, _ = metadata.FromIncomingContext(ctx)
+		assert.Equal(t, []string{"owner"}, md.Get("user-id"))
+		assert.Empty(t, header)
+	})
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+
+	var handlerCtx context.Context
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		handlerCtx = ss.Context()
+		return nil
+	}
+
+	stream := &serverStream{ctx: context.Background()}
+	assert.NoError(t, StreamAuthInterceptor(nil, stream, nil, handler))
+	md, _ := metadata.FromIncomingContext(handlerCtx)
+	assert.Len(t, md.Get("user-id"), 1)
+	assert.Len(t, stream.header.Get("user-token"), 1)
+
+	forged := metadata.New(map[string]string{"user-token": "victim|x|y"})
+	stream = &serverStream{ctx: metadata.NewIncomingContext(context.Background(), forged)}
+	err := StreamAuthInterceptor(nil, stream, nil, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAPIKeyInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return ctx, nil
+	}
+	mockService := new(MockService)
+	mockService.On("AuthenticateAPIKey", mock.Anything, "good").Return("owner", nil)
+	mockService.On("AuthenticateAPIKey", mock.Anything, "revoked").Return("", ErrUnauthorized)
+	interceptor := APIKeyInterceptor(mockService)
+
+	t.Run("Valid Key", func(t *testing.T) {
+		md := metadata.New(map[string]string{"authorization": "Bearer good"})
+		resp, err := interceptor(metadata.NewIncomingContext(context.Background(), md), nil, nil, handler)
+		assert.NoError(t, err)
+
+		userID, ok := auth.UserIDFromContext(resp.(context.Context))
+		assert.True(t, ok)
+		assert.Equal(t, "owner", userID)
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		md := metadata.New(map[string]string{"authorization": "Bearer revoked"})
+		_, err := interceptor(metadata.NewIncomingContext(context.Background(), md), nil, nil, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Malformed Metadata", func(t *testing.T) {
+		md := metadata.New(map[string]string{"authorization": "Basic abc"})
+		_, err := interceptor(metadata.NewIncomingContext(context.Background(), md), nil, nil, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("No Key", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil, nil, handler)
+		assert.NoError(t, err)
+
+		_, ok := auth.UserIDFromContext(resp.(context.Context))
+		assert.False(t, ok)
 	})
 }
 
@@ -134,8 +370,14 @@ func TestConvertErrorToCode(t *testing.T) {
 		expected codes.Code
 	}{
 		{"URL Not Found", ErrURLNotFound, codes.NotFound},
-		{"URL Deleted", ErrURLDeleted, codes.NotFound},
+		{"URL Deleted", ErrURLDeleted, codes.FailedPrecondition},
+		{"URL Expired", service.ErrExpired, codes.FailedPrecondition},
+		{"URL Conflict", ErrURLConflict, codes.AlreadyExists},
+		{"Alias Taken", ErrAliasTaken, codes.AlreadyExists},
+		{"Invalid Alias", fmt.Errorf("%w: too short", ErrInvalidAlias), codes.InvalidArgument},
+		{"Invalid URL", fmt.Errorf("%w: scheme must be http or https", ErrInvalidURL), codes.InvalidArgument},
 		{"Access Denied", ErrAccessDenied, codes.PermissionDenied},
+		{"Blocked", fmt.Errorf("%w by rule %q", ErrBlocked, "evil.com"), codes.PermissionDenied},
 		{"Missing User ID", ErrMissingUserID, codes.Unauthenticated},
 		{"Unknown Error", errors.New("unknown error"), codes.Internal},
 	}
@@ -163,7 +405,7 @@ func TestBatchShorten(t *testing.T) {
 			{CorrelationID: "2", ShortURL: "http://test2.com"},
 		}
 
-		expected := []storage.URL{
+		expected := []storage.BatchResult{
 			{CorrelationID: "1", ShortURL: "abc"},
 			{CorrelationID: "2", ShortURL: "def"},
 		}
@@ -190,6 +432,91 @@ func TestBatchShorten(t *testing.T) {
 	})
 }
 
+func TestGRPCServer_BatchShorten(t *testing.T) {
+	mockService := new(MockService)
+	server := &GRPCServer{service: mockService}
+
+	mockService.On("BatchShorten", mock.Anything, []storage.URL{
+		{CorrelationID: "1", OriginalURL: "http://a.com", UserID: "user123"},
+		{CorrelationID: "3", OriginalURL: "http://b.com", ShortURL: "taken", UserID: "user123"},
+	}).Return([]storage.BatchResult{
+		{CorrelationID: "1", ShortURL: "http://short/abc", Status: storage.BatchCreated},
+		{CorrelationID: "3", Status: storage.BatchInvalid, Err: ErrAliasTaken},
+	}, nil)
+
+	resp, err := server.BatchShorten(contextWithUser("user123"), &pb.BatchShortenRequest{
+		Items: []*pb.BatchShortenRequestItem{
+			{CorrelationId: "1", OriginalUrl: "http://a.com"},
+			{CorrelationId: "2", OriginalUrl: "http://c.com", Ttl: -1},
+			{CorrelationId: "3", OriginalUrl: "http://b.com", Alias: "taken"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetItems(), 3)
+
+	assert.Equal(t, "http://short/abc", resp.GetItems()[0].GetShortUrl())
+	assert.Equal(t, "created", resp.GetItems()[0].GetStatus())
+	assert.Equal(t, "2", resp.GetItems()[1].GetCorrelationId())
+	assert.Equal(t, "invalid", resp.GetItems()[1].GetStatus())
+	assert.Contains(t, resp.GetItems()[1].GetError(), "ttl")
+	assert.Equal(t, "invalid", resp.GetItems()[2].GetStatus())
+	assert.Equal(t, ErrAliasTaken.Error(), resp.GetItems()[2].GetError())
+	mockService.AssertExpectations(t)
+}
+
+// importStream — поток Import с заранее заданными сообщениями.
+type importStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	items    []*pb.BatchShortenRequestItem
+	response *pb.ImportResponse
+}
+
+func (s *importStream) Context() context.Context { return s.ctx }
+
+func (s *importStream) Recv() (*pb.BatchShortenRequestItem, error) {
+	if len(s.items) == 0 {
+		return nil, io.EOF
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+func (s *importStream) SendAndClose(response *pb.ImportResponse) error {
+	s.response = response
+	return nil
+}
+
+func TestGRPCServer_Import(t *testing.T) {
+	server := &GRPCServer{service: service.NewShortener(storage.NewMemoryStorage(), "http://short", nil)}
+
+	stream := &importStream{
+		ctx: contextWithUser("user123"),
+		items: []*pb.BatchShortenRequestItem{
+			{OriginalUrl: "http://a.com"},
+			{CorrelationId: "dup", OriginalUrl: "http://a.com"},
+			{OriginalUrl: "http://b.com", Ttl: -1},
+			{OriginalUrl: "ftp://c.com"},
+		},
+	}
+	require.NoError(t, server.Import(stream))
+
+	resp := stream.response
+	assert.Equal(t, int64(1), resp.GetCreated())
+	assert.Equal(t, int64(1), resp.GetExisting())
+	assert.Equal(t, int64(2), resp.GetInvalid())
+	require.Len(t, resp.GetRejected(), 2)
+	assert.Equal(t, "3", resp.GetRejected()[0].GetCorrelationId())
+	assert.Contains(t, resp.GetRejected()[0].GetError(), "ttl")
+	assert.Equal(t, "4", resp.GetRejected()[1].GetCorrelationId())
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		err := server.Import(&importStream{ctx: context.Background()})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
 func TestPing(t *testing.T) {
 	mockService := new(MockService)
 
@@ -216,29 +543,42 @@ func TestPing(t *testing.T) {
 func TestGetUserURLs(t *testing.T) {
 	ctx := contextWithUser("user123")
 	mockService := new(MockService)
+	server := &GRPCServer{service: mockService}
 
 	t.Run("Success", func(t *testing.T) {
-		expected := []storage.URL{
-			{ShortURL: "abc", OriginalURL: "http://test1.com"},
-			{ShortURL: "def", OriginalURL: "http://test2.com"},
+		expected := storage.URLPage{
+			URLs: []storage.URL{
+				{ShortURL: "abc", OriginalURL: "http://test1.com"},
+				{ShortURL: "def", OriginalURL: "http://test2.com"},
+			},
+			NextCursor: "next",
 		}
+		query := storage.ListQuery{Limit: 2, Desc: true, State: storage.StateActive, Search: "test"}
 
-		mockService.On("GetUserURLs", ctx, "user123").
+		mockService.On("GetUserURLs", ctx, "user123", query).
 			Return(expected, nil)
 
-		resp, err := mockService.GetUserURLs(ctx, "user123")
+		resp, err := server.GetUserURLs(ctx, &pb.GetUserURLsRequest{Limit: 2, Order: "desc", State: "active", Search: "test"})
 
 		assert.NoError(t, err)
-		assert.Len(t, resp, 2)
-		assert.Equal(t, "http://test1.com", resp[0].OriginalURL)
+		assert.Len(t, resp.Items, 2)
+		assert.Equal(t, "http://test1.com", resp.Items[0].OriginalUrl)
+		assert.Equal(t, "next", resp.NextCursor)
 	})
 
 	t.Run("Empty Result", func(t *testing.T) {
-		mockService.On("GetUserURLs", ctx, "user123").
-			Return([]storage.URL{}, nil)
+		mockService.On("GetUserURLs", ctx, "user123", storage.ListQuery{State: storage.StateActive}).
+			Return(storage.URLPage{}, nil)
 
-		_, err := mockService.GetUserURLs(ctx, "user123")
+		resp, err := server.GetUserURLs(ctx, &pb.GetUserURLsRequest{})
 		assert.NoError(t, err)
+		assert.Empty(t, resp.Items)
+		assert.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("Invalid Query", func(t *testing.T) {
+		_, err := server.GetUserURLs(ctx, &pb.GetUserURLsRequest{State: "archived"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	})
 }
 
@@ -263,3 +603,71 @@ func TestDeleteUserURLs(t *testing.T) {
 		mockService.DeleteUserURLs(ctx, "user123", []string{})
 	})
 }
+
+func TestNewServerGRPC_RateLimitTokenless(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	mockService := new(MockService)
+	mockService.On("Shorten", mock.Anything, mock.Anything).Return("http://short/abc", nil)
+	limits := ratelimit.Limits{Shorten: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.001, Burst: 3})}
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := NewServerGRPC(mockService, limits)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewShortenerClient(conn)
+
+	// Каждый вызов без токена получает нового пользователя, но лимит общий для адреса.
+	var tokens []string
+	for i := 0; i < 5; i++ {
+		var header metadata.MD
+		_, err := client.Shorten(context.Background(), &pb.ShortenRequest{Url: "http://test.com"}, grpc.Header(&header))
+		if i < 3 {
+			require.NoError(t, err)
+			tokens = append(tokens, header.Get(userTokenMetadata)...)
+		} else {
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		}
+	}
+
+	// Выданные токены тоже не дают отдельного ведра.
+	require.Len(t, tokens, 3)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userTokenMetadata, tokens[0])
+	_, err = client.Shorten(ctx, &pb.ShortenRequest{Url: "http://test.com"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestNewServerGRPC_RateLimitImport(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	mockService := new(MockService)
+	mockService.On("Import", mock.Anything, mock.Anything).Return(service.ImportSummary{Created: 1}, nil)
+	limits := ratelimit.Limits{Batch: ratelimit.NewLimiter(ratelimit.Limit{Rate: 0.001, Burst: 1})}
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := NewServerGRPC(mockService, limits)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewShortenerClient(conn)
+
+	importOnce := func() error {
+		stream, err := client.Import(context.Background())
+		require.NoError(t, err)
+		_, err = stream.CloseAndRecv()
+		return err
+	}
+	require.NoError(t, importOnce())
+	// Поточная загрузка ограничивается тем же лимитом, что и пакетное сокращение.
+	assert.Equal(t, codes.ResourceExhausted, status.Code(importOnce()))
+	mockService.AssertNumberOfCalls(t, "Import", 1)
+}
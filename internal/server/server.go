@@ -12,37 +12,46 @@ import (
 	"github.com/mi4r/go-url-shortener/internal/logger"
 	"github.com/mi4r/go-url-shortener/internal/profiler"
 	pb "github.com/mi4r/go-url-shortener/internal/proto"
-	"github.com/mi4r/go-url-shortener/internal/storage"
+	"github.com/mi4r/go-url-shortener/internal/ratelimit"
+	"github.com/mi4r/go-url-shortener/internal/service"
 )
 
 // NewRouter создаёт маршрутизатор Chi с зарегистрированными обработчиками.
-func NewRouter(storage storage.Storage, trustedSubnet *net.IPNet) *chi.Mux {
+// Все обработчики работают через сервисный слой, общий с gRPC-сервером.
+// limits ограничивают частоту сокращения и переходов по ссылкам.
+func NewRouter(svc service.ShortenerInterface, limits ratelimit.Limits) *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(logger.LoggingMiddleware)
 	r.Use(compress.CompressMiddleware)
+	r.Use(handlers.APIKeyMiddleware(svc))
 
 	r.Route("/", func(r chi.Router) {
-		r.Post("/", handlers.ShortenURLHandler(storage))
+		r.With(ratelimit.Middleware(limits.Shorten, limits.TrustedSubnet)).Post("/", handlers.ShortenURLHandler(svc))
 		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", handlers.RedirectHandler(storage))
+			r.With(ratelimit.Middleware(limits.Redirect, limits.TrustedSubnet)).Get("/", handlers.RedirectHandler(svc, limits.TrustedSubnet))
 		})
 	})
 
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/shorten", func(r chi.Router) {
-			r.Post("/", handlers.APIShortenURLHandler(storage))
-			r.Post("/batch", handlers.BatchShortenURLHandler(storage))
+			r.With(ratelimit.Middleware(limits.Shorten, limits.TrustedSubnet)).Post("/", handlers.APIShortenURLHandler(svc))
+			r.With(ratelimit.Middleware(limits.Batch, limits.TrustedSubnet)).Post("/batch", handlers.BatchShortenURLHandler(svc))
+			r.With(ratelimit.Middleware(limits.Batch, limits.TrustedSubnet)).Post("/import", handlers.ImportURLsHandler(svc))
 		})
 		r.Route("/user", func(r chi.Router) {
-			r.Get("/urls", handlers.UserURLsHandler(storage))
-			r.Delete("/urls", handlers.DeleteUserURLsHandler(storage))
+			r.Get("/urls", handlers.UserURLsHandler(svc))
+			r.Delete("/urls", handlers.DeleteUserURLsHandler(svc))
+			r.Get("/urls/{id}/stats", handlers.LinkStatsHandler(svc))
+			r.Post("/keys", handlers.CreateAPIKeyHandler(svc))
+			r.Get("/keys", handlers.ListAPIKeysHandler(svc))
+			r.Delete("/keys/{id}", handlers.RevokeAPIKeyHandler(svc))
 		})
 		r.Route("/internal", func(r chi.Router) {
-			r.Get("/stats", handlers.InternalStatsHandler(storage, trustedSubnet))
+			r.Get("/stats", handlers.InternalStatsHandler(svc))
 		})
 	})
 
-	r.Get("/ping", handlers.PingHandler(storage))
+	r.Get("/ping", handlers.PingHandler(svc))
 	r.Mount("/debug", profiler.Profiler())
 
 	return r
@@ -56,13 +65,20 @@ func NewServer(addr string, handler http.Handler) *http.Server {
 	}
 }
 
-func NewServerGRPC(storageImpl storage.Storage, trustedSubnet *net.IPNet) *grpc.Server {
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(AuthInterceptor))
-	pb.RegisterShortenerServer(grpcServer, NewGRPCServer(
-		storageImpl,
-		handlers.Flags.BaseShortAddr,
-		trustedSubnet,
-	))
+// NewServerGRPC создаёт gRPC-сервер. Ограничение частоты вызовов применяется
+// после аутентификации, чтобы лимит учитывался по владельцу API-ключа.
+func NewServerGRPC(svc service.ShortenerInterface, limits ratelimit.Limits) *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(APIKeyInterceptor(svc), AuthInterceptor, ratelimit.UnaryInterceptor(map[string]*ratelimit.Limiter{
+			pb.Shortener_Shorten_FullMethodName:      limits.Shorten,
+			pb.Shortener_BatchShorten_FullMethodName: limits.Batch,
+			pb.Shortener_GetOriginal_FullMethodName:  limits.Redirect,
+		})),
+		grpc.ChainStreamInterceptor(APIKeyStreamInterceptor(svc), StreamAuthInterceptor, ratelimit.StreamInterceptor(map[string]*ratelimit.Limiter{
+			pb.Shortener_Import_FullMethodName: limits.Batch,
+		})),
+	)
+	pb.RegisterShortenerServer(grpcServer, NewGRPCServer(svc))
 	return grpcServer
 }
 
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidAlias возвращается, если пользовательский алиас не проходит проверку AliasPolicy.
+var ErrInvalidAlias = errors.New("invalid alias")
+
+// Значения AliasPolicy по умолчанию.
+const (
+	DefaultAliasCharset   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+	DefaultAliasMinLength = 3
+	DefaultAliasMaxLength = 32
+)
+
+// DefaultReservedAliases содержит алиасы, совпадающие с маршрутами сервиса.
+var DefaultReservedAliases = []string{"api", "ping", "debug"}
+
+// AliasPolicy задаёт правила для пользовательских коротких идентификаторов.
+type AliasPolicy struct {
+	Charset   string   // Допустимые символы.
+	MinLength int      // Минимальная длина.
+	MaxLength int      // Максимальная длина.
+	Reserved  []string // Зарезервированные слова (без учёта регистра).
+}
+
+// DefaultAliasPolicy возвращает политику алиасов по умолчанию.
+func DefaultAliasPolicy() AliasPolicy {
+	return AliasPolicy{
+		Charset:   DefaultAliasCharset,
+		MinLength: DefaultAliasMinLength,
+		MaxLength: DefaultAliasMaxLength,
+		Reserved:  DefaultReservedAliases,
+	}
+}
+
+// Validate проверяет алиас. Ошибка оборачивает ErrInvalidAlias и описывает причину.
+func (p AliasPolicy) Validate(alias string) error {
+	if len(alias) < p.MinLength || (p.MaxLength > 0 && len(alias) > p.MaxLength) {
+		return fmt.Errorf("%w: length must be between %d and %d", ErrInvalidAlias, p.MinLength, p.MaxLength)
+	}
+	for _, r := range alias {
+		if !strings.ContainsRune(p.Charset, r) {
+			return fmt.Errorf("%w: character %q is not allowed", ErrInvalidAlias, r)
+		}
+	}
+	for _, word := range p.Reserved {
+		if strings.EqualFold(alias, word) {
+			return fmt.Errorf("%w: %q is reserved", ErrInvalidAlias, alias)
+		}
+	}
+	return nil
+}
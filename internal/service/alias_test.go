@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasPolicy_Validate(t *testing.T) {
+	policy := DefaultAliasPolicy()
+
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr bool
+	}{
+		{"valid", "spring-sale", false},
+		{"valid with underscore", "Promo_2024", false},
+		{"too short", "ab", true},
+		{"too long", "abcdefghijklmnopqrstuvwxyz0123456789", true},
+		{"bad character", "spring/sale", true},
+		{"non ascii", "весна", true},
+		{"reserved", "api", true},
+		{"reserved case insensitive", "PING", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.alias)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAlias)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/internal/auth"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// maxAPIKeyNameLength ограничивает длину названия API-ключа.
+const maxAPIKeyNameLength = 100
+
+var (
+	// ErrUnauthorized возвращается для неизвестного или отозванного API-ключа.
+	ErrUnauthorized = errors.New("invalid api key")
+	// ErrInvalidKeyName возвращается для слишком длинного названия API-ключа.
+	ErrInvalidKeyName = errors.New("invalid api key name")
+)
+
+// CreateAPIKey создаёт API-ключ пользователя. Возвращает сохранённое описание
+// ключа и сам ключ, который больше нигде не хранится.
+func (s *Shortener) CreateAPIKey(ctx context.Context, userID, name string) (storage.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) > maxAPIKeyNameLength {
+		return storage.APIKey{}, "", fmt.Errorf("%w: name must be at most %d bytes", ErrInvalidKeyName, maxAPIKeyNameLength)
+	}
+
+	secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		return storage.APIKey{}, "", fmt.Errorf("generate api key failed: %w", err)
+	}
+	key := storage.APIKey{
+		ID:        auth.GenerateUserID(),
+		UserID:    userID,
+		Name:      name,
+		Hash:      auth.HashAPIKey(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.Storage.SaveAPIKey(ctx, key); err != nil {
+		return storage.APIKey{}, "", fmt.Errorf("save api key failed: %w", err)
+	}
+	return key, secret, nil
+}
+
+// ListAPIKeys возвращает API-ключи пользователя, включая отозванные.
+func (s *Shortener) ListAPIKeys(ctx context.Context, userID string) ([]storage.APIKey, error) {
+	keys, err := s.Storage.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys failed: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ пользователя.
+func (s *Shortener) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	return s.Storage.RevokeAPIKey(ctx, userID, id)
+}
+
+// AuthenticateAPIKey возвращает идентификатор владельца API-ключа.
+func (s *Shortener) AuthenticateAPIKey(ctx context.Context, key string) (string, error) {
+	stored, err := s.Storage.GetAPIKeyByHash(ctx, auth.HashAPIKey(key))
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrUnauthorized
+	}
+	if err != nil {
+		return "", err
+	}
+	if stored.Revoked() {
+		return "", ErrUnauthorized
+	}
+	return stored.UserID, nil
+}
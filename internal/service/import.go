@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// Ошибки импорта.
+var (
+	// ErrInvalidRecord возвращается источником импорта для записи, которую не удалось разобрать.
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrInvalidImport возвращается, если поток импорта не удалось дочитать.
+	ErrInvalidImport = errors.New("invalid import")
+)
+
+// Параметры импорта.
+const (
+	// ImportChunkSize — число записей, сохраняемых за одно обращение к хранилищу.
+	ImportChunkSize = 5000
+	// MaxImportRejected ограничивает число отклонённых записей в ImportSummary.
+	MaxImportRejected = 100
+)
+
+// URLSource возвращает очередную запись импорта и io.EOF после последней.
+// Ошибка, оборачивающая ErrInvalidRecord, отклоняет только текущую запись
+// (её CorrelationID берётся из возвращённого URL), остальные ошибки прерывают импорт.
+type URLSource func() (storage.URL, error)
+
+// ImportSummary — итог импорта.
+type ImportSummary struct {
+	Created  int                   // Число созданных ссылок.
+	Existing int                   // Число записей, получивших существующие ссылки.
+	Invalid  int                   // Число отклонённых записей.
+	Rejected []storage.BatchResult // Первые MaxImportRejected отклонённых записей.
+}
+
+// Total возвращает число обработанных записей.
+func (sum ImportSummary) Total() int {
+	return sum.Created + sum.Existing + sum.Invalid
+}
+
+func (sum *ImportSummary) add(results []storage.BatchResult) {
+	for _, result := range results {
+		switch result.Status {
+		case storage.BatchCreated:
+			sum.Created++
+		case storage.BatchExisting:
+			sum.Existing++
+		default:
+			sum.Invalid++
+			if len(sum.Rejected) < MaxImportRejected {
+				sum.Rejected = append(sum.Rejected, result)
+			}
+		}
+	}
+}
+
+// Import сокращает для пользователя userID все URL из next, сохраняя их
+// частями по ImportChunkSize. Записи проверяются так же, как в BatchShorten.
+// Если хранилище реализует storage.BulkImporter, используется массовая загрузка,
+// иначе SaveBatch. Уже сохранённые части не откатываются: при ошибке
+// возвращается итог по ним вместе с ошибкой.
+func (s *Shortener) Import(ctx context.Context, userID string, next URLSource) (ImportSummary, error) {
+	save := s.Storage.SaveBatch
+	if importer, ok := s.Storage.(storage.BulkImporter); ok {
+		save = importer.ImportBatch
+	}
+
+	var summary ImportSummary
+	flush := func(chunk []storage.URL) error {
+		results, err := s.shortenBatch(ctx, chunk, save)
+		if err != nil {
+			return fmt.Errorf("import failed after %d records: %w", summary.Total(), err)
+		}
+		summary.add(results)
+		return nil
+	}
+
+	chunk := make([]storage.URL, 0, ImportChunkSize)
+	for {
+		url, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, ErrInvalidRecord) {
+			summary.add([]storage.BatchResult{{
+				CorrelationID: url.CorrelationID,
+				Status:        storage.BatchInvalid,
+				Err:           err,
+			}})
+			continue
+		}
+		if err != nil {
+			if flushErr := flush(chunk); flushErr != nil {
+				return summary, flushErr
+			}
+			return summary, fmt.Errorf("%w after %d records: %w", ErrInvalidImport, summary.Total(), err)
+		}
+
+		url.UserID = userID
+		chunk = append(chunk, url)
+		if len(chunk) == ImportChunkSize {
+			if err := flush(chunk); err != nil {
+				return summary, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if len(chunk) > 0 {
+		if err := flush(chunk); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mi4r/go-url-shortener/internal/storage"
+	"github.com/mi4r/go-url-shortener/internal/storage/mocks"
+)
+
+// bulkStorage — мок-хранилище с поддержкой массовой загрузки.
+type bulkStorage struct {
+	*mocks.MockStorage
+}
+
+func (s bulkStorage) ImportBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	args := s.Called(ctx, urls)
+	return args.Get(0).([]storage.BatchResult), args.Error(1)
+}
+
+// sliceSource возвращает записи urls; для индексов из invalid — ErrInvalidRecord.
+func sliceSource(urls []storage.URL, invalid map[int]bool) URLSource {
+	i := 0
+	return func() (storage.URL, error) {
+		if i == len(urls) {
+			return storage.URL{}, io.EOF
+		}
+		url := urls[i]
+		i++
+		if invalid[i-1] {
+			return url, fmt.Errorf("%w: broken", ErrInvalidRecord)
+		}
+		return url, nil
+	}
+}
+
+// createdResults возвращает результат BatchCreated для каждого URL.
+func createdResults(args mock.Arguments) []storage.BatchResult {
+	urls := args.Get(1).([]storage.URL)
+	results := make([]storage.BatchResult, len(urls))
+	for i, url := range urls {
+		results[i] = storage.BatchResult{CorrelationID: url.CorrelationID, ShortURL: url.CorrelationID, Status: storage.BatchCreated}
+	}
+	return results
+}
+
+func TestShortener_Import(t *testing.T) {
+	urls := make([]storage.URL, ImportChunkSize+2)
+	for i := range urls {
+		urls[i] = storage.URL{CorrelationID: fmt.Sprint(i), OriginalURL: fmt.Sprintf("http://example.com/%d", i)}
+	}
+
+	t.Run("bulk importer", func(t *testing.T) {
+		store := bulkStorage{new(mocks.MockStorage)}
+		var chunks []int
+		call := store.On("ImportBatch", mock.Anything, mock.MatchedBy(func(chunk []storage.URL) bool {
+			return chunk[0].UserID == "user1"
+		}))
+		call.Run(func(args mock.Arguments) {
+			chunks = append(chunks, len(args.Get(1).([]storage.URL)))
+			call.ReturnArguments = mock.Arguments{createdResults(args), nil}
+		})
+
+		s := NewShortener(store, "http://short", nil)
+		summary, err := s.Import(context.Background(), "user1", sliceSource(urls, map[int]bool{1: true}))
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{ImportChunkSize, 1}, chunks)
+		assert.Equal(t, ImportChunkSize+1, summary.Created)
+		assert.Equal(t, 1, summary.Invalid)
+		require.Len(t, summary.Rejected, 1)
+		assert.Equal(t, "1", summary.Rejected[0].CorrelationID)
+		assert.ErrorIs(t, summary.Rejected[0].Err, ErrInvalidRecord)
+		store.AssertNotCalled(t, "SaveBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("save batch fallback", func(t *testing.T) {
+		store := new(mocks.MockStorage)
+		call := store.On("SaveBatch", mock.Anything, mock.Anything)
+		call.Run(func(args mock.Arguments) {
+			call.ReturnArguments = mock.Arguments{createdResults(args), nil}
+		})
+
+		s := NewShortener(store, "http://short", nil)
+		summary, err := s.Import(context.Background(), "user1", sliceSource(urls[:3], nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, summary.Created)
+		store.AssertNumberOfCalls(t, "SaveBatch", 1)
+	})
+
+	t.Run("source error", func(t *testing.T) {
+		store := new(mocks.MockStorage)
+		store.On("SaveBatch", mock.Anything, mock.Anything).
+			Return([]storage.BatchResult{{CorrelationID: "0", ShortURL: "id0", Status: storage.BatchCreated}}, nil)
+
+		readErr := errors.New("connection reset")
+		next := sliceSource(urls[:1], nil)
+		done := false
+		s := NewShortener(store, "http://short", nil)
+		summary, err := s.Import(context.Background(), "user1", func() (storage.URL, error) {
+			if done {
+				return storage.URL{}, readErr
+			}
+			done = true
+			return next()
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidImport)
+		assert.ErrorIs(t, err, readErr)
+		// Записи, прочитанные до ошибки, сохранены.
+		assert.Equal(t, 1, summary.Created)
+	})
+}
@@ -8,11 +8,29 @@ import (
 )
 
 type ShortenerInterface interface {
-	Shorten(ctx context.Context, originalURL, userID string) (string, error)
+	Shorten(ctx context.Context, url storage.URL) (string, error)
 	GetOriginal(ctx context.Context, shortID string) (string, error)
-	BatchShorten(ctx context.Context, items []storage.URL) ([]storage.URL, error)
-	GetUserURLs(ctx context.Context, userID string) ([]storage.URL, error)
+	BatchShorten(ctx context.Context, items []storage.URL) ([]storage.BatchResult, error)
+	Import(ctx context.Context, userID string, next URLSource) (ImportSummary, error)
+	GetUserURLs(ctx context.Context, userID string, query storage.ListQuery) (storage.URLPage, error)
 	DeleteUserURLs(ctx context.Context, userID string, ids []string) error
 	Ping(ctx context.Context) (bool, error)
 	InternalStats(ctx context.Context, ip net.IP) (urls, users int, err error)
+	RecordClick(ctx context.Context, click storage.Click)
+	GetLinkStats(ctx context.Context, userID, shortID string) (storage.LinkStats, error)
+	CreateAPIKey(ctx context.Context, userID, name string) (storage.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]storage.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id string) error
+	AuthenticateAPIKey(ctx context.Context, key string) (string, error)
+}
+
+// ClickTracker принимает переходы по коротким ссылкам для асинхронной обработки.
+type ClickTracker interface {
+	Track(click storage.Click)
+}
+
+// DestinationChecker проверяет, разрешено ли сокращать адрес и переходить по нему.
+// Возвращает ошибку, оборачивающую ErrBlocked, для запрещённых адресов.
+type DestinationChecker interface {
+	Check(rawURL string) error
 }
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// ErrInvalidQuery возвращается для некорректных параметров постраничной выборки.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Размеры страницы URL пользователя.
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// NewListQuery собирает запрос страницы URL пользователя из параметров транспорта.
+// order принимает значения "asc" и "desc", state — "all", "active" и "deleted";
+// пустые значения означают "asc" и "active": как и до появления фильтра,
+// удалённые ссылки возвращаются только по явному запросу.
+func NewListQuery(limit int, cursor, order, state, search string) (storage.ListQuery, error) {
+	query := storage.ListQuery{Limit: limit, Cursor: cursor, Search: search}
+	if limit < 0 {
+		return query, fmt.Errorf("%w: limit must not be negative", ErrInvalidQuery)
+	}
+
+	switch strings.ToLower(order) {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, fmt.Errorf("%w: unknown order %q", ErrInvalidQuery, order)
+	}
+
+	switch strings.ToLower(state) {
+	case "all":
+		query.State = storage.StateAll
+	case "", "active":
+		query.State = storage.StateActive
+	case "deleted":
+		query.State = storage.StateDeleted
+	default:
+		return query, fmt.Errorf("%w: unknown state %q", ErrInvalidQuery, state)
+	}
+	return query, nil
+}
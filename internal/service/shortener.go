@@ -2,24 +2,51 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/mi4r/go-url-shortener/internal/policy"
 	"github.com/mi4r/go-url-shortener/internal/storage"
 )
 
 const (
-	charset   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	idLength  = 8
-	batchSize = 10
+	charset    = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	idLength   = 8
+	batchSize  = 10
+	numWorkers = 5
+)
+
+// Ошибки сервисного слоя. Транспорты (HTTP и gRPC) отображают их на свои коды ответа.
+// Ошибки хранилища пробрасываются без изменений, поэтому errors.Is работает для обоих уровней.
+var (
+	ErrNotFound    = storage.ErrNotFound
+	ErrDeleted     = storage.ErrGone
+	ErrConflict    = storage.ErrConflict
+	ErrForbidden   = storage.ErrForbidden
+	ErrUnavailable = storage.ErrUnavailable
+	ErrAliasTaken  = storage.ErrAliasTaken
+	ErrBlocked     = policy.ErrBlocked
+
+	// ErrExpired возвращается для ссылок с истёкшим сроком действия. Оборачивает
+	// ErrDeleted, поэтому транспорты отвечают на неё так же, как на удалённую ссылку.
+	ErrExpired = fmt.Errorf("%w: link expired", ErrDeleted)
+	// ErrInvalidExpiry возвращается для некорректного срока действия ссылки.
+	ErrInvalidExpiry = errors.New("invalid expiration")
 )
 
 type Shortener struct {
 	Storage       storage.Storage
 	BaseURL       string
 	TrustedSubnet *net.IPNet
+	AliasPolicy   AliasPolicy
+	Clicks        ClickTracker       // Если nil, переходы не учитываются.
+	Destinations  DestinationChecker // Если nil, адреса не ограничиваются.
 }
 
 func NewShortener(storage storage.Storage, baseURL string, trustedSubnet *net.IPNet) *Shortener {
@@ -27,6 +54,7 @@ func NewShortener(storage storage.Storage, baseURL string, trustedSubnet *net.IP
 		Storage:       storage,
 		BaseURL:       baseURL,
 		TrustedSubnet: trustedSubnet,
+		AliasPolicy:   DefaultAliasPolicy(),
 	}
 }
 
@@ -38,100 +66,304 @@ func generateShortID() string {
 	return string(b)
 }
 
-func (s *Shortener) Shorten(ctx context.Context, originalURL, userID string) (string, error) {
-	var shortID string
-	for {
+// shortURL формирует полный короткий URL из идентификатора.
+func (s *Shortener) shortURL(shortID string) string {
+	return fmt.Sprintf("%s/%s", s.BaseURL, shortID)
+}
+
+// Shorten сокращает url.OriginalURL для пользователя url.UserID. URL
+// проверяется и нормализуется функцией NormalizeURL. Непустой
+// url.ShortURL используется как алиас: он проверяется по AliasPolicy, а если
+// уже занят, возвращается ErrAliasTaken. Если оригинальный URL уже сокращён,
+// возвращает существующий короткий URL вместе с ErrConflict.
+func (s *Shortener) Shorten(ctx context.Context, url storage.URL) (string, error) {
+	if url.Expired(time.Now()) {
+		return "", fmt.Errorf("%w: expiration is in the past", ErrInvalidExpiry)
+	}
+	originalURL, err := s.validateURL(url.OriginalURL)
+	if err != nil {
+		return "", err
+	}
+	url.OriginalURL = originalURL
+
+	shortID := url.ShortURL
+	if shortID != "" {
+		if err := s.AliasPolicy.Validate(shortID); err != nil {
+			return "", err
+		}
+	}
+	for shortID == "" {
 		shortID = generateShortID()
-		if _, exists := s.Storage.Get(shortID); !exists {
-			nextID, err := s.Storage.GetNextID()
-			if err != nil {
-				return "", fmt.Errorf("failed to generate ID: %w", err)
-			}
+		_, err := s.Storage.Get(ctx, shortID)
+		if err == nil || errors.Is(err, ErrDeleted) {
+			shortID = ""
+			continue
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("storage get error: %w", err)
+		}
+	}
 
-			url := storage.URL{
-				CorrelationID: strconv.Itoa(nextID),
-				ShortURL:      shortID,
-				OriginalURL:   originalURL,
-				UserID:        userID,
-			}
+	nextID, err := s.Storage.GetNextID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
 
-			existingURL, err := s.Storage.Save(url)
-			if err != nil {
-				return "", fmt.Errorf("storage save error: %w", err)
-			}
+	url.CorrelationID = strconv.Itoa(nextID)
+	url.ShortURL = shortID
 
-			if existingURL != "" {
-				return fmt.Sprintf("%s/%s", s.BaseURL, existingURL), nil
-			}
-			break
-		}
+	existingURL, err := s.Storage.Save(ctx, url)
+	if errors.Is(err, ErrConflict) {
+		return s.shortURL(existingURL), ErrConflict
+	}
+	if errors.Is(err, ErrAliasTaken) {
+		return "", ErrAliasTaken
 	}
-	return fmt.Sprintf("%s/%s", s.BaseURL, shortID), nil
+	if err != nil {
+		return "", fmt.Errorf("storage save error: %w", err)
+	}
+	return s.shortURL(shortID), nil
 }
 
+// GetOriginal возвращает оригинальный URL. Для удалённых ссылок возвращает
+// ErrDeleted, для ссылок с истёкшим сроком действия — ErrExpired, для ссылок
+// на адреса, запрещённые политикой Destinations, — ErrBlocked.
 func (s *Shortener) GetOriginal(ctx context.Context, shortID string) (string, error) {
-	url, exists := s.Storage.Get(shortID)
-	if !exists {
-		return "", fmt.Errorf("url not found")
+	url, err := s.Storage.Get(ctx, shortID)
+	if errors.Is(err, ErrDeleted) && url.Expired(time.Now()) {
+		return "", ErrExpired
+	}
+	if err != nil {
+		return "", err
 	}
 
 	if url.DeletedFlag {
-		return "", fmt.Errorf("url deleted")
+		return "", ErrDeleted
+	}
+	if url.Expired(time.Now()) {
+		return "", ErrExpired
+	}
+	if s.Destinations != nil {
+		if err := s.Destinations.Check(url.OriginalURL); err != nil {
+			return "", err
+		}
 	}
 
 	return url.OriginalURL, nil
 }
 
-func (s *Shortener) BatchShorten(ctx context.Context, items []storage.URL) ([]storage.URL, error) {
-	shortIDs, err := s.Storage.SaveBatch(items)
+// BatchShorten сокращает пакет URL и возвращает результат для каждого
+// элемента в исходном порядке с полными короткими URL. URL, алиасы и сроки
+// действия проверяются так же, как в Shorten; элементы, не прошедшие проверку,
+// получают статус storage.BatchInvalid с причиной, а остальные сохраняются.
+// Ошибка возвращается, только если пакет не удалось обработать целиком.
+func (s *Shortener) BatchShorten(ctx context.Context, items []storage.URL) ([]storage.BatchResult, error) {
+	return s.shortenBatch(ctx, items, s.Storage.SaveBatch)
+}
+
+// shortenBatch проверяет элементы пакета и сохраняет прошедшие проверку функцией save.
+func (s *Shortener) shortenBatch(ctx context.Context, items []storage.URL,
+	save func(context.Context, []storage.URL) ([]storage.BatchResult, error)) ([]storage.BatchResult, error) {
+	now := time.Now()
+	results := make([]storage.BatchResult, len(items))
+	valid := make([]storage.URL, 0, len(items))
+	validIdx := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].CorrelationID = item.CorrelationID
+		if err := s.validateBatchItem(&item, now); err != nil {
+			results[i].Status = storage.BatchInvalid
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, item)
+		validIdx = append(validIdx, i)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	saved, err := save(ctx, valid)
 	if err != nil {
 		return nil, fmt.Errorf("batch save failed: %w", err)
 	}
-
-	result := make([]storage.URL, len(items))
-	for i, id := range shortIDs {
-		result[i].ShortURL = id
+	for j, result := range saved {
+		i := validIdx[j]
+		results[i] = result
+		results[i].CorrelationID = items[i].CorrelationID
+		if result.ShortURL != "" {
+			results[i].ShortURL = s.shortURL(result.ShortURL)
+		}
 	}
 
-	return result, nil
+	return results, nil
+}
+
+// validateBatchItem проверяет элемент пакета и нормализует его URL.
+func (s *Shortener) validateBatchItem(item *storage.URL, now time.Time) error {
+	if item.Expired(now) {
+		return fmt.Errorf("%w: expiration is in the past", ErrInvalidExpiry)
+	}
+	originalURL, err := s.validateURL(item.OriginalURL)
+	if err != nil {
+		return err
+	}
+	item.OriginalURL = originalURL
+	if item.ShortURL != "" {
+		return s.AliasPolicy.Validate(item.ShortURL)
+	}
+	return nil
 }
 
-func (s *Shortener) GetUserURLs(ctx context.Context, userID string) ([]storage.URL, error) {
-	urls, err := s.Storage.GetURLsByUserID(userID)
+// GetUserURLs возвращает страницу URL пользователя с полными короткими адресами.
+// Без limit и cursor возвращаются все URL, как до постраничной выборки;
+// иначе размер страницы ограничивается DefaultPageSize и MaxPageSize.
+func (s *Shortener) GetUserURLs(ctx context.Context, userID string, query storage.ListQuery) (storage.URLPage, error) {
+	switch {
+	case query.Limit <= 0 && query.Cursor == "":
+		query.Limit = 0
+	case query.Limit <= 0:
+		query.Limit = DefaultPageSize
+	case query.Limit > MaxPageSize:
+		query.Limit = MaxPageSize
+	}
+
+	page, err := s.Storage.ListURLsByUserID(ctx, userID, query)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		return storage.URLPage{}, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("get user urls failed: %w", err)
+		return storage.URLPage{}, fmt.Errorf("get user urls failed: %w", err)
+	}
+	for i := range page.URLs {
+		page.URLs[i].ShortURL = s.shortURL(page.URLs[i].ShortURL)
 	}
-	return urls, nil
+	return page, nil
 }
 
+// DeleteUserURLs помечает URL пользователя удалёнными, разбивая список на
+// пакеты и обрабатывая их пулом воркеров.
 func (s *Shortener) DeleteUserURLs(ctx context.Context, userID string, ids []string) error {
-	if err := s.Storage.MarkURLsAsDeleted(userID, ids); err != nil {
-		return fmt.Errorf("delete urls failed: %w", err)
+	batches := make(chan []string)
+	go func() {
+		defer close(batches)
+		for start := 0; start < len(ids); start += batchSize {
+			end := min(start+batchSize, len(ids))
+			batches <- ids[start:end]
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if err := s.Storage.MarkURLsAsDeleted(ctx, userID, batch); err != nil {
+					once.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return fmt.Errorf("delete urls failed: %w", firstErr)
 	}
 	return nil
 }
 
 func (s *Shortener) Ping(ctx context.Context) (bool, error) {
-	if pinger, ok := s.Storage.(storage.Pinger); ok {
-		return pinger.Ping() == nil, nil
+	if err := s.Storage.Ping(ctx); err != nil {
+		return false, err
 	}
-	return false, fmt.Errorf("storage does not support ping")
+	return true, nil
 }
 
 func (s *Shortener) InternalStats(ctx context.Context, ip net.IP) (urls, users int, err error) {
-	if s.TrustedSubnet == nil || !s.TrustedSubnet.Contains(ip) {
-		return 0, 0, fmt.Errorf("access denied")
+	if s.TrustedSubnet == nil || ip == nil || !s.TrustedSubnet.Contains(ip) {
+		return 0, 0, ErrForbidden
 	}
 
-	urls, err = s.Storage.URLCount()
+	urls, err = s.Storage.URLCount(ctx)
 	if err != nil {
 		return 0, 0, fmt.Errorf("get url count failed: %w", err)
 	}
 
-	users, err = s.Storage.UserCount()
+	users, err = s.Storage.UserCount(ctx)
 	if err != nil {
 		return 0, 0, fmt.Errorf("get user count failed: %w", err)
 	}
 
 	return urls, users, nil
 }
+
+// ResolveExpiry вычисляет момент истечения срока действия ссылки по абсолютному
+// времени expiresAt или по ttl относительно текущего момента. Нулевые значения
+// обоих параметров означают бессрочную ссылку; одновременно задавать их нельзя.
+func ResolveExpiry(expiresAt time.Time, ttl time.Duration) (time.Time, error) {
+	switch {
+	case !expiresAt.IsZero() && ttl != 0:
+		return time.Time{}, fmt.Errorf("%w: expires_at and ttl are mutually exclusive", ErrInvalidExpiry)
+	case ttl < 0:
+		return time.Time{}, fmt.Errorf("%w: ttl must be positive", ErrInvalidExpiry)
+	case ttl > 0:
+		return time.Now().Add(ttl), nil
+	default:
+		return expiresAt, nil
+	}
+}
+
+// RunReaper периодически помечает удалёнными ссылки с истёкшим сроком
+// действия. Переходы по ним получают ErrExpired, пока ссылки не удалены
+// безвозвратно через Admin.PurgeDeleted.
+// Блокируется до отмены ctx.
+func (s *Shortener) RunReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.Storage.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				logger.Sugar.Error("Failed to expire urls: ", err)
+				continue
+			}
+			if deleted > 0 {
+				logger.Sugar.Infof("Expired %d urls", deleted)
+			}
+		}
+	}
+}
+
+// RecordClick передаёт переход на асинхронную обработку, не дожидаясь сохранения.
+func (s *Shortener) RecordClick(_ context.Context, click storage.Click) {
+	if s.Clicks == nil {
+		return
+	}
+	s.Clicks.Track(click)
+}
+
+// GetLinkStats возвращает статистику переходов по ссылке пользователя.
+// Для чужих ссылок возвращает ErrForbidden; статистика удалённых и истёкших ссылок доступна.
+func (s *Shortener) GetLinkStats(ctx context.Context, userID, shortID string) (storage.LinkStats, error) {
+	url, err := s.Storage.Get(ctx, shortID)
+	if err != nil && !errors.Is(err, ErrDeleted) {
+		return storage.LinkStats{}, err
+	}
+	if url.UserID != userID {
+		return storage.LinkStats{}, ErrForbidden
+	}
+
+	stats, err := s.Storage.GetLinkStats(ctx, shortID)
+	if err != nil {
+		return storage.LinkStats{}, fmt.Errorf("get link stats failed: %w", err)
+	}
+	return stats, nil
+}
@@ -3,24 +3,30 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mi4r/go-url-shortener/internal/logger"
 	"github.com/mi4r/go-url-shortener/internal/storage"
 	"github.com/mi4r/go-url-shortener/internal/storage/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func TestShortener_Shorten(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Get", mock.AnythingOfType("string")).Return(storage.URL{}, false)
-		mockStorage.On("GetNextID").Return(1, nil)
-		mockStorage.On("Save", mock.AnythingOfType("storage.URL")).Return("", nil)
+		mockStorage.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(storage.URL{}, storage.ErrNotFound)
+		mockStorage.On("GetNextID", mock.Anything).Return(1, nil)
+		mockStorage.On("Save", mock.Anything, mock.AnythingOfType("storage.URL")).Return("", nil)
 
 		s := NewShortener(mockStorage, "http://short", nil)
-		result, err := s.Shorten(context.Background(), "http://original", "user1")
+		result, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "http://original", UserID: "user1"})
 
 		assert.NoError(t, err)
 		assert.Regexp(t, `^http://short/[a-zA-Z0-9]{8}$`, result)
@@ -29,35 +35,71 @@ func TestShortener_Shorten(t *testing.T) {
 
 	t.Run("existing url", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Get", mock.AnythingOfType("string")).Return(storage.URL{}, false)
-		mockStorage.On("GetNextID").Return(1, nil)
-		mockStorage.On("Save", mock.Anything).Return("existingID", nil)
+		mockStorage.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(storage.URL{}, storage.ErrNotFound)
+		mockStorage.On("GetNextID", mock.Anything).Return(1, nil)
+		mockStorage.On("Save", mock.Anything, mock.Anything).Return("existingID", storage.ErrConflict)
 
 		s := NewShortener(mockStorage, "http://short", nil)
-		result, err := s.Shorten(context.Background(), "http://original", "user1")
+		result, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "http://original", UserID: "user1"})
 
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrConflict)
 		assert.Equal(t, "http://short/existingID", result)
 		mockStorage.AssertExpectations(t)
 	})
 
 	t.Run("storage error", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Get", mock.AnythingOfType("string")).Return(storage.URL{}, false)
-		mockStorage.On("GetNextID").Return(0, errors.New("id error"))
+		mockStorage.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(storage.URL{}, storage.ErrNotFound)
+		mockStorage.On("GetNextID", mock.Anything).Return(0, errors.New("id error"))
 
 		s := NewShortener(mockStorage, "http://short", nil)
-		_, err := s.Shorten(context.Background(), "http://original", "user1")
+		_, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "http://original", UserID: "user1"})
 
 		assert.ErrorContains(t, err, "failed to generate ID")
 		mockStorage.AssertExpectations(t)
 	})
+
+	t.Run("alias", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("GetNextID", mock.Anything).Return(1, nil)
+		mockStorage.On("Save", mock.Anything, mock.MatchedBy(func(u storage.URL) bool {
+			return u.ShortURL == "spring-sale"
+		})).Return("", nil)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		result, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "http://original", ShortURL: "spring-sale", UserID: "user1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "http://short/spring-sale", result)
+		mockStorage.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("alias taken", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("GetNextID", mock.Anything).Return(1, nil)
+		mockStorage.On("Save", mock.Anything, mock.Anything).Return("", storage.ErrAliasTaken)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		_, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "http://original", ShortURL: "spring-sale", UserID: "user1"})
+
+		assert.ErrorIs(t, err, ErrAliasTaken)
+	})
+
+	t.Run("invalid alias", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		_, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "http://original", ShortURL: "api", UserID: "user1"})
+
+		assert.ErrorIs(t, err, ErrInvalidAlias)
+		mockStorage.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
 }
 
 func TestShortener_GetOriginal(t *testing.T) {
 	t.Run("found", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Get", "valid").Return(storage.URL{OriginalURL: "http://original"}, true)
+		mockStorage.On("Get", mock.Anything, "valid").Return(storage.URL{OriginalURL: "http://original"}, nil)
 
 		s := NewShortener(mockStorage, "", nil)
 		result, err := s.GetOriginal(context.Background(), "valid")
@@ -68,75 +110,263 @@ func TestShortener_GetOriginal(t *testing.T) {
 
 	t.Run("not found", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Get", "invalid").Return(storage.URL{}, false)
+		mockStorage.On("Get", mock.Anything, "invalid").Return(storage.URL{}, storage.ErrNotFound)
 
 		s := NewShortener(mockStorage, "", nil)
 		_, err := s.GetOriginal(context.Background(), "invalid")
 
-		assert.ErrorContains(t, err, "url not found")
+		assert.ErrorIs(t, err, ErrNotFound)
 	})
 
 	t.Run("deleted", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Get", "deleted").Return(storage.URL{DeletedFlag: true}, true)
+		mockStorage.On("Get", mock.Anything, "deleted").Return(storage.URL{DeletedFlag: true}, storage.ErrGone)
 
 		s := NewShortener(mockStorage, "", nil)
 		_, err := s.GetOriginal(context.Background(), "deleted")
 
-		assert.ErrorContains(t, err, "url deleted")
+		assert.ErrorIs(t, err, ErrDeleted)
 	})
 }
 
+func TestShortener_GetOriginal_Unavailable(t *testing.T) {
+	mockStorage := new(mocks.MockStorage)
+	mockStorage.On("Get", mock.Anything, "id").Return(storage.URL{}, fmt.Errorf("%w: timeout", storage.ErrUnavailable))
+
+	s := NewShortener(mockStorage, "", nil)
+	_, err := s.GetOriginal(context.Background(), "id")
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestShortener_GetOriginal_Expired(t *testing.T) {
+	mockStorage := new(mocks.MockStorage)
+	mockStorage.On("Get", mock.Anything, "id").Return(storage.URL{
+		OriginalURL: "http://original",
+		ExpiresAt:   time.Now().Add(-time.Second),
+	}, nil)
+
+	s := NewShortener(mockStorage, "", nil)
+	_, err := s.GetOriginal(context.Background(), "id")
+
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.ErrorIs(t, err, ErrDeleted)
+}
+
+func TestResolveExpiry(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+
+	got, err := ResolveExpiry(at, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, at, got)
+
+	got, err = ResolveExpiry(time.Time{}, time.Minute)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), got, time.Second)
+
+	got, err = ResolveExpiry(time.Time{}, 0)
+	assert.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	_, err = ResolveExpiry(at, time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidExpiry)
+
+	_, err = ResolveExpiry(time.Time{}, -time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidExpiry)
+}
+
+func TestShortener_RunReaper(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	mockStorage := new(mocks.MockStorage)
+	called := make(chan struct{}, 1)
+	mockStorage.On("DeleteExpired", mock.Anything, mock.Anything).Return(2, nil).Run(func(mock.Arguments) {
+		select {
+		case called <- struct{}{}:
+		default:
+		}
+	})
+
+	s := NewShortener(mockStorage, "", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunReaper(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not call DeleteExpired")
+	}
+	cancel()
+	<-done
+}
+
+func TestShortener_RunReaperKeepsGone(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	store := storage.NewMemoryStorage()
+	_, err := store.Save(context.Background(), storage.URL{
+		ShortURL: "id", OriginalURL: "http://original", UserID: "user1", ExpiresAt: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	s := NewShortener(store, "", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunReaper(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		url, _ := store.Get(context.Background(), "id")
+		return url.DeletedFlag
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	// После очистки истёкшая ссылка по-прежнему отвечает 410, а не 404.
+	_, err = store.Get(context.Background(), "id")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	_, err = s.GetOriginal(context.Background(), "id")
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.ErrorIs(t, err, ErrDeleted)
+}
+
 func TestShortener_BatchShorten(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("SaveBatch", mock.Anything).Return([]string{"id1", "id2"}, nil)
+		mockStorage.On("SaveBatch", mock.Anything, mock.Anything).Return([]storage.BatchResult{{ShortURL: "id1"}, {ShortURL: "id2"}}, nil)
 
 		s := NewShortener(mockStorage, "http://short", nil)
 		urls := []storage.URL{
-			{OriginalURL: "http://1"},
-			{OriginalURL: "http://2"},
+			{CorrelationID: "c1", OriginalURL: "http://1"},
+			{CorrelationID: "c2", OriginalURL: "http://2"},
 		}
 
 		result, err := s.BatchShorten(context.Background(), urls)
 
 		assert.NoError(t, err)
 		assert.Len(t, result, 2)
-		assert.Equal(t, "id1", result[0].ShortURL)
+		assert.Equal(t, "c1", result[0].CorrelationID)
+		assert.Equal(t, "http://short/id1", result[0].ShortURL)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("SaveBatch", mock.Anything, []storage.URL{
+			{CorrelationID: "c1", OriginalURL: "http://1"},
+			{CorrelationID: "c3", OriginalURL: "http://3", ShortURL: "taken"},
+		}).Return([]storage.BatchResult{
+			{CorrelationID: "c1", ShortURL: "id1", Status: storage.BatchExisting},
+			{CorrelationID: "c3", Status: storage.BatchInvalid, Err: storage.ErrAliasTaken},
+		}, nil)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		result, err := s.BatchShorten(context.Background(), []storage.URL{
+			{CorrelationID: "c1", OriginalURL: "http://1"},
+			{CorrelationID: "c2", OriginalURL: "ftp://2"},
+			{CorrelationID: "c3", OriginalURL: "http://3", ShortURL: "taken"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.BatchResult{
+			{CorrelationID: "c1", ShortURL: "http://short/id1", Status: storage.BatchExisting},
+			{CorrelationID: "c2", Status: storage.BatchInvalid, Err: result[1].Err},
+			{CorrelationID: "c3", Status: storage.BatchInvalid, Err: storage.ErrAliasTaken},
+		}, result)
+		assert.ErrorIs(t, result[1].Err, ErrInvalidURL)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("SaveBatch", mock.Anything, mock.Anything).Return([]storage.BatchResult(nil), storage.ErrUnavailable)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		_, err := s.BatchShorten(context.Background(), []storage.URL{{CorrelationID: "c1", OriginalURL: "http://1"}})
+		assert.ErrorIs(t, err, ErrUnavailable)
 	})
 }
 
 func TestShortener_GetUserURLs(t *testing.T) {
+	t.Run("all without pagination", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("ListURLsByUserID", mock.Anything, "user1", storage.ListQuery{}).Return(storage.URLPage{
+			URLs: []storage.URL{{ShortURL: "id1", OriginalURL: "http://1"}},
+		}, nil)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		result, err := s.GetUserURLs(context.Background(), "user1", storage.ListQuery{})
+
+		assert.NoError(t, err)
+		assert.Len(t, result.URLs, 1)
+		assert.Empty(t, result.NextCursor)
+	})
+
 	t.Run("found", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("GetURLsByUserID", "user1").Return([]storage.URL{
-			{ShortURL: "id1", OriginalURL: "http://1"},
+		mockStorage.On("ListURLsByUserID", mock.Anything, "user1", storage.ListQuery{Limit: DefaultPageSize, Cursor: "c"}).Return(storage.URLPage{
+			URLs:       []storage.URL{{ShortURL: "id1", OriginalURL: "http://1"}},
+			NextCursor: "next",
 		}, nil)
 
 		s := NewShortener(mockStorage, "http://short", nil)
-		result, err := s.GetUserURLs(context.Background(), "user1")
+		result, err := s.GetUserURLs(context.Background(), "user1", storage.ListQuery{Cursor: "c"})
 
 		assert.NoError(t, err)
-		assert.Len(t, result, 1)
-		assert.Equal(t, "id1", result[0].ShortURL)
+		assert.Len(t, result.URLs, 1)
+		assert.Equal(t, "http://short/id1", result.URLs[0].ShortURL)
+		assert.Equal(t, "next", result.NextCursor)
 	})
 
 	t.Run("empty", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("GetURLsByUserID", "user2").Return([]storage.URL{}, nil)
+		mockStorage.On("ListURLsByUserID", mock.Anything, "user2", storage.ListQuery{Limit: MaxPageSize}).Return(storage.URLPage{}, nil)
 
 		s := NewShortener(mockStorage, "http://short", nil)
-		result, err := s.GetUserURLs(context.Background(), "user2")
+		result, err := s.GetUserURLs(context.Background(), "user2", storage.ListQuery{Limit: MaxPageSize + 1})
 
 		assert.NoError(t, err)
-		assert.Empty(t, result)
+		assert.Empty(t, result.URLs)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("ListURLsByUserID", mock.Anything, "user3", mock.Anything).Return(storage.URLPage{}, storage.ErrInvalidCursor)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		_, err := s.GetUserURLs(context.Background(), "user3", storage.ListQuery{Cursor: "bad"})
+
+		assert.ErrorIs(t, err, ErrInvalidQuery)
 	})
 }
 
+func TestNewListQuery(t *testing.T) {
+	query, err := NewListQuery(10, "c", "DESC", "deleted", "example")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.ListQuery{Limit: 10, Cursor: "c", Desc: true, State: storage.StateDeleted, Search: "example"}, query)
+
+	// Без параметров удалённые ссылки не возвращаются.
+	query, err = NewListQuery(0, "", "", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.ListQuery{State: storage.StateActive}, query)
+	query, err = NewListQuery(0, "", "", "all", "")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.StateAll, query.State)
+
+	_, err = NewListQuery(-1, "", "", "", "")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = NewListQuery(0, "", "random", "", "")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = NewListQuery(0, "", "", "archived", "")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
 func TestShortener_DeleteUserURLs(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("MarkURLsAsDeleted", "user1", []string{"id1"}).Return(nil)
+		mockStorage.On("MarkURLsAsDeleted", mock.Anything, "user1", []string{"id1"}).Return(nil)
 
 		s := NewShortener(mockStorage, "", nil)
 		err := s.DeleteUserURLs(context.Background(), "user1", []string{"id1"})
@@ -144,9 +374,24 @@ func TestShortener_DeleteUserURLs(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("batched", func(t *testing.T) {
+		ids := make([]string, 25)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("id%d", i)
+		}
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("MarkURLsAsDeleted", mock.Anything, "user1", mock.Anything).Return(nil)
+
+		s := NewShortener(mockStorage, "", nil)
+		err := s.DeleteUserURLs(context.Background(), "user1", ids)
+
+		assert.NoError(t, err)
+		mockStorage.AssertNumberOfCalls(t, "MarkURLsAsDeleted", 3)
+	})
+
 	t.Run("error", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("MarkURLsAsDeleted", "user1", mock.Anything).Return(errors.New("delete error"))
+		mockStorage.On("MarkURLsAsDeleted", mock.Anything, "user1", mock.Anything).Return(errors.New("delete error"))
 
 		s := NewShortener(mockStorage, "", nil)
 		err := s.DeleteUserURLs(context.Background(), "user1", []string{"id1"})
@@ -158,7 +403,7 @@ func TestShortener_DeleteUserURLs(t *testing.T) {
 func TestShortener_Ping(t *testing.T) {
 	t.Run("pinger ok", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("Ping").Return(nil)
+		mockStorage.On("Ping", mock.Anything).Return(nil)
 
 		s := NewShortener(mockStorage, "", nil)
 		ok, err := s.Ping(context.Background())
@@ -176,13 +421,13 @@ func TestShortener_InternalStats(t *testing.T) {
 		s := NewShortener(mockStorage, "", subnet)
 
 		_, _, err := s.InternalStats(context.Background(), net.ParseIP("10.0.0.1"))
-		assert.ErrorContains(t, err, "access denied")
+		assert.ErrorIs(t, err, ErrForbidden)
 	})
 
 	t.Run("success", func(t *testing.T) {
 		mockStorage := new(mocks.MockStorage)
-		mockStorage.On("URLCount").Return(10, nil)
-		mockStorage.On("UserCount").Return(5, nil)
+		mockStorage.On("URLCount", mock.Anything).Return(10, nil)
+		mockStorage.On("UserCount", mock.Anything).Return(5, nil)
 
 		s := NewShortener(mockStorage, "", subnet)
 		urls, users, err := s.InternalStats(context.Background(), net.ParseIP("192.168.0.1"))
@@ -192,3 +437,100 @@ func TestShortener_InternalStats(t *testing.T) {
 		assert.Equal(t, 5, users)
 	})
 }
+
+type clickRecorder struct {
+	clicks []storage.Click
+}
+
+func (r *clickRecorder) Track(click storage.Click) {
+	r.clicks = append(r.clicks, click)
+}
+
+func TestShortener_RecordClick(t *testing.T) {
+	s := NewShortener(new(mocks.MockStorage), "", nil)
+	s.RecordClick(context.Background(), storage.Click{ShortURL: "abc"})
+
+	recorder := &clickRecorder{}
+	s.Clicks = recorder
+	s.RecordClick(context.Background(), storage.Click{ShortURL: "abc"})
+
+	assert.Len(t, recorder.clicks, 1)
+}
+
+func TestShortener_GetLinkStats(t *testing.T) {
+	t.Run("own link", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("Get", mock.Anything, "abc").Return(storage.URL{ShortURL: "abc", UserID: "user1"}, nil)
+		stats := storage.NewLinkStats("abc")
+		stats.Clicks = 5
+		mockStorage.On("GetLinkStats", mock.Anything, "abc").Return(stats, nil)
+
+		s := NewShortener(mockStorage, "", nil)
+		got, err := s.GetLinkStats(context.Background(), "user1", "abc")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, got.Clicks)
+	})
+
+	t.Run("deleted link", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("Get", mock.Anything, "abc").Return(storage.URL{ShortURL: "abc", UserID: "user1", DeletedFlag: true}, storage.ErrGone)
+		mockStorage.On("GetLinkStats", mock.Anything, "abc").Return(storage.NewLinkStats("abc"), nil)
+
+		s := NewShortener(mockStorage, "", nil)
+		_, err := s.GetLinkStats(context.Background(), "user1", "abc")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("foreign link", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("Get", mock.Anything, "abc").Return(storage.URL{ShortURL: "abc", UserID: "user2"}, nil)
+
+		s := NewShortener(mockStorage, "", nil)
+		_, err := s.GetLinkStats(context.Background(), "user1", "abc")
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		mockStorage.AssertNotCalled(t, "GetLinkStats", mock.Anything, mock.Anything)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("Get", mock.Anything, "abc").Return(storage.URL{}, storage.ErrNotFound)
+
+		s := NewShortener(mockStorage, "", nil)
+		_, err := s.GetLinkStats(context.Background(), "user1", "abc")
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestShortener_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewShortener(storage.NewMemoryStorage(), "http://short", nil)
+
+	key, secret, err := s.CreateAPIKey(ctx, "user1", " ci ")
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", key.Name)
+	assert.NotEqual(t, secret, key.Hash, "ключ не должен храниться в открытом виде")
+
+	userID, err := s.AuthenticateAPIKey(ctx, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", userID)
+
+	_, err = s.AuthenticateAPIKey(ctx, "usk_unknown")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	assert.ErrorIs(t, s.RevokeAPIKey(ctx, "user2", key.ID), ErrForbidden)
+	assert.NoError(t, s.RevokeAPIKey(ctx, "user1", key.ID))
+	_, err = s.AuthenticateAPIKey(ctx, secret)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	keys, err := s.ListAPIKeys(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.True(t, keys[0].Revoked())
+
+	_, _, err = s.CreateAPIKey(ctx, "user1", strings.Repeat("x", maxAPIKeyNameLength+1))
+	assert.ErrorIs(t, err, ErrInvalidKeyName)
+}
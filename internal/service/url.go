@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidURL возвращается, если сокращаемый URL не проходит проверку.
+var ErrInvalidURL = errors.New("invalid url")
+
+// MaxURLLength ограничивает длину нормализованного URL.
+const MaxURLLength = 2048
+
+// defaultPorts содержит порты по умолчанию для допустимых схем.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL проверяет и нормализует сокращаемый URL: допускаются только
+// схемы http и https, схема и хост приводятся к нижнему регистру, порт по
+// умолчанию удаляется, а интернационализированный хост кодируется в punycode.
+// Нормализованная форма сохраняется в хранилище, поэтому записи одного и того
+// же адреса совпадают и дедуплицируются. Ошибка оборачивает ErrInvalidURL.
+func NormalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: url is empty", ErrInvalidURL)
+	}
+	if len(raw) > MaxURLLength {
+		return "", fmt.Errorf("%w: url is longer than %d characters", ErrInvalidURL, MaxURLLength)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURL, unwrapURLError(err))
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	defaultPort, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return "", fmt.Errorf("%w: scheme must be http or https", ErrInvalidURL)
+	}
+	if u.Opaque != "" || u.Hostname() == "" {
+		return "", fmt.Errorf("%w: host is required", ErrInvalidURL)
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	if port := u.Port(); port != "" && port != defaultPort {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	normalized := u.String()
+	if len(normalized) > MaxURLLength {
+		return "", fmt.Errorf("%w: url is longer than %d characters", ErrInvalidURL, MaxURLLength)
+	}
+	return normalized, nil
+}
+
+// normalizeHost приводит хост к нижнему регистру и кодирует его в punycode.
+// IP-адреса возвращаются в каноническом виде.
+func normalizeHost(host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return "", fmt.Errorf("%w: host %q: %v", ErrInvalidURL, host, err)
+	}
+	return ascii, nil
+}
+
+// unwrapURLError убирает из ошибки url.Parse повтор исходной строки.
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// sameHost сообщает, указывают ли нормализованные URL a и b на один хост и порт.
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Host == ub.Host
+}
+
+// validateURL нормализует URL, отклоняет ссылки на сам сервис, которые
+// приводят к циклу перенаправлений, и проверяет адрес политикой Destinations.
+func (s *Shortener) validateURL(raw string) (string, error) {
+	normalized, err := NormalizeURL(raw)
+	if err != nil {
+		return "", err
+	}
+	if base, err := NormalizeURL(s.BaseURL); err == nil && sameHost(normalized, base) {
+		return "", fmt.Errorf("%w: url points to the shortener itself", ErrInvalidURL)
+	}
+	if s.Destinations != nil {
+		if err := s.Destinations.Check(normalized); err != nil {
+			return "", err
+		}
+	}
+	return normalized, nil
+}
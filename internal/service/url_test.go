@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mi4r/go-url-shortener/internal/policy"
+	"github.com/mi4r/go-url-shortener/internal/storage"
+	"github.com/mi4r/go-url-shortener/internal/storage/mocks"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "unchanged", in: "https://example.com/path?q=1#top", want: "https://example.com/path?q=1#top"},
+		{name: "case", in: "HTTPS://Example.COM/Path", want: "https://example.com/Path"},
+		{name: "default http port", in: "http://example.com:80/", want: "http://example.com/"},
+		{name: "default https port", in: "https://example.com:443", want: "https://example.com"},
+		{name: "custom port", in: "https://example.com:8443/", want: "https://example.com:8443/"},
+		{name: "idna", in: "https://Пример.рф/путь", want: "https://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C"},
+		{name: "trailing dot", in: "https://example.com./", want: "https://example.com/"},
+		{name: "ipv6", in: "http://[::1]:80/", want: "http://[::1]/"},
+		{name: "spaces", in: "  https://example.com\n", want: "https://example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeURL_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"example.com",
+		"ftp://example.com",
+		"javascript:alert(1)",
+		"http:example.com",
+		"https://",
+		"http://exa mple.com",
+		"https://example.com/" + strings.Repeat("a", MaxURLLength),
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := NormalizeURL(in)
+			assert.ErrorIs(t, err, ErrInvalidURL)
+		})
+	}
+}
+
+func TestShortener_ShortenValidatesURL(t *testing.T) {
+	t.Run("self reference", func(t *testing.T) {
+		s := NewShortener(new(mocks.MockStorage), "http://localhost:8080", nil)
+		_, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "HTTP://LOCALHOST:8080/abc", UserID: "user1"})
+		assert.ErrorIs(t, err, ErrInvalidURL)
+
+		results, err := s.BatchShorten(context.Background(), []storage.URL{{CorrelationID: "c1", OriginalURL: "http://localhost:8080/abc"}})
+		require.NoError(t, err)
+		assert.Equal(t, storage.BatchInvalid, results[0].Status)
+		assert.ErrorIs(t, results[0].Err, ErrInvalidURL)
+	})
+
+	t.Run("normalized before save", func(t *testing.T) {
+		mockStorage := new(mocks.MockStorage)
+		mockStorage.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(storage.URL{}, storage.ErrNotFound)
+		mockStorage.On("GetNextID", mock.Anything).Return(1, nil)
+		mockStorage.On("Save", mock.Anything, mock.MatchedBy(func(url storage.URL) bool {
+			return url.OriginalURL == "https://example.com/Path"
+		})).Return("", nil)
+
+		s := NewShortener(mockStorage, "http://short", nil)
+		_, err := s.Shorten(context.Background(), storage.URL{OriginalURL: "https://EXAMPLE.com:443/Path", UserID: "user1"})
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("empty batch item", func(t *testing.T) {
+		s := NewShortener(new(mocks.MockStorage), "http://short", nil)
+		results, err := s.BatchShorten(context.Background(), []storage.URL{{CorrelationID: "c1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "c1", results[0].CorrelationID)
+		assert.ErrorIs(t, results[0].Err, ErrInvalidURL)
+	})
+}
+
+func TestShortener_Destinations(t *testing.T) {
+	destinations, err := policy.New(policy.Rules{Block: []string{"evil.com"}})
+	require.NoError(t, err)
+
+	mockStorage := new(mocks.MockStorage)
+	mockStorage.On("Get", mock.Anything, "old").Return(storage.URL{OriginalURL: "https://evil.com/login"}, nil)
+	s := NewShortener(mockStorage, "http://short", nil)
+	s.Destinations = destinations
+
+	_, err = s.Shorten(context.Background(), storage.URL{OriginalURL: "https://EVIL.com/login", UserID: "user1"})
+	assert.ErrorIs(t, err, ErrBlocked)
+
+	results, err := s.BatchShorten(context.Background(), []storage.URL{{CorrelationID: "c1", OriginalURL: "https://evil.com/"}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrBlocked)
+
+	_, err = s.GetOriginal(context.Background(), "old")
+	assert.ErrorIs(t, err, ErrBlocked)
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Admin определяет операции обслуживания хранилища, выполняемые без проверки
+// владельца ссылок. Реализуется всеми хранилищами и используется утилитой shortenerctl.
+type Admin interface {
+	// SetURLDeleted помечает ссылку удалённой (отключает её) или восстанавливает.
+	// Для отсутствующей ссылки возвращает ErrNotFound.
+	SetURLDeleted(ctx context.Context, shortURL string, deleted bool) error
+	// ReassignURLs передаёт ссылки пользователя from пользователю to и возвращает
+	// их число. Если shortURLs пуст, передаются все ссылки from; ссылки других
+	// пользователей из shortURLs пропускаются.
+	ReassignURLs(ctx context.Context, from, to string, shortURLs []string) (int, error)
+	// PurgeDeleted безвозвратно удаляет ссылки, помеченные удалёнными раньше
+	// before, и возвращает их число.
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+}
+
+// setURLDeleted помечает запись shortURL в data удалённой или восстанавливает её.
+// Восстановленная запись попадает в индекс дедупликации, если в нём нет
+// другой действующей записи с тем же ключом.
+func setURLDeleted(data map[string]URL, dedup *dedupIndex, shortURL string, deleted bool, now time.Time) error {
+	url, ok := data[shortURL]
+	if !ok {
+		return ErrNotFound
+	}
+	switch {
+	case deleted:
+		url.markDeleted(now)
+	case url.DeletedFlag:
+		url.DeletedFlag = false
+		url.DeletedAt = time.Time{}
+		url.UpdatedAt = now
+	}
+	data[shortURL] = url
+	if _, found := dedup.find(data, url, now); !found && url.active(now) {
+		dedup.add(url)
+	}
+	return nil
+}
+
+// reassignURLs передаёт записи пользователя from пользователю to и возвращает
+// идентификаторы переданных записей.
+func reassignURLs(data map[string]URL, userURLs map[string][]string, dedup *dedupIndex, from, to string, shortURLs []string, now time.Time) []string {
+	moved := ownedURLs(data, userURLs, from, shortURLs)
+	for _, shortID := range moved {
+		url := data[shortID]
+		dedup.remove(url)
+		removeUserURL(userURLs, from, shortID)
+		url.UserID = to
+		url.UpdatedAt = now
+		data[shortID] = url
+		userURLs[to] = append(userURLs[to], shortID)
+		if _, found := dedup.find(data, url, now); !found && url.active(now) {
+			dedup.add(url)
+		}
+	}
+	return moved
+}
+
+// ownedURLs возвращает идентификаторы из shortURLs, принадлежащие from, без
+// повторов. Пустой shortURLs означает все записи from.
+func ownedURLs(data map[string]URL, userURLs map[string][]string, from string, shortURLs []string) []string {
+	if len(shortURLs) == 0 {
+		return append([]string(nil), userURLs[from]...)
+	}
+	var owned []string
+	seen := make(map[string]bool, len(shortURLs))
+	for _, shortID := range shortURLs {
+		if url, ok := data[shortID]; ok && url.UserID == from && !seen[shortID] {
+			seen[shortID] = true
+			owned = append(owned, shortID)
+		}
+	}
+	return owned
+}
+
+// purgeDeleted удаляет из data и userURLs записи, помеченные удалёнными
+// раньше before, и возвращает их идентификаторы. Записи без времени удаления
+// считаются удалёнными давно.
+func purgeDeleted(data map[string]URL, userURLs map[string][]string, before time.Time) []string {
+	purged := deletedBefore(data, before)
+	for _, shortID := range purged {
+		removeURL(data, userURLs, shortID)
+	}
+	return purged
+}
+
+// deletedBefore возвращает идентификаторы записей data, помеченных удалёнными раньше before.
+func deletedBefore(data map[string]URL, before time.Time) []string {
+	var deleted []string
+	for shortID, url := range data {
+		if url.DeletedFlag && url.DeletedAt.Before(before) {
+			deleted = append(deleted, shortID)
+		}
+	}
+	return deleted
+}
+
+// removeURL удаляет запись shortID из data и userURLs.
+func removeURL(data map[string]URL, userURLs map[string][]string, shortID string) {
+	url, ok := data[shortID]
+	if !ok {
+		return
+	}
+	delete(data, shortID)
+	removeUserURL(userURLs, url.UserID, shortID)
+}
+
+// removeUserURL удаляет shortID из списка ссылок пользователя userID.
+func removeUserURL(userURLs map[string][]string, userID, shortID string) {
+	ids := userURLs[userID]
+	for i, id := range ids {
+		if id == shortID {
+			userURLs[userID] = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(userURLs[userID]) == 0 {
+		delete(userURLs, userID)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBStorage_Admin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	storage := &DBStorage{Database: db}
+	ctx := context.Background()
+
+	// Уведомление отправляется в той же транзакции, что и изменение.
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE urls SET .* is_deleted = \$2\s+WHERE short_url = \$1;`).
+		WithArgs("a1", true).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\);`).
+		WithArgs(urlChangesChannel, `{"op":"disable","ids":["a1"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	require.NoError(t, storage.SetURLDeleted(ctx, "a1", true))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE urls SET`).
+		WithArgs("missing", false).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, storage.SetURLDeleted(ctx, "missing", false), ErrNotFound)
+
+	// Без уведомления изменение не фиксируется.
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE urls SET`).
+		WithArgs("a1", false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`SELECT pg_notify`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+	assert.Error(t, storage.SetURLDeleted(ctx, "a1", false))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE urls SET user_id = \$2, updated_at = now\(\) WHERE user_id = \$1 RETURNING short_url;`).
+		WithArgs("alice", "bob").WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("a1").AddRow("a2").AddRow("a3"))
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"update","ids":["a1","a2","a3"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	moved, err := storage.ReassignURLs(ctx, "alice", "bob", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, moved)
+
+	before := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM urls WHERE is_deleted AND \(deleted_at IS NULL OR deleted_at < \$1\) RETURNING short_url;`).
+		WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("a4").AddRow("a5"))
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"delete","ids":["a4","a5"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	purged, err := storage.PurgeDeleted(ctx, before)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	// Без изменённых строк уведомление не рассылается.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM urls`).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+	mock.ExpectCommit()
+	purged, err = storage.PurgeDeleted(ctx, before)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKey описывает долгоживущий ключ доступа пользователя. Сам ключ не
+// хранится: сохраняется только его хеш, по которому выполняется поиск.
+type APIKey struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+	RevokedAt time.Time `json:"revoked_at,omitempty"`
+}
+
+// Revoked сообщает, отозван ли ключ.
+func (k APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// apiKeySet хранит API-ключи в памяти для MemoryStorage и FileStorage.
+// Не потокобезопасен: доступ защищается мьютексом хранилища.
+type apiKeySet struct {
+	byID   map[string]APIKey
+	byHash map[string]string // Хеш ключа → ID.
+}
+
+// newAPIKeySet создаёт пустой набор ключей.
+func newAPIKeySet() apiKeySet {
+	return apiKeySet{byID: make(map[string]APIKey), byHash: make(map[string]string)}
+}
+
+// add добавляет новый ключ. Возвращает ErrConflict, если ID или хеш заняты.
+func (s apiKeySet) add(key APIKey) error {
+	if err := s.checkAdd(key); err != nil {
+		return err
+	}
+	s.put(key)
+	return nil
+}
+
+// checkAdd проверяет, что ключ можно добавить, не меняя набор.
+func (s apiKeySet) checkAdd(key APIKey) error {
+	if _, exists := s.byID[key.ID]; exists {
+		return ErrConflict
+	}
+	if _, exists := s.byHash[key.Hash]; exists {
+		return ErrConflict
+	}
+	return nil
+}
+
+// put добавляет или заменяет ключ.
+func (s apiKeySet) put(key APIKey) {
+	s.byID[key.ID] = key
+	s.byHash[key.Hash] = key.ID
+}
+
+// getByHash ищет ключ по хешу.
+func (s apiKeySet) getByHash(hash string) (APIKey, error) {
+	id, ok := s.byHash[hash]
+	if !ok {
+		return APIKey{}, ErrNotFound
+	}
+	return s.byID[id], nil
+}
+
+// list возвращает ключи пользователя, упорядоченные по времени создания.
+func (s apiKeySet) list(userID string) []APIKey {
+	var keys []APIKey
+	for _, key := range s.byID {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sortAPIKeys(keys)
+	return keys
+}
+
+// sortAPIKeys упорядочивает ключи по времени создания и ID.
+func sortAPIKeys(keys []APIKey) {
+	slices.SortFunc(keys, func(a, b APIKey) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// revoke отзывает ключ пользователя и возвращает его. Повторный отзыв
+// сохраняет исходное время.
+func (s apiKeySet) revoke(userID, id string, now time.Time) (APIKey, error) {
+	key, err := s.revoked(userID, id, now)
+	if err != nil {
+		return APIKey{}, err
+	}
+	s.put(key)
+	return key, nil
+}
+
+// revoked возвращает отозванное состояние ключа пользователя, не меняя набор.
+func (s apiKeySet) revoked(userID, id string, now time.Time) (APIKey, error) {
+	key, ok := s.byID[id]
+	switch {
+	case !ok:
+		return APIKey{}, ErrNotFound
+	case key.UserID != userID:
+		return APIKey{}, ErrForbidden
+	case key.Revoked():
+		return key, nil
+	}
+	key.RevokedAt = now
+	return key, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import "time"
+
+// BatchStatus описывает результат обработки элемента пакета.
+type BatchStatus int
+
+// Статусы элементов пакета.
+const (
+	// BatchCreated — создана новая короткая ссылка.
+	BatchCreated BatchStatus = iota
+	// BatchExisting — возвращена существующая ссылка на тот же URL.
+	BatchExisting
+	// BatchInvalid — элемент не сохранён, причина в BatchResult.Err.
+	BatchInvalid
+)
+
+// String возвращает название статуса для ответов API.
+func (st BatchStatus) String() string {
+	switch st {
+	case BatchCreated:
+		return "created"
+	case BatchExisting:
+		return "existing"
+	default:
+		return "invalid"
+	}
+}
+
+// BatchResult — результат сохранения одного элемента пакета.
+type BatchResult struct {
+	CorrelationID string      // Корреляционный идентификатор элемента.
+	ShortURL      string      // Короткий идентификатор; пуст для BatchInvalid.
+	Status        BatchStatus // Результат обработки.
+	Err           error       // Причина отказа для BatchInvalid.
+}
+
+// planBatch подготавливает пакет к сохранению в хранилище с данными data.
+// Элементы, совпадающие с действующими записями или с предыдущими элементами
+// пакета, получают их короткие идентификаторы, элементы с занятым алиасом
+// отклоняются, остальным при необходимости генерируется идентификатор.
+// Возвращает новые записи для сохранения и результаты всех элементов пакета.
+func planBatch(data map[string]URL, dedup *dedupIndex, urls []URL, now time.Time) (fresh []URL, results []BatchResult) {
+	results = make([]BatchResult, len(urls))
+	refs := make([]int, len(urls))     // Индекс новой записи в fresh или -1.
+	inBatch := make(map[string]int)    // Ключ дедупликации -> индекс в fresh.
+	taken := make(map[string]struct{}) // Идентификаторы, занятые пакетом.
+	for i, url := range urls {
+		refs[i] = -1
+		results[i].CorrelationID = url.CorrelationID
+		if shortID, ok := dedup.find(data, url, now); ok {
+			results[i].ShortURL = shortID
+			results[i].Status = BatchExisting
+			continue
+		}
+		key, hasKey := dedup.mode.key(url)
+		if j, seen := inBatch[key]; hasKey && seen {
+			refs[i] = j
+			results[i].Status = BatchExisting
+			continue
+		}
+
+		if url.ShortURL == "" {
+			url.ShortURL = uniqueShortID(data, taken)
+		} else {
+			_, inData := data[url.ShortURL]
+			_, inTaken := taken[url.ShortURL]
+			if inData || inTaken {
+				results[i].Status = BatchInvalid
+				results[i].Err = ErrAliasTaken
+				continue
+			}
+		}
+		taken[url.ShortURL] = struct{}{}
+		if hasKey {
+			inBatch[key] = len(fresh)
+		}
+		refs[i] = len(fresh)
+		results[i].Status = BatchCreated
+		fresh = append(fresh, url)
+	}
+
+	for i, ref := range refs {
+		if ref >= 0 {
+			results[i].ShortURL = fresh[ref].ShortURL
+		}
+	}
+	return fresh, results
+}
@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -30,7 +31,7 @@ func BenchmarkSaveURL(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		url.ShortURL = fmt.Sprintf("shortURL_%d", i)
-		if _, err := store.Save(url); err != nil {
+		if _, err := store.Save(context.Background(), url); err != nil {
 			b.Errorf("failed to save URL: %v", err)
 		}
 	}
@@ -0,0 +1,705 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Бакеты BoltStorage. Ключи индексов составные, части разделены нулевым байтом.
+var (
+	boltURLs       = []byte("urls")           // Короткий идентификатор -> URL в JSON.
+	boltByUser     = []byte("by_user")        // user_id, short_url -> пусто.
+	boltByOriginal = []byte("by_original")    // original_url, user_id, short_url -> пусто.
+	boltStats      = []byte("stats")          // Короткий идентификатор -> LinkStats в JSON.
+	boltKeys       = []byte("api_keys")       // ID ключа -> APIKey в JSON.
+	boltKeyHashes  = []byte("api_key_hashes") // Хеш ключа -> ID ключа.
+)
+
+// boltOpenTimeout — время ожидания блокировки файла, занятого другим процессом.
+const boltOpenTimeout = time.Second
+
+// BoltStorage представляет хранилище во встроенной базе bbolt (B+-дерево в
+// одном файле). Каждая изменяющая операция выполняется в отдельной
+// транзакции и сбрасывается на диск до возврата. Файл блокируется
+// открывшим его процессом.
+type BoltStorage struct {
+	db    *bolt.DB
+	dedup DedupMode // Режим дедупликации оригинальных URL.
+}
+
+// NewBoltStorage открывает или создаёт базу bbolt по пути path.
+func NewBoltStorage(path string, opts ...Option) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltURLs, boltByUser, boltByOriginal, boltStats, boltKeys, boltKeyHashes} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStorage{db: db, dedup: newOptions(opts).dedup}, nil
+}
+
+// wrapBoltError помечает ошибку закрытой базы как ErrUnavailable.
+func wrapBoltError(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// indexKey собирает составной ключ индекса.
+func indexKey(parts ...string) []byte {
+	return []byte(strings.Join(parts, "\x00"))
+}
+
+// indexPrefix собирает префикс составного ключа для перебора курсором.
+func indexPrefix(parts ...string) []byte {
+	return append(indexKey(parts...), 0)
+}
+
+// scanIndex вызывает fn для коротких идентификаторов из ключей индекса
+// bucket, начинающихся с prefix. Идентификатор — последняя часть ключа.
+func scanIndex(tx *bolt.Tx, bucket, prefix []byte, fn func(shortID string) error) error {
+	c := tx.Bucket(bucket).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if err := fn(string(k[bytes.LastIndexByte(k, 0)+1:])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getURL читает запись shortID.
+func getURL(tx *bolt.Tx, shortID string) (URL, bool, error) {
+	raw := tx.Bucket(boltURLs).Get([]byte(shortID))
+	if raw == nil {
+		return URL{}, false, nil
+	}
+	var url URL
+	if err := json.Unmarshal(raw, &url); err != nil {
+		return URL{}, false, fmt.Errorf("decode url %s: %w", shortID, err)
+	}
+	return url, true, nil
+}
+
+// putURL записывает запись без изменения индексов.
+func putURL(tx *bolt.Tx, url URL) error {
+	raw, err := json.Marshal(url)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(boltURLs).Put([]byte(url.ShortURL), raw)
+}
+
+// indexURL добавляет запись в индексы по пользователю и оригинальному URL.
+func indexURL(tx *bolt.Tx, url URL) error {
+	if err := tx.Bucket(boltByUser).Put(indexKey(url.UserID, url.ShortURL), nil); err != nil {
+		return err
+	}
+	return tx.Bucket(boltByOriginal).Put(indexKey(url.OriginalURL, url.UserID, url.ShortURL), nil)
+}
+
+// unindexURL удаляет запись из индексов.
+func unindexURL(tx *bolt.Tx, url URL) error {
+	if err := tx.Bucket(boltByUser).Delete(indexKey(url.UserID, url.ShortURL)); err != nil {
+		return err
+	}
+	return tx.Bucket(boltByOriginal).Delete(indexKey(url.OriginalURL, url.UserID, url.ShortURL))
+}
+
+// insertURL сохраняет новую запись и добавляет её в индексы.
+func insertURL(tx *bolt.Tx, url URL) error {
+	// Последовательность бакета служит счётчиком для GetNextID.
+	if _, err := tx.Bucket(boltURLs).NextSequence(); err != nil {
+		return err
+	}
+	if err := putURL(tx, url); err != nil {
+		return err
+	}
+	return indexURL(tx, url)
+}
+
+// removeBoltURL удаляет запись и её элементы индексов.
+func removeBoltURL(tx *bolt.Tx, url URL) error {
+	if err := unindexURL(tx, url); err != nil {
+		return err
+	}
+	return tx.Bucket(boltURLs).Delete([]byte(url.ShortURL))
+}
+
+// duplicates возвращает записи с тем же оригинальным URL, что у url, в
+// области дедупликации режима s.dedup.
+func (s *BoltStorage) duplicates(tx *bolt.Tx, url URL) ([]URL, error) {
+	var prefix []byte
+	switch s.dedup {
+	case DedupPerUser:
+		prefix = indexPrefix(url.OriginalURL, url.UserID)
+	case DedupGlobal:
+		prefix = indexPrefix(url.OriginalURL)
+	default:
+		return nil, nil
+	}
+	var urls []URL
+	err := scanIndex(tx, boltByOriginal, prefix, func(shortID string) error {
+		existing, ok, err := getURL(tx, shortID)
+		if ok {
+			urls = append(urls, existing)
+		}
+		return err
+	})
+	return urls, err
+}
+
+// findDuplicate возвращает короткий идентификатор самой ранней действующей
+// записи с тем же оригинальным URL в области дедупликации.
+func (s *BoltStorage) findDuplicate(tx *bolt.Tx, url URL, now time.Time) (string, bool, error) {
+	urls, err := s.duplicates(tx, url)
+	if err != nil {
+		return "", false, err
+	}
+	var found *URL
+	for i := range urls {
+		if urls[i].active(now) && (found == nil || urls[i].CreatedAt.Before(found.CreatedAt)) {
+			found = &urls[i]
+		}
+	}
+	if found == nil {
+		return "", false, nil
+	}
+	return found.ShortURL, true, nil
+}
+
+// Save сохраняет URL в базе.
+func (s *BoltStorage) Save(_ context.Context, url URL) (string, error) {
+	now := time.Now()
+	var existing string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		shortID, ok, err := s.findDuplicate(tx, url, now)
+		if err != nil {
+			return err
+		}
+		if ok {
+			existing = shortID
+			return ErrConflict
+		}
+		if tx.Bucket(boltURLs).Get([]byte(url.ShortURL)) != nil {
+			return ErrAliasTaken
+		}
+		url.stampCreated(now)
+		return insertURL(tx, url)
+	})
+	return existing, wrapBoltError(err)
+}
+
+// SaveBatch сохраняет пакет URL в одной транзакции.
+func (s *BoltStorage) SaveBatch(_ context.Context, urls []URL) ([]BatchResult, error) {
+	now := time.Now()
+	var results []BatchResult
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		data, index, err := s.batchDuplicates(tx, urls, now)
+		if err != nil {
+			return err
+		}
+		var fresh []URL
+		for {
+			fresh, results = planBatch(data, &index, urls, now)
+			// data содержит только возможные дубликаты, поэтому занятые
+			// идентификаторы добавляются в data, и пакет планируется заново.
+			var taken bool
+			for _, url := range fresh {
+				if tx.Bucket(boltURLs).Get([]byte(url.ShortURL)) != nil {
+					data[url.ShortURL] = URL{ShortURL: url.ShortURL}
+					taken = true
+				}
+			}
+			if !taken {
+				break
+			}
+		}
+		for _, url := range fresh {
+			url.stampCreated(now)
+			if err := insertURL(tx, url); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapBoltError(err)
+	}
+	return results, nil
+}
+
+// batchDuplicates загружает действующие записи с теми же оригинальными URL,
+// что у элементов пакета, и строит по ним индекс дедупликации.
+func (s *BoltStorage) batchDuplicates(tx *bolt.Tx, urls []URL, now time.Time) (map[string]URL, dedupIndex, error) {
+	data := make(map[string]URL)
+	index := dedupIndex{mode: s.dedup}
+	var found []URL
+	for _, url := range urls {
+		dups, err := s.duplicates(tx, url)
+		if err != nil {
+			return nil, index, err
+		}
+		for _, dup := range dups {
+			if _, seen := data[dup.ShortURL]; !seen && dup.active(now) {
+				data[dup.ShortURL] = dup
+				found = append(found, dup)
+			}
+		}
+	}
+	// Индекс заполняется от поздних записей к ранним, чтобы в нём осталась
+	// самая ранняя, как в findDuplicate.
+	sort.Slice(found, func(i, j int) bool { return found[i].CreatedAt.After(found[j].CreatedAt) })
+	for _, url := range found {
+		index.add(url)
+	}
+	return data, index, nil
+}
+
+// Get возвращает URL по сокращённому идентификатору.
+func (s *BoltStorage) Get(_ context.Context, shortURL string) (URL, error) {
+	var url URL
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var (
+			ok  bool
+			err error
+		)
+		url, ok, err = getURL(tx, shortURL)
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			return ErrNotFound
+		case url.DeletedFlag:
+			return ErrGone
+		}
+		return nil
+	})
+	return url, wrapBoltError(err)
+}
+
+// userURLs возвращает записи пользователя в порядке создания.
+func userURLs(tx *bolt.Tx, userID string) ([]URL, error) {
+	var urls []URL
+	err := scanIndex(tx, boltByUser, indexPrefix(userID), func(shortID string) error {
+		url, ok, err := getURL(tx, shortID)
+		if ok {
+			urls = append(urls, url)
+		}
+		return err
+	})
+	slices.SortFunc(urls, func(a, b URL) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ShortURL, b.ShortURL)
+	})
+	return urls, err
+}
+
+// GetURLsByUserID возвращает все URL, связанные с указанным идентификатором пользователя.
+func (s *BoltStorage) GetURLsByUserID(_ context.Context, userID string) ([]URL, error) {
+	var urls []URL
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		urls, err = userURLs(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, wrapBoltError(err)
+	}
+	return urls, nil
+}
+
+// ListURLsByUserID возвращает страницу URL пользователя.
+func (s *BoltStorage) ListURLsByUserID(ctx context.Context, userID string, query ListQuery) (URLPage, error) {
+	urls, err := s.GetURLsByUserID(ctx, userID)
+	if err != nil {
+		return URLPage{}, err
+	}
+	return listURLs(urls, query)
+}
+
+// GetNextID возвращает следующий уникальный идентификатор.
+func (s *BoltStorage) GetNextID(_ context.Context) (int, error) {
+	var nextID int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		nextID = int(tx.Bucket(boltURLs).Sequence()) + 1
+		return nil
+	})
+	return nextID, wrapBoltError(err)
+}
+
+// Ping проверяет, что база открыта.
+func (s *BoltStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return wrapBoltError(s.db.View(func(*bolt.Tx) error { return nil }))
+}
+
+// Close закрывает базу и освобождает блокировку файла.
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
+
+// MarkURLsAsDeleted помечает указанные сокращённые URL как удалённые для указанного пользователя.
+func (s *BoltStorage) MarkURLsAsDeleted(_ context.Context, userID string, ids []string) error {
+	now := time.Now()
+	var forbidden bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			url, ok, err := getURL(tx, id)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if url.UserID != userID {
+				forbidden = true
+				continue
+			}
+			url.markDeleted(now)
+			if err := putURL(tx, url); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return wrapBoltError(err)
+	}
+	if forbidden {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// URLCount возвращает число всех URL в базе.
+func (s *BoltStorage) URLCount(_ context.Context) (int, error) {
+	var count int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(boltURLs).Stats().KeyN
+		return nil
+	})
+	return count, wrapBoltError(err)
+}
+
+// UserCount возвращает количество пользователей, у которых есть ссылки.
+func (s *BoltStorage) UserCount(_ context.Context) (int, error) {
+	var count int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var last []byte
+		return tx.Bucket(boltByUser).ForEach(func(k, _ []byte) error {
+			userID := k[:bytes.IndexByte(k, 0)]
+			if last == nil || !bytes.Equal(userID, last) {
+				count++
+				last = append(last[:0], userID...)
+			}
+			return nil
+		})
+	})
+	return count, wrapBoltError(err)
+}
+
+// matchURLs возвращает записи, для которых match возвращает true. Бакет
+// нельзя изменять во время ForEach, поэтому найденные записи изменяются после обхода.
+func matchURLs(tx *bolt.Tx, match func(URL) bool) ([]URL, error) {
+	var matched []URL
+	err := tx.Bucket(boltURLs).ForEach(func(k, v []byte) error {
+		var url URL
+		if err := json.Unmarshal(v, &url); err != nil {
+			return fmt.Errorf("decode url %s: %w", k, err)
+		}
+		if match(url) {
+			matched = append(matched, url)
+		}
+		return nil
+	})
+	return matched, err
+}
+
+// removeWhere удаляет записи, для которых match возвращает true, и возвращает их число.
+func removeWhere(tx *bolt.Tx, match func(URL) bool) (int, error) {
+	matched, err := matchURLs(tx, match)
+	if err != nil {
+		return 0, err
+	}
+	for _, url := range matched {
+		if err := removeBoltURL(tx, url); err != nil {
+			return 0, err
+		}
+	}
+	return len(matched), nil
+}
+
+// DeleteExpired помечает удалёнными URL с истёкшим сроком действия.
+func (s *BoltStorage) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	var deleted int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		expired, err := matchURLs(tx, func(url URL) bool { return !url.DeletedFlag && url.Expired(now) })
+		if err != nil {
+			return err
+		}
+		for _, url := range expired {
+			url.markDeleted(now)
+			if err := putURL(tx, url); err != nil {
+				return err
+			}
+		}
+		deleted = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, wrapBoltError(err)
+	}
+	return deleted, nil
+}
+
+// SaveClicks учитывает переходы в статистике ссылок.
+func (s *BoltStorage) SaveClicks(_ context.Context, clicks []Click) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltStats)
+		stats := make(map[string]LinkStats)
+		for _, c := range clicks {
+			if _, loaded := stats[c.ShortURL]; loaded {
+				continue
+			}
+			if raw := bucket.Get([]byte(c.ShortURL)); raw != nil {
+				var st LinkStats
+				if err := json.Unmarshal(raw, &st); err != nil {
+					return fmt.Errorf("decode stats %s: %w", c.ShortURL, err)
+				}
+				stats[c.ShortURL] = st
+			}
+		}
+		addClicks(stats, clicks)
+		for shortID, st := range stats {
+			raw, err := json.Marshal(st)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(shortID), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return wrapBoltError(err)
+}
+
+// GetLinkStats возвращает статистику переходов по короткой ссылке.
+func (s *BoltStorage) GetLinkStats(_ context.Context, shortURL string) (LinkStats, error) {
+	st := NewLinkStats(shortURL)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltStats).Get([]byte(shortURL))
+		if raw == nil {
+			return nil
+		}
+		return json.Unmarshal(raw, &st)
+	})
+	return st, wrapBoltError(err)
+}
+
+// getAPIKey читает API-ключ по ID.
+func getAPIKey(tx *bolt.Tx, id []byte) (APIKey, bool, error) {
+	raw := tx.Bucket(boltKeys).Get(id)
+	if raw == nil {
+		return APIKey{}, false, nil
+	}
+	var key APIKey
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return APIKey{}, false, fmt.Errorf("decode api key %s: %w", id, err)
+	}
+	return key, true, nil
+}
+
+// putAPIKey записывает API-ключ и его хеш.
+func putAPIKey(tx *bolt.Tx, key APIKey) error {
+	raw, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(boltKeys).Put([]byte(key.ID), raw); err != nil {
+		return err
+	}
+	return tx.Bucket(boltKeyHashes).Put([]byte(key.Hash), []byte(key.ID))
+}
+
+// SaveAPIKey сохраняет новый API-ключ.
+func (s *BoltStorage) SaveAPIKey(_ context.Context, key APIKey) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltKeys).Get([]byte(key.ID)) != nil || tx.Bucket(boltKeyHashes).Get([]byte(key.Hash)) != nil {
+			return ErrConflict
+		}
+		return putAPIKey(tx, key)
+	})
+	return wrapBoltError(err)
+}
+
+// GetAPIKeyByHash возвращает API-ключ по хешу.
+func (s *BoltStorage) GetAPIKeyByHash(_ context.Context, hash string) (APIKey, error) {
+	var key APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(boltKeyHashes).Get([]byte(hash))
+		if id == nil {
+			return ErrNotFound
+		}
+		var (
+			ok  bool
+			err error
+		)
+		key, ok, err = getAPIKey(tx, id)
+		if err == nil && !ok {
+			return ErrNotFound
+		}
+		return err
+	})
+	return key, wrapBoltError(err)
+}
+
+// ListAPIKeys возвращает API-ключи пользователя.
+func (s *BoltStorage) ListAPIKeys(_ context.Context, userID string) ([]APIKey, error) {
+	var keys []APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltKeys).ForEach(func(k, v []byte) error {
+			var key APIKey
+			if err := json.Unmarshal(v, &key); err != nil {
+				return fmt.Errorf("decode api key %s: %w", k, err)
+			}
+			if key.UserID == userID {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, wrapBoltError(err)
+	}
+	sortAPIKeys(keys)
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ пользователя. Повторный отзыв сохраняет исходное время.
+func (s *BoltStorage) RevokeAPIKey(_ context.Context, userID, id string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		key, ok, err := getAPIKey(tx, []byte(id))
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			return ErrNotFound
+		case key.UserID != userID:
+			return ErrForbidden
+		case key.Revoked():
+			return nil
+		}
+		key.RevokedAt = time.Now()
+		return putAPIKey(tx, key)
+	})
+	return wrapBoltError(err)
+}
+
+// SetURLDeleted помечает ссылку удалённой или восстанавливает её.
+func (s *BoltStorage) SetURLDeleted(_ context.Context, shortURL string, deleted bool) error {
+	now := time.Now()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		url, ok, err := getURL(tx, shortURL)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNotFound
+		}
+		switch {
+		case deleted:
+			url.markDeleted(now)
+		case url.DeletedFlag:
+			url.DeletedFlag = false
+			url.DeletedAt = time.Time{}
+			url.UpdatedAt = now
+		}
+		return putURL(tx, url)
+	})
+	return wrapBoltError(err)
+}
+
+// ReassignURLs передаёт ссылки пользователя from пользователю to.
+func (s *BoltStorage) ReassignURLs(_ context.Context, from, to string, shortURLs []string) (int, error) {
+	now := time.Now()
+	var moved int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		ids := shortURLs
+		if len(ids) == 0 {
+			err := scanIndex(tx, boltByUser, indexPrefix(from), func(shortID string) error {
+				ids = append(ids, shortID)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, shortID := range ids {
+			url, ok, err := getURL(tx, shortID)
+			if err != nil {
+				return err
+			}
+			if !ok || url.UserID != from {
+				continue
+			}
+			if err := unindexURL(tx, url); err != nil {
+				return err
+			}
+			url.UserID = to
+			url.UpdatedAt = now
+			if err := putURL(tx, url); err != nil {
+				return err
+			}
+			if err := indexURL(tx, url); err != nil {
+				return err
+			}
+			moved++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, wrapBoltError(err)
+	}
+	return moved, nil
+}
+
+// PurgeDeleted удаляет ссылки, помеченные удалёнными раньше before.
+func (s *BoltStorage) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+	var purged int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		purged, err = removeWhere(tx, func(url URL) bool {
+			return url.DeletedFlag && url.DeletedAt.Before(before)
+		})
+		return err
+	})
+	if err != nil {
+		return 0, wrapBoltError(err)
+	}
+	return purged, nil
+}
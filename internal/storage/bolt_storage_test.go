@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// newBoltStorage открывает базу bbolt во временном каталоге.
+func newBoltStorage(t *testing.T, opts ...Option) *BoltStorage {
+	logger.Sugar = *zap.NewNop().Sugar()
+	s, err := NewBoltStorage(filepath.Join(t.TempDir(), "urls.db"), opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestBoltStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "urls.db")
+	s, err := NewBoltStorage(path)
+	require.NoError(t, err)
+
+	_, err = s.Save(ctx, URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	require.NoError(t, err)
+	results, err := s.SaveBatch(ctx, []URL{
+		{CorrelationID: "2", OriginalURL: "https://b.com", UserID: "alice"},
+		{CorrelationID: "3", ShortURL: "a1", OriginalURL: "https://c.com", UserID: "alice"},
+		{CorrelationID: "4", OriginalURL: "https://d.com", UserID: "bob", ExpiresAt: time.Now().Add(-time.Minute)},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, BatchCreated, results[0].Status)
+	assert.Equal(t, BatchInvalid, results[1].Status)
+	assert.ErrorIs(t, results[1].Err, ErrAliasTaken)
+	assert.Equal(t, BatchCreated, results[2].Status)
+
+	nextID, err := s.GetNextID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, nextID)
+
+	require.NoError(t, s.SaveClicks(ctx, []Click{
+		{ShortURL: "a1", ClickedAt: time.Now(), Country: "DE"},
+		{ShortURL: "a1", ClickedAt: time.Now(), Country: "DE"},
+	}))
+	require.NoError(t, s.SaveAPIKey(ctx, APIKey{ID: "k1", UserID: "alice", Hash: "h1", CreatedAt: time.Now()}))
+	assert.ErrorIs(t, s.SaveAPIKey(ctx, APIKey{ID: "k2", UserID: "bob", Hash: "h1"}), ErrConflict)
+
+	assert.ErrorIs(t, s.MarkURLsAsDeleted(ctx, "bob", []string{"a1"}), ErrForbidden)
+	deleted, err := s.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	require.NoError(t, s.Close())
+
+	_, err = s.Get(ctx, "a1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, s.Ping(ctx), ErrUnavailable)
+
+	// Данные и индексы сохраняются в файле.
+	reloaded, err := NewBoltStorage(path)
+	require.NoError(t, err)
+	defer reloaded.Close()
+	require.NoError(t, reloaded.Ping(ctx))
+
+	url, err := reloaded.Get(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://a.com", url.OriginalURL)
+	assert.False(t, url.CreatedAt.IsZero())
+	_, err = reloaded.Get(ctx, results[2].ShortURL)
+	assert.ErrorIs(t, err, ErrGone)
+
+	urls, err := reloaded.GetURLsByUserID(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, "a1", urls[0].ShortURL)
+	count, err := reloaded.URLCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	users, err := reloaded.UserCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, users)
+
+	stats, err := reloaded.GetLinkStats(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Clicks)
+	assert.Equal(t, 2, stats.Countries["DE"])
+
+	key, err := reloaded.GetAPIKeyByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.ID)
+	assert.ErrorIs(t, reloaded.RevokeAPIKey(ctx, "bob", "k1"), ErrForbidden)
+	require.NoError(t, reloaded.RevokeAPIKey(ctx, "alice", "k1"))
+	keys, err := reloaded.ListAPIKeys(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, keys[0].Revoked())
+
+	existing, err := reloaded.Save(ctx, URL{ShortURL: "a9", OriginalURL: "https://a.com", UserID: "alice"})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "a1", existing)
+}
+
+func TestBoltStorage_Locked(t *testing.T) {
+	s := newBoltStorage(t)
+	_, err := NewBoltStorage(s.db.Path())
+	assert.Error(t, err)
+}
+
+func TestBoltStorage_Concurrent(t *testing.T) {
+	hammerStorage(t, newBoltStorage(t))
+}
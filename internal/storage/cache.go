@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// cacheStats — счётчики кеша коротких ссылок, доступные через /debug/vars.
+// Общие для всех экземпляров CachedStorage.
+var cacheStats = expvar.NewMap("storage_cache")
+
+// Ключи счётчиков cacheStats.
+const (
+	statHits      = "hits"      // Ответы из кеша, включая отсутствующие ссылки.
+	statMisses    = "misses"    // Обращения к обёрнутому хранилищу.
+	statEvictions = "evictions" // Записи, вытесненные по размеру кеша.
+)
+
+// CachedStorage — хранилище с кешем Get поверх другого хранилища.
+// Кеш ограничен по числу записей и вытесняет давно не использованные (LRU).
+// Кешируются найденные, удалённые и отсутствующие ссылки; записи
+// сбрасываются при изменении ссылок через CachedStorage. Изменения,
+// сделанные в обход него, видны после истечения TTL записи, а при
+// ttl <= 0 — только после вытеснения. Изменения DBStorage на других
+// экземплярах сервиса сбрасывает ChangeListener.
+type CachedStorage struct {
+	Storage
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // Записи от недавно использованных к давно использованным.
+	entries map[string]*list.Element
+	// gen увеличивается при каждом сбросе: результат Get, запрошенный до
+	// сброса, не попадает в кеш.
+	gen uint64
+}
+
+// cacheEntry — результат Get для короткого идентификатора.
+type cacheEntry struct {
+	shortURL string
+	url      URL
+	err      error // nil, ErrGone или ErrNotFound.
+	expires  time.Time
+}
+
+// NewCachedStorage оборачивает s кешем на size записей, каждая из которых
+// живёт ttl. При ttl <= 0 записи устаревают только при вытеснении и сбросе.
+func NewCachedStorage(s Storage, size int, ttl time.Duration) *CachedStorage {
+	return &CachedStorage{
+		Storage: s,
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get возвращает URL из кеша или из обёрнутого хранилища.
+func (s *CachedStorage) Get(ctx context.Context, shortURL string) (URL, error) {
+	s.mu.Lock()
+	if entry, ok := s.lookup(shortURL); ok {
+		s.mu.Unlock()
+		cacheStats.Add(statHits, 1)
+		return entry.url, entry.err
+	}
+	gen := s.gen
+	s.mu.Unlock()
+	cacheStats.Add(statMisses, 1)
+
+	url, err := s.Storage.Get(ctx, shortURL)
+	if err == nil || errors.Is(err, ErrGone) || errors.Is(err, ErrNotFound) {
+		s.mu.Lock()
+		if s.gen == gen {
+			s.store(cacheEntry{shortURL: shortURL, url: url, err: err})
+		}
+		s.mu.Unlock()
+	}
+	return url, err
+}
+
+// lookup возвращает действующую запись кеша. Вызывается под s.mu.
+func (s *CachedStorage) lookup(shortURL string) (*cacheEntry, bool) {
+	elem, ok := s.entries[shortURL]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if s.ttl > 0 && !s.now().Before(entry.expires) {
+		s.order.Remove(elem)
+		delete(s.entries, shortURL)
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return entry, true
+}
+
+// store добавляет запись в кеш и вытесняет лишние. Вызывается под s.mu.
+func (s *CachedStorage) store(entry cacheEntry) {
+	if s.ttl > 0 {
+		entry.expires = s.now().Add(s.ttl)
+	}
+	if elem, ok := s.entries[entry.shortURL]; ok {
+		elem.Value = &entry
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[entry.shortURL] = s.order.PushFront(&entry)
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cacheEntry).shortURL)
+		cacheStats.Add(statEvictions, 1)
+	}
+}
+
+// Invalidate сбрасывает записи кеша для коротких идентификаторов shortURLs.
+func (s *CachedStorage) Invalidate(shortURLs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	for _, shortURL := range shortURLs {
+		if elem, ok := s.entries[shortURL]; ok {
+			s.order.Remove(elem)
+			delete(s.entries, shortURL)
+		}
+	}
+}
+
+// Purge сбрасывает все записи кеша.
+func (s *CachedStorage) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	s.order.Init()
+	clear(s.entries)
+}
+
+// Len возвращает число записей в кеше.
+func (s *CachedStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Save сохраняет URL и сбрасывает запись об отсутствии его идентификатора.
+func (s *CachedStorage) Save(ctx context.Context, url URL) (string, error) {
+	shortURL, err := s.Storage.Save(ctx, url)
+	if err == nil {
+		s.Invalidate(url.ShortURL)
+	}
+	return shortURL, err
+}
+
+// SaveBatch сохраняет пакет URL и сбрасывает записи созданных идентификаторов.
+func (s *CachedStorage) SaveBatch(ctx context.Context, urls []URL) ([]BatchResult, error) {
+	results, err := s.Storage.SaveBatch(ctx, urls)
+	s.invalidateCreated(results)
+	return results, err
+}
+
+// ImportBatch импортирует пакет через BulkImporter обёрнутого хранилища,
+// а если он не поддерживается — через SaveBatch.
+func (s *CachedStorage) ImportBatch(ctx context.Context, urls []URL) ([]BatchResult, error) {
+	importer, ok := s.Storage.(BulkImporter)
+	if !ok {
+		return s.SaveBatch(ctx, urls)
+	}
+	results, err := importer.ImportBatch(ctx, urls)
+	s.invalidateCreated(results)
+	return results, err
+}
+
+func (s *CachedStorage) invalidateCreated(results []BatchResult) {
+	var created []string
+	for _, result := range results {
+		if result.Status == BatchCreated {
+			created = append(created, result.ShortURL)
+		}
+	}
+	if len(created) > 0 {
+		s.Invalidate(created...)
+	}
+}
+
+// MarkURLsAsDeleted помечает URL удалёнными и сбрасывает их записи в кеше.
+func (s *CachedStorage) MarkURLsAsDeleted(ctx context.Context, userID string, shortIDs []string) error {
+	defer s.Invalidate(shortIDs...)
+	return s.Storage.MarkURLsAsDeleted(ctx, userID, shortIDs)
+}
+
+// DeleteExpired удаляет истёкшие URL и сбрасывает кеш, если что-то удалено.
+func (s *CachedStorage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	deleted, err := s.Storage.DeleteExpired(ctx, now)
+	if deleted > 0 {
+		s.Purge()
+	}
+	return deleted, err
+}
+
+// admin возвращает Admin обёрнутого хранилища.
+func (s *CachedStorage) admin() (Admin, error) {
+	admin, ok := s.Storage.(Admin)
+	if !ok {
+		return nil, fmt.Errorf("%T: %w", s.Storage, errors.ErrUnsupported)
+	}
+	return admin, nil
+}
+
+// SetURLDeleted реализует Admin и сбрасывает запись ссылки в кеше.
+func (s *CachedStorage) SetURLDeleted(ctx context.Context, shortURL string, deleted bool) error {
+	admin, err := s.admin()
+	if err != nil {
+		return err
+	}
+	defer s.Invalidate(shortURL)
+	return admin.SetURLDeleted(ctx, shortURL, deleted)
+}
+
+// ReassignURLs реализует Admin. Владелец входит в кешируемый URL,
+// поэтому кеш сбрасывается.
+func (s *CachedStorage) ReassignURLs(ctx context.Context, from, to string, shortURLs []string) (int, error) {
+	admin, err := s.admin()
+	if err != nil {
+		return 0, err
+	}
+	moved, err := admin.ReassignURLs(ctx, from, to, shortURLs)
+	if moved > 0 {
+		s.Purge()
+	}
+	return moved, err
+}
+
+// PurgeDeleted реализует Admin и сбрасывает кеш, если что-то удалено.
+func (s *CachedStorage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	admin, err := s.admin()
+	if err != nil {
+		return 0, err
+	}
+	purged, err := admin.PurgeDeleted(ctx, before)
+	if purged > 0 {
+		s.Purge()
+	}
+	return purged, err
+}
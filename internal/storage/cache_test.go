@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// countingStorage считает обращения к Get обёрнутого хранилища.
+type countingStorage struct {
+	*MemoryStorage
+	gets atomic.Int64
+}
+
+func (s *countingStorage) Get(ctx context.Context, shortURL string) (URL, error) {
+	s.gets.Add(1)
+	return s.MemoryStorage.Get(ctx, shortURL)
+}
+
+func newCachedStorage(size int, ttl time.Duration) (*CachedStorage, *countingStorage) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	inner := &countingStorage{MemoryStorage: NewMemoryStorage()}
+	return NewCachedStorage(inner, size, ttl), inner
+}
+
+// cacheStat возвращает текущее значение счётчика кеша.
+func cacheStat(key string) int64 {
+	if v, ok := cacheStats.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestCachedStorage_Get(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newCachedStorage(10, time.Minute)
+	_, err := s.Save(ctx, URL{ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	require.NoError(t, err)
+
+	hits, misses := cacheStat(statHits), cacheStat(statMisses)
+	for i := 0; i < 3; i++ {
+		url, err := s.Get(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, "https://a.com", url.OriginalURL)
+	}
+	assert.EqualValues(t, 1, inner.gets.Load())
+	assert.Equal(t, hits+2, cacheStat(statHits))
+	assert.Equal(t, misses+1, cacheStat(statMisses))
+	assert.Contains(t, expvar.Get("storage_cache").String(), `"hits"`)
+
+	// Отсутствующий идентификатор тоже кешируется.
+	for i := 0; i < 2; i++ {
+		_, err = s.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.EqualValues(t, 2, inner.gets.Load())
+
+	// Сохранение сбрасывает запись об отсутствии.
+	_, err = s.Save(ctx, URL{ShortURL: "missing", OriginalURL: "https://b.com", UserID: "alice"})
+	require.NoError(t, err)
+	url, err := s.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, "https://b.com", url.OriginalURL)
+
+	results, err := s.SaveBatch(ctx, []URL{{ShortURL: "a2", OriginalURL: "https://c.com", UserID: "alice"}})
+	require.NoError(t, err)
+	require.Equal(t, BatchCreated, results[0].Status)
+	_, err = s.Get(ctx, "a2")
+	assert.NoError(t, err)
+}
+
+func TestCachedStorage_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newCachedStorage(10, 0)
+	for _, id := range []string{"a1", "a2"} {
+		_, err := s.Save(ctx, URL{ShortURL: id, OriginalURL: "https://" + id + ".com", UserID: "alice"})
+		require.NoError(t, err)
+		_, err = s.Get(ctx, id)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a1"}))
+	url, err := s.Get(ctx, "a1")
+	assert.ErrorIs(t, err, ErrGone)
+	assert.True(t, url.DeletedFlag)
+
+	require.NoError(t, s.SetURLDeleted(ctx, "a1", false))
+	_, err = s.Get(ctx, "a1")
+	assert.NoError(t, err)
+
+	_, err = s.ReassignURLs(ctx, "alice", "bob", []string{"a2"})
+	require.NoError(t, err)
+	url, err = s.Get(ctx, "a2")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", url.UserID)
+
+	s.Purge()
+	assert.Zero(t, s.Len())
+}
+
+func TestCachedStorage_Eviction(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newCachedStorage(2, 0)
+	evictions := cacheStat(statEvictions)
+
+	for _, id := range []string{"a1", "a2", "a1", "a3"} {
+		_, err := s.Get(ctx, id)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, evictions+1, cacheStat(statEvictions))
+	assert.EqualValues(t, 3, inner.gets.Load())
+
+	// Вытеснена давно не использованная запись a2, a1 осталась.
+	_, _ = s.Get(ctx, "a1")
+	assert.EqualValues(t, 3, inner.gets.Load())
+	_, _ = s.Get(ctx, "a2")
+	assert.EqualValues(t, 4, inner.gets.Load())
+}
+
+func TestCachedStorage_TTL(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newCachedStorage(10, time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	_, _ = s.Get(ctx, "a1")
+	now = now.Add(59 * time.Second)
+	_, _ = s.Get(ctx, "a1")
+	assert.EqualValues(t, 1, inner.gets.Load())
+
+	now = now.Add(time.Second)
+	_, _ = s.Get(ctx, "a1")
+	assert.EqualValues(t, 2, inner.gets.Load())
+}
+
+// blockingStorage задерживает Get до сигнала release.
+type blockingStorage struct {
+	*MemoryStorage
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStorage) Get(ctx context.Context, shortURL string) (URL, error) {
+	url, err := s.MemoryStorage.Get(ctx, shortURL)
+	s.started <- struct{}{}
+	<-s.release
+	return url, err
+}
+
+func TestCachedStorage_StaleFill(t *testing.T) {
+	ctx := context.Background()
+	logger.Sugar = *zap.NewNop().Sugar()
+	inner := &blockingStorage{MemoryStorage: NewMemoryStorage(), started: make(chan struct{}), release: make(chan struct{})}
+	s := NewCachedStorage(inner, 10, 0)
+	_, err := s.Save(ctx, URL{ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = s.Get(ctx, "a1")
+	}()
+	<-inner.started
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a1"}))
+	close(inner.release)
+	<-done
+
+	// Результат, прочитанный до удаления, не попал в кеш.
+	assert.Zero(t, s.Len())
+	go func() { <-inner.started }()
+	_, err = s.Get(ctx, "a1")
+	assert.ErrorIs(t, err, ErrGone)
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	concurrentWorkers = 8
+	concurrentOps     = 100
+)
+
+// hammerStorage параллельно вызывает Save, Get, GetURLsByUserID и
+// MarkURLsAsDeleted. Гонки данных обнаруживаются при запуске с -race.
+func hammerStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrentWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			userID := fmt.Sprintf("user%d", w)
+			for i := 0; i < concurrentOps; i++ {
+				shortID := fmt.Sprintf("w%d-%d", w, i)
+				url := URL{
+					CorrelationID: shortID,
+					ShortURL:      shortID,
+					OriginalURL:   "https://example.com/" + shortID,
+					UserID:        userID,
+				}
+				if _, err := s.Save(ctx, url); err != nil {
+					t.Errorf("save %s: %v", shortID, err)
+					return
+				}
+				if _, err := s.Get(ctx, shortID); err != nil {
+					t.Errorf("get %s: %v", shortID, err)
+				}
+				if i%10 == 0 {
+					if err := s.MarkURLsAsDeleted(ctx, userID, []string{shortID}); err != nil {
+						t.Errorf("delete %s: %v", shortID, err)
+					}
+				}
+				if _, err := s.GetURLsByUserID(ctx, userID); err != nil {
+					t.Errorf("get user urls: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	count, err := s.URLCount(ctx)
+	if err != nil {
+		t.Fatalf("url count: %v", err)
+	}
+	if want := concurrentWorkers * concurrentOps; count != want {
+		t.Errorf("expected %d urls, got %d", want, count)
+	}
+
+	if _, err := s.Get(ctx, "w0-0"); !errors.Is(err, ErrGone) {
+		t.Errorf("expected ErrGone for deleted url, got %v", err)
+	}
+}
+
+func TestMemoryStorage_Concurrent(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	hammerStorage(t, NewMemoryStorage())
+}
+
+func TestFileStorage_Concurrent(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+
+	tempFile, err := os.CreateTemp("", "storage_concurrent_*.json")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	fs, err := NewFileStorage(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	hammerStorage(t, fs)
+
+	// Файл, записанный параллельно, должен корректно загружаться.
+	reloaded, err := NewFileStorage(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to reload file storage: %v", err)
+	}
+	count, _ := reloaded.URLCount(context.Background())
+	if want := concurrentWorkers * concurrentOps; count != want {
+		t.Errorf("expected %d urls after reload, got %d", want, count)
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// importColumns — столбцы, заполняемые при массовой загрузке.
+var importColumns = []string{"correlation_id", "short_url", "original_url", "user_id", "expires_at", "created_at", "updated_at"}
+
+// ImportBatch сохраняет пакет URL через COPY во временную таблицу с
+// последующим переносом в urls одним запросом. Результаты совпадают с
+// SaveBatch. На время переноса таблица urls блокируется для записи, поэтому
+// параллельные сокращения ждут завершения пакета, а дедупликация не нарушается.
+func (s *DBStorage) ImportBatch(ctx context.Context, urls []URL) ([]BatchResult, error) {
+	conn, err := s.Database.Conn(ctx)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer conn.Close()
+
+	var results []BatchResult
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("bulk import requires the pgx driver, got %T", driverConn)
+		}
+		results, err = s.importBatch(ctx, pgxConn.Conn(), urls)
+		return err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	return results, nil
+}
+
+func (s *DBStorage) importBatch(ctx context.Context, conn *pgx.Conn, urls []URL) ([]BatchResult, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "LOCK TABLE urls IN SHARE ROW EXCLUSIVE MODE;"); err != nil {
+		return nil, err
+	}
+	data, index, err := s.importDuplicates(ctx, tx, urls)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var (
+		fresh   []URL
+		results []BatchResult
+	)
+	for {
+		fresh, results = planBatch(data, &index, urls, now)
+		// data содержит только возможные дубликаты, поэтому алиасы и
+		// сгенерированные идентификаторы проверяются отдельно. Занятые
+		// добавляются в data, и пакет планируется заново.
+		taken, err := takenShortIDs(ctx, tx, fresh)
+		if err != nil {
+			return nil, err
+		}
+		if len(taken) == 0 {
+			break
+		}
+		for _, shortID := range taken {
+			data[shortID] = URL{ShortURL: shortID}
+		}
+	}
+	if len(fresh) == 0 {
+		return results, nil
+	}
+
+	_, err = tx.Exec(ctx, "CREATE TEMP TABLE import_urls (LIKE urls INCLUDING DEFAULTS) ON COMMIT DROP;")
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_urls"}, importColumns,
+		pgx.CopyFromSlice(len(fresh), func(i int) ([]any, error) {
+			url := fresh[i]
+			url.stampCreated(now)
+			return []any{url.CorrelationID, url.ShortURL, url.OriginalURL, url.UserID,
+				nullTime(url.ExpiresAt), url.CreatedAt, url.UpdatedAt}, nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+	tag, err := tx.Exec(ctx, "INSERT INTO urls (correlation_id, short_url, original_url, user_id, expires_at, created_at, updated_at) "+
+		"SELECT correlation_id, short_url, original_url, user_id, expires_at, created_at, updated_at FROM import_urls ORDER BY id "+
+		"ON CONFLICT DO NOTHING;")
+	if err != nil {
+		return nil, err
+	}
+	// Под блокировкой конфликтов быть не может; расхождение означает ошибку в плане.
+	if tag.RowsAffected() != int64(len(fresh)) {
+		return nil, fmt.Errorf("bulk import inserted %d of %d urls", tag.RowsAffected(), len(fresh))
+	}
+	// Уведомление доставляется при фиксации вместе с новыми ссылками.
+	payload, err := changePayload(changeCreate, createdShortURLs(results))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2);", urlChangesChannel, payload); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// importDuplicates загружает действующие записи с теми же оригинальными URL,
+// что у элементов пакета, и строит по ним индекс дедупликации.
+func (s *DBStorage) importDuplicates(ctx context.Context, tx pgx.Tx, urls []URL) (map[string]URL, dedupIndex, error) {
+	data := make(map[string]URL)
+	index := dedupIndex{mode: s.dedup}
+	if s.dedup == DedupNone {
+		return data, index, nil
+	}
+
+	originals := make([]string, 0, len(urls))
+	seen := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		if _, ok := seen[url.OriginalURL]; !ok {
+			seen[url.OriginalURL] = struct{}{}
+			originals = append(originals, url.OriginalURL)
+		}
+	}
+
+	// Записи читаются в обратном порядке, чтобы в индексе осталась самая
+	// ранняя, как в findDuplicate.
+	rows, err := tx.Query(ctx, "SELECT short_url, original_url, COALESCE(user_id, '') FROM urls "+
+		"WHERE original_url = ANY($1) AND NOT is_deleted AND (expires_at IS NULL OR expires_at > now()) ORDER BY id DESC;", originals)
+	if err != nil {
+		return nil, index, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url URL
+		if err := rows.Scan(&url.ShortURL, &url.OriginalURL, &url.UserID); err != nil {
+			return nil, index, err
+		}
+		data[url.ShortURL] = url
+		index.add(url)
+	}
+	return data, index, rows.Err()
+}
+
+// takenShortIDs возвращает короткие идентификаторы новых записей, уже занятые в urls.
+func takenShortIDs(ctx context.Context, tx pgx.Tx, fresh []URL) ([]string, error) {
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(fresh))
+	for i, url := range fresh {
+		ids[i] = url.ShortURL
+	}
+	rows, err := tx.Query(ctx, "SELECT short_url FROM urls WHERE short_url = ANY($1);", ids)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+func TestDBStorage_ImportBatch(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	storage, err := NewDBStorage(testDSN)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	ctx := context.Background()
+	_, err = storage.Save(ctx, URL{CorrelationID: "0", ShortURL: "taken", OriginalURL: "https://existing.com", UserID: "user1"})
+	require.NoError(t, err)
+
+	results, err := storage.ImportBatch(ctx, []URL{
+		{CorrelationID: "1", OriginalURL: "https://a.com", UserID: "user1"},
+		{CorrelationID: "2", OriginalURL: "https://existing.com", UserID: "user1"},
+		{CorrelationID: "3", OriginalURL: "https://a.com", UserID: "user1"},
+		{CorrelationID: "4", OriginalURL: "https://b.com", ShortURL: "taken", UserID: "user1"},
+		{CorrelationID: "5", OriginalURL: "https://c.com", ShortURL: "custom", UserID: "user1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, BatchCreated, results[0].Status)
+	assert.Equal(t, BatchResult{CorrelationID: "2", ShortURL: "taken", Status: BatchExisting}, results[1])
+	assert.Equal(t, BatchResult{CorrelationID: "3", ShortURL: results[0].ShortURL, Status: BatchExisting}, results[2])
+	assert.ErrorIs(t, results[3].Err, ErrAliasTaken)
+	assert.Equal(t, BatchResult{CorrelationID: "5", ShortURL: "custom", Status: BatchCreated}, results[4])
+
+	url, err := storage.Get(ctx, results[0].ShortURL)
+	require.NoError(t, err)
+	assert.Equal(t, "https://a.com", url.OriginalURL)
+	assert.False(t, url.CreatedAt.IsZero())
+}
+
+func TestDBStorage_ImportBatchRequiresPgx(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+	_, err = storage.ImportBatch(context.Background(), []URL{{OriginalURL: "https://a.com"}})
+	assert.ErrorContains(t, err, "pgx driver")
+}
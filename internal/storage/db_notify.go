@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// urlChangesChannel — канал pg_notify для уведомлений об изменении ссылок.
+const urlChangesChannel = "url_changes"
+
+// maxNotifyPayload — предел размера уведомления; у PostgreSQL он чуть меньше 8000 байт.
+// Уведомление со списком длиннее отправляется без идентификаторов.
+const maxNotifyPayload = 7900
+
+// Виды изменений ссылок в уведомлениях.
+const (
+	changeCreate  = "create"  // Созданы новые ссылки; сбрасываются записи об их отсутствии.
+	changeDelete  = "delete"  // Ссылки удалены пользователем, по сроку действия или безвозвратно.
+	changeUpdate  = "update"  // Изменён владелец ссылок.
+	changeDisable = "disable" // Ссылка отключена администратором.
+	changeEnable  = "enable"  // Ссылка восстановлена администратором.
+)
+
+// Пределы задержки перед повторным подключением ChangeListener.
+const (
+	listenMinBackoff = 500 * time.Millisecond
+	listenMaxBackoff = 30 * time.Second
+)
+
+// urlChange — содержимое уведомления об изменении ссылок.
+type urlChange struct {
+	Op  string   `json:"op"`
+	IDs []string `json:"ids,omitempty"` // Пустой список — затронутые ссылки неизвестны.
+}
+
+// changePayload кодирует уведомление об изменении ссылок ids. Если
+// уведомление не помещается в предел PostgreSQL, идентификаторы опускаются.
+func changePayload(op string, ids []string) (string, error) {
+	payload, err := json.Marshal(urlChange{Op: op, IDs: ids})
+	if err == nil && len(payload) > maxNotifyPayload {
+		payload, err = json.Marshal(urlChange{Op: op})
+	}
+	return string(payload), err
+}
+
+// publishChange рассылает уведомление об изменении ссылок ids через
+// pg_notify в транзакции tx. PostgreSQL доставляет уведомление только при
+// фиксации tx, поэтому оно уходит тогда и только тогда, когда сохранено
+// само изменение.
+func publishChange(ctx context.Context, tx *sql.Tx, op string, ids []string) error {
+	payload, err := changePayload(op, ids)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "SELECT pg_notify($1, $2);", urlChangesChannel, payload)
+	return wrapDBError(err)
+}
+
+// createdShortURLs возвращает идентификаторы созданных элементов пакета.
+func createdShortURLs(results []BatchResult) []string {
+	var ids []string
+	for _, result := range results {
+		if result.Status == BatchCreated {
+			ids = append(ids, result.ShortURL)
+		}
+	}
+	return ids
+}
+
+// Invalidator сбрасывает записи кеша ссылок. Реализуется CachedStorage.
+type Invalidator interface {
+	// Invalidate сбрасывает записи для коротких идентификаторов shortURLs.
+	Invalidate(shortURLs ...string)
+	// Purge сбрасывает все записи.
+	Purge()
+}
+
+// notifyConn — соединение, получающее уведомления PostgreSQL.
+type notifyConn interface {
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// ChangeListener подписывается на уведомления DBStorage об изменении ссылок
+// и сбрасывает соответствующие записи локального кеша. Так создание и
+// удаление ссылки на одном экземпляре сервиса сразу видны остальным.
+type ChangeListener struct {
+	target     Invalidator
+	connect    func(ctx context.Context) (notifyConn, error)
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewChangeListener создаёт подписчика на уведомления базы dsn, сбрасывающего записи target.
+func NewChangeListener(dsn string, target Invalidator) *ChangeListener {
+	return &ChangeListener{
+		target: target,
+		connect: func(ctx context.Context) (notifyConn, error) {
+			conn, err := pgx.Connect(ctx, dsn)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := conn.Exec(ctx, "LISTEN "+urlChangesChannel+";"); err != nil {
+				conn.Close(context.Background())
+				return nil, err
+			}
+			return conn, nil
+		},
+		minBackoff: listenMinBackoff,
+		maxBackoff: listenMaxBackoff,
+	}
+}
+
+// Run получает уведомления до отмены ctx. При потере соединения
+// подключается повторно с экспоненциальной задержкой и сбрасывает кеш
+// целиком: уведомления, отправленные без подписки, потеряны.
+func (l *ChangeListener) Run(ctx context.Context) {
+	backoff := l.minBackoff
+	for {
+		conn, err := l.connect(ctx)
+		if err == nil {
+			l.target.Purge()
+			backoff = l.minBackoff
+			err = l.listen(ctx, conn)
+			conn.Close(context.Background())
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Sugar.Warnf("URL change listener disconnected, retrying in %s: %v", backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, l.maxBackoff)
+	}
+}
+
+// listen обрабатывает уведомления соединения conn до ошибки.
+func (l *ChangeListener) listen(ctx context.Context, conn notifyConn) error {
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change urlChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			logger.Sugar.Warnf("Malformed URL change notification %q: %v", notification.Payload, err)
+			l.target.Purge()
+			continue
+		}
+		if len(change.IDs) == 0 {
+			l.target.Purge()
+		} else {
+			l.target.Invalidate(change.IDs...)
+		}
+	}
+}
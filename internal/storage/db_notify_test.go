@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+func TestPublishChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\);`).
+		WithArgs(urlChangesChannel, `{"op":"delete","ids":["a1","a2"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	// Слишком длинный список заменяется сбросом всего кеша.
+	long := strings.Split(strings.Repeat("abcdefgh,", 1000), ",")
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"delete"}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	// Ошибка рассылки возвращается, чтобы изменение откатилось вместе с ней.
+	mock.ExpectExec(`SELECT pg_notify`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, publishChange(ctx, tx, changeDelete, []string{"a1", "a2"}))
+	require.NoError(t, publishChange(ctx, tx, changeDelete, long))
+	assert.Error(t, publishChange(ctx, tx, changeEnable, []string{"a1"}))
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// fakeNotifyConn выдаёт уведомления из канала, а после его закрытия — ошибку.
+type fakeNotifyConn struct {
+	notifications chan string
+	closed        chan struct{}
+}
+
+func (c *fakeNotifyConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	select {
+	case payload, ok := <-c.notifications:
+		if !ok {
+			return nil, errors.New("connection lost")
+		}
+		return &pgconn.Notification{Channel: urlChangesChannel, Payload: payload}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *fakeNotifyConn) Close(ctx context.Context) error {
+	close(c.closed)
+	return nil
+}
+
+// recordingInvalidator запоминает сброшенные идентификаторы, "*" — сброс всего кеша.
+type recordingInvalidator struct {
+	mu    sync.Mutex
+	calls []string
+	seen  chan struct{}
+}
+
+func (r *recordingInvalidator) Invalidate(shortURLs ...string) {
+	r.record(strings.Join(shortURLs, ","))
+}
+
+func (r *recordingInvalidator) Purge() {
+	r.record("*")
+}
+
+func (r *recordingInvalidator) record(call string) {
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+	r.seen <- struct{}{}
+}
+
+func TestChangeListener(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	target := &recordingInvalidator{seen: make(chan struct{}, 16)}
+	first := &fakeNotifyConn{notifications: make(chan string, 4), closed: make(chan struct{})}
+	second := &fakeNotifyConn{notifications: make(chan string), closed: make(chan struct{})}
+	refused := errors.New("connection refused")
+
+	// Первое подключение и первое переподключение не удаются.
+	results := []struct {
+		conn notifyConn
+		err  error
+	}{{nil, refused}, {first, nil}, {nil, refused}, {second, nil}}
+	var attempts []time.Time
+	listener := &ChangeListener{
+		target: target,
+		connect: func(ctx context.Context) (notifyConn, error) {
+			attempts = append(attempts, time.Now())
+			result := results[0]
+			results = results[1:]
+			return result.conn, result.err
+		},
+		minBackoff: 20 * time.Millisecond,
+		maxBackoff: 30 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		listener.Run(ctx)
+		close(done)
+	}()
+
+	waitCalls := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case <-target.seen:
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for cache invalidation")
+			}
+		}
+	}
+
+	first.notifications <- `{"op":"delete","ids":["a1","a2"]}`
+	first.notifications <- `{"op":"update"}`
+	first.notifications <- `not json`
+	close(first.notifications)
+	// Сброс после подключения, три уведомления, сброс после переподключения.
+	waitCalls(5)
+	<-first.closed
+
+	cancel()
+	<-done
+	<-second.closed
+	assert.Equal(t, []string{"*", "a1,a2", "*", "*", "*"}, target.calls)
+
+	// Задержка растёт после неудачных попыток и сбрасывается после успешного подключения.
+	require.Len(t, attempts, 4)
+	assert.GreaterOrEqual(t, attempts[1].Sub(attempts[0]), 20*time.Millisecond)
+	assert.GreaterOrEqual(t, attempts[2].Sub(attempts[1]), 20*time.Millisecond)
+	assert.GreaterOrEqual(t, attempts[3].Sub(attempts[2]), 30*time.Millisecond)
+}
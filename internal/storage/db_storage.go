@@ -1,9 +1,13 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"net"
+	"time"
 
 	"github.com/mi4r/go-url-shortener/internal/logger"
 
@@ -15,7 +19,8 @@ import (
 
 // DBStorage представляет реализацию хранилища URL на основе базы данных.
 type DBStorage struct {
-	Database   *sql.DB // Соединение с базой данных.
+	Database   *sql.DB   // Соединение с базой данных.
+	dedup      DedupMode // Режим дедупликации оригинальных URL.
 	statements struct {
 		save   *sql.Stmt
 		get    *sql.Stmt
@@ -23,71 +28,110 @@ type DBStorage struct {
 	}
 }
 
-// MigrateDB выполняет миграции базы данных, удаляя дубликаты URL и добавляя уникальный индекс.
+// wrapDBError приводит ошибки драйвера к ошибкам хранилища: отсутствие строк
+// становится ErrNotFound, а проблемы соединения и нехватка ресурсов сервера — ErrUnavailable.
+func wrapDBError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case isUnavailable(err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	default:
+		return err
+	}
+}
+
+// isUnavailable сообщает, вызвана ли ошибка временной недоступностью базы данных.
+func isUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgerrcode.IsConnectionException(pgErr.Code) ||
+			pgerrcode.IsInsufficientResources(pgErr.Code) ||
+			pgerrcode.IsOperatorIntervention(pgErr.Code)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// MigrateDB применяет к базе данных все неприменённые встроенные миграции.
 func MigrateDB(db *sql.DB) error {
-	// Удаление дубликатов
-	_, err := db.Exec(`
-        DELETE FROM urls
-        WHERE id NOT IN (
-            SELECT MIN(id)
-            FROM urls
-            GROUP BY original_url
-        );
-    `)
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
 	if err != nil {
 		return err
 	}
+	if applied > 0 {
+		logger.Sugar.Infof("Applied %d database migrations", applied)
+	}
+	return nil
+}
 
-	// Добавление уникального индекса
-	_, err = db.Exec(`
-        CREATE UNIQUE INDEX IF NOT EXISTS unique_original_url_idx
-        ON urls (original_url);
-    `)
-	return err
+// urlColumns — столбцы urls, из которых scanURL собирает URL.
+const urlColumns = "correlation_id, short_url, original_url, user_id, is_deleted, expires_at, created_at, updated_at, deleted_at"
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-// NewDBStorage создает новое хранилище URL на основе базы данных.
-func NewDBStorage(dsn string) (*DBStorage, error) {
-	db, err := sql.Open("pgx", dsn)
+// scanURL читает URL из строки со столбцами urlColumns.
+func scanURL(row rowScanner) (URL, error) {
+	var (
+		url                  URL
+		userID               sql.NullString
+		expiresAt, deletedAt sql.NullTime
+	)
+	err := row.Scan(&url.CorrelationID, &url.ShortURL, &url.OriginalURL, &userID,
+		&url.DeletedFlag, &expiresAt, &url.CreatedAt, &url.UpdatedAt, &deletedAt)
 	if err != nil {
-		return nil, err
+		return URL{}, err
 	}
+	url.UserID = userID.String
+	url.ExpiresAt = expiresAt.Time
+	url.DeletedAt = deletedAt.Time
+	return url, nil
+}
 
-	query := `
-    CREATE TABLE IF NOT EXISTS urls (
-        id SERIAL PRIMARY KEY,
-        correlation_id VARCHAR(255) NOT NULL,
-        short_url VARCHAR(255) NOT NULL UNIQUE,
-        original_url TEXT NOT NULL UNIQUE,
-		user_id VARCHAR(255),
-		is_deleted BOOLEAN DEFAULT FALSE
-    );
-	`
-
-	_, err = db.Exec(query)
+// NewDBStorage создает новое хранилище URL на основе базы данных.
+func NewDBStorage(dsn string, opts ...Option) (*DBStorage, error) {
+	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, err
 	}
+
 	err = MigrateDB(db)
 	if err != nil {
 		return nil, err
 	}
 
 	// Инициализация подготовленного запроса
-	saveStmt, err := db.Prepare("INSERT INTO urls (correlation_id, short_url, original_url, user_id) VALUES ($1, $2, $3, $4);")
+	saveStmt, err := db.Prepare("INSERT INTO urls (correlation_id, short_url, original_url, user_id, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7);")
 	if err != nil {
 		return nil, err
 	}
-	deleteStmt, err := db.Prepare(`UPDATE urls SET is_deleted = TRUE WHERE user_id = $1 AND short_url = ANY($2);`)
+	deleteStmt, err := db.Prepare(`UPDATE urls SET is_deleted = TRUE, deleted_at = now(), updated_at = now() WHERE user_id = $1 AND short_url = ANY($2) AND NOT is_deleted;`)
 	if err != nil {
 		return nil, err
 	}
-	getStmt, err := db.Prepare("SELECT correlation_id, short_url, original_url, is_deleted FROM urls WHERE short_url = $1;")
+	getStmt, err := db.Prepare("SELECT " + urlColumns + " FROM urls WHERE short_url = $1;")
 	if err != nil {
 		return nil, err
 	}
 
-	storage := &DBStorage{Database: db}
+	storage := &DBStorage{Database: db, dedup: newOptions(opts).dedup}
 	storage.statements.save = saveStmt
 	storage.statements.delete = deleteStmt
 	storage.statements.get = getStmt
@@ -95,108 +139,255 @@ func NewDBStorage(dsn string) (*DBStorage, error) {
 	return storage, nil
 }
 
-// Save сохраняет URL в базе данных и возвращает существующий короткий URL, если оригинальный уже существует.
-func (s *DBStorage) Save(url URL) (string, error) {
-	_, err := s.statements.save.Exec(url.CorrelationID, url.ShortURL, url.OriginalURL, url.UserID)
+// Save сохраняет URL в базе данных. Если оригинальный URL уже сохранён в
+// области дедупликации, возвращает его короткий идентификатор и ErrConflict,
+// если занят короткий идентификатор — ErrAliasTaken.
+func (s *DBStorage) Save(ctx context.Context, url URL) (string, error) {
+	url.stampCreated(time.Now())
+
+	tx, err := s.Database.BeginTx(ctx, nil)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			var existingURL string
-			queryErr := s.Database.QueryRow("SELECT short_url FROM urls WHERE original_url = $1;", url.OriginalURL).Scan(&existingURL)
-			if queryErr != nil {
-				return "", queryErr
-			}
-			return existingURL, nil
-		}
+		return "", wrapDBError(err)
+	}
+	defer tx.Rollback()
+
+	existingURL, err := s.findDuplicate(ctx, tx, url)
+	if err != nil {
+		return "", err
+	}
+	if existingURL != "" {
+		return existingURL, ErrConflict
+	}
+
+	stmt := tx.StmtContext(ctx, s.statements.save)
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, url.CorrelationID, url.ShortURL, url.OriginalURL, url.UserID,
+		nullTime(url.ExpiresAt), url.CreatedAt, url.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return "", ErrAliasTaken
+	}
+	if err != nil {
+		return "", wrapDBError(err)
+	}
+	if err := publishChange(ctx, tx, changeCreate, []string{url.ShortURL}); err != nil {
 		return "", err
 	}
+	if err := tx.Commit(); err != nil {
+		return "", wrapDBError(err)
+	}
 
 	logger.Sugar.Infof("сохранен Save.url: %v", url)
 
 	return "", nil
 }
 
-// SaveBatch сохраняет пакет URL в базе данных и возвращает список коротких идентификаторов.
-func (s *DBStorage) SaveBatch(urls []URL) ([]string, error) {
-	tx, err := s.Database.Begin()
+// SaveBatch сохраняет пакет URL в базе данных в одной транзакции и возвращает
+// результат для каждого элемента. Вставка выполняется с ON CONFLICT DO NOTHING,
+// поэтому занятый алиас отклоняет только свой элемент, а не весь пакет.
+// Дубликаты действующих записей и предыдущих элементов пакета не сохраняются.
+func (s *DBStorage) SaveBatch(ctx context.Context, urls []URL) ([]BatchResult, error) {
+	tx, err := s.Database.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 	defer tx.Rollback()
 
-	stmt := s.statements.save
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO urls (correlation_id, short_url, original_url, user_id, expires_at, created_at, updated_at) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING RETURNING short_url;")
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
 	defer stmt.Close()
 
-	ids := make([]string, 0, len(urls))
+	now := time.Now()
+	results := make([]BatchResult, len(urls))
+
+	for i, url := range urls {
+		results[i].CorrelationID = url.CorrelationID
 
-	for _, url := range urls {
-		var shortID string
+		// Вставленные ранее элементы пакета видны в той же транзакции.
+		existingURL, err := s.findDuplicate(ctx, tx, url)
+		if err != nil {
+			return nil, err
+		}
+		if existingURL != "" {
+			results[i].ShortURL = existingURL
+			results[i].Status = BatchExisting
+			continue
+		}
+
+		url.stampCreated(now)
 		for {
-			shortID = generateShortID()
-			if err := checkUniqueShortID(tx, shortID); err == nil {
+			shortID := url.ShortURL
+			if shortID == "" {
+				shortID = generateShortID()
+			}
+			err := stmt.QueryRowContext(ctx, url.CorrelationID, shortID, url.OriginalURL, url.UserID,
+				nullTime(url.ExpiresAt), url.CreatedAt, url.UpdatedAt).Scan(&shortID)
+			if errors.Is(err, sql.ErrNoRows) {
+				if url.ShortURL == "" {
+					// Сгенерированный идентификатор занят, пробуем другой.
+					continue
+				}
+				results[i].Status = BatchInvalid
+				results[i].Err = ErrAliasTaken
 				break
 			}
+			if err != nil {
+				return nil, wrapDBError(err)
+			}
+			results[i].ShortURL = shortID
+			results[i].Status = BatchCreated
+			logger.Sugar.Infof("сохранен SaveBatch.url:%v c shortID: %s", url, shortID)
+			break
 		}
+	}
 
-		if _, err := stmt.Exec(url.CorrelationID, shortID, url.OriginalURL, url.UserID); err != nil {
+	if created := createdShortURLs(results); len(created) > 0 {
+		if err := publishChange(ctx, tx, changeCreate, created); err != nil {
 			return nil, err
 		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, wrapDBError(err)
+	}
 
-		logger.Sugar.Infof("сохранен SaveBatch.url:%v c shortID: %s", url, shortID)
+	return results, nil
+}
 
-		ids = append(ids, shortID)
+// findDuplicate возвращает короткий идентификатор действующей записи с тем же
+// оригинальным URL в области дедупликации или пустую строку. Блокировка по
+// ключу дедупликации удерживается до конца транзакции tx, поэтому параллельное
+// сокращение одного URL не создаёт дубликатов.
+func (s *DBStorage) findDuplicate(ctx context.Context, tx *sql.Tx, url URL) (string, error) {
+	key, ok := s.dedup.key(url)
+	if !ok {
+		return "", nil
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0));", key); err != nil {
+		return "", wrapDBError(err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	query := "SELECT short_url FROM urls WHERE original_url = $1 AND NOT is_deleted AND (expires_at IS NULL OR expires_at > now())"
+	args := []any{url.OriginalURL}
+	if s.dedup == DedupPerUser {
+		query += " AND user_id = $2"
+		args = append(args, url.UserID)
 	}
+	query += " ORDER BY id LIMIT 1;"
 
-	return ids, nil
+	var shortID string
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&shortID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", wrapDBError(err)
+	}
+	return shortID, nil
 }
 
-// Get возвращает URL, связанный с заданным коротким идентификатором, и флаг существования.
-func (s *DBStorage) Get(shortURL string) (URL, bool) {
-	var url URL
-	err := s.statements.get.QueryRow(shortURL).Scan(&url.CorrelationID, &url.ShortURL, &url.OriginalURL, &url.DeletedFlag)
-
+// Get возвращает URL, связанный с заданным коротким идентификатором.
+func (s *DBStorage) Get(ctx context.Context, shortURL string) (URL, error) {
+	url, err := scanURL(s.statements.get.QueryRowContext(ctx, shortURL))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return url, false
-		}
-		return url, false
+		return URL{}, wrapDBError(err)
+	}
+	if url.DeletedFlag {
+		return url, ErrGone
 	}
-	return url, true
+	return url, nil
 }
 
 // GetURLsByUserID возвращает все URL, связанные с заданным идентификатором пользователя.
-func (s *DBStorage) GetURLsByUserID(userID string) ([]URL, error) {
-	rows, err := s.Database.Query("SELECT short_url, original_url FROM urls WHERE user_id = $1;", userID)
+func (s *DBStorage) GetURLsByUserID(ctx context.Context, userID string) ([]URL, error) {
+	rows, err := s.Database.QueryContext(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE user_id = $1 ORDER BY created_at, short_url;", userID)
 	if err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 	defer rows.Close()
 
 	var urls []URL
 	for rows.Next() {
-		var url URL
-		if err := rows.Scan(&url.ShortURL, &url.OriginalURL); err != nil {
-			return nil, err
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, wrapDBError(err)
 		}
 		urls = append(urls, url)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, wrapDBError(err)
 	}
 
 	return urls, nil
 }
 
+// ListURLsByUserID возвращает страницу URL пользователя. Страницы выбираются
+// по ключу (created_at, short_url), поэтому стоимость запроса не зависит от
+// номера страницы.
+func (s *DBStorage) ListURLsByUserID(ctx context.Context, userID string, query ListQuery) (URLPage, error) {
+	after, hasCursor, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return URLPage{}, err
+	}
+
+	sqlQuery := "SELECT " + urlColumns + " FROM urls WHERE user_id = $1"
+	args := []any{userID}
+	switch query.State {
+	case StateActive:
+		sqlQuery += " AND NOT is_deleted"
+	case StateDeleted:
+		sqlQuery += " AND is_deleted"
+	}
+	if query.Search != "" {
+		args = append(args, query.Search)
+		sqlQuery += fmt.Sprintf(" AND strpos(lower(original_url), lower($%d)) > 0", len(args))
+	}
+	cmp, order := ">", "ASC"
+	if query.Desc {
+		cmp, order = "<", "DESC"
+	}
+	if hasCursor {
+		args = append(args, after.time(), after.shortURL)
+		sqlQuery += fmt.Sprintf(" AND (created_at, short_url) %s ($%d, $%d)", cmp, len(args)-1, len(args))
+	}
+	sqlQuery += fmt.Sprintf(" ORDER BY created_at %s, short_url %s", order, order)
+	if query.Limit > 0 {
+		// Лишняя запись показывает, что за страницей есть продолжение.
+		args = append(args, query.Limit+1)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.Database.QueryContext(ctx, sqlQuery+";", args...)
+	if err != nil {
+		return URLPage{}, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	var urls []URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return URLPage{}, wrapDBError(err)
+		}
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return URLPage{}, wrapDBError(err)
+	}
+
+	return newURLPage(urls, query.Limit), nil
+}
+
 // GetNextID возвращает следующий уникальный идентификатор для новой записи.
-func (s *DBStorage) GetNextID() (int, error) {
+func (s *DBStorage) GetNextID(ctx context.Context) (int, error) {
 	var nextID int
-	err := s.Database.QueryRow("SELECT COALESCE(MAX(id), 0) + 1 FROM urls;").Scan(&nextID)
-	return nextID, err
+	err := s.Database.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) + 1 FROM urls;").Scan(&nextID)
+	return nextID, wrapDBError(err)
 }
 
 // Close закрывает соединение с базой данных.
@@ -207,51 +398,341 @@ func (s *DBStorage) Close() error {
 	if s.statements.delete != nil {
 		_ = s.statements.delete.Close()
 	}
+	if s.statements.get != nil {
+		_ = s.statements.get.Close()
+	}
 	return s.Database.Close()
 }
 
 // Ping проверяет доступность соединения с базой данных.
-func (s *DBStorage) Ping() error {
-	return s.Database.Ping()
-}
-
-func checkUniqueShortID(tx *sql.Tx, shortID string) error {
-	var exists bool
-	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM urls WHERE short_url = $1);", shortID).Scan(&exists)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return fmt.Errorf("duplicate key value violates unique constraint")
+// Любая ошибка проверки означает недоступность хранилища.
+func (s *DBStorage) Ping(ctx context.Context) error {
+	if err := s.Database.PingContext(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
 	}
 	return nil
 }
 
 // MarkURLsAsDeleted помечает список URL как удаленные для указанного пользователя.
-func (s *DBStorage) MarkURLsAsDeleted(userID string, shortIDs []string) error {
-	_, err := s.statements.delete.Exec(userID, shortIDs)
+// Если часть идентификаторов принадлежит другим пользователям, возвращает ErrForbidden.
+func (s *DBStorage) MarkURLsAsDeleted(ctx context.Context, userID string, shortIDs []string) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		stmt := tx.StmtContext(ctx, s.statements.delete)
+		defer stmt.Close()
+
+		res, err := stmt.ExecContext(ctx, userID, shortIDs)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			return publishChange(ctx, tx, changeDelete, shortIDs)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+
+	var foreign bool
+	err = s.Database.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM urls WHERE short_url = ANY($2) AND user_id IS DISTINCT FROM $1);",
+		userID, shortIDs,
+	).Scan(&foreign)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if foreign {
+		return ErrForbidden
+	}
 	return nil
 }
 
 // URLCount возвращает число всех загруженных URL
-func (s *DBStorage) URLCount() (int, error) {
+func (s *DBStorage) URLCount(ctx context.Context) (int, error) {
 	var cnt int
-	err := s.Database.QueryRow(`SELECT COUNT(*) FROM urls;`).Scan(&cnt)
+	err := s.Database.QueryRowContext(ctx, `SELECT COUNT(*) FROM urls;`).Scan(&cnt)
 	if err != nil {
-		return 0, err
+		return 0, wrapDBError(err)
 	}
 	return cnt, nil
 }
 
 // UserCount возвращает количество пользователей в хранилище
-func (s *DBStorage) UserCount() (int, error) {
+func (s *DBStorage) UserCount(ctx context.Context) (int, error) {
 	var cnt int
-	err := s.Database.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM urls;`).Scan(&cnt)
+	err := s.Database.QueryRowContext(ctx, `SELECT COUNT(DISTINCT user_id) FROM urls;`).Scan(&cnt)
 	if err != nil {
-		return 0, err
+		return 0, wrapDBError(err)
 	}
 	return cnt, nil
 }
+
+// DeleteExpired помечает удалёнными URL, срок действия которых истёк к моменту now.
+func (s *DBStorage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	var deleted []string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		deleted, err = queryShortURLs(ctx, tx, `UPDATE urls SET is_deleted = TRUE, deleted_at = $1, updated_at = $1
+		WHERE expires_at IS NOT NULL AND expires_at <= $1 AND NOT is_deleted RETURNING short_url;`, now)
+		if err != nil || len(deleted) == 0 {
+			return err
+		}
+		return publishChange(ctx, tx, changeDelete, deleted)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(deleted), nil
+}
+
+// SetURLDeleted помечает ссылку удалённой или восстанавливает её.
+func (s *DBStorage) SetURLDeleted(ctx context.Context, shortURL string, deleted bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE urls SET
+		updated_at = CASE WHEN is_deleted <> $2 THEN now() ELSE updated_at END,
+		deleted_at = CASE WHEN $2 THEN COALESCE(deleted_at, now()) END,
+		is_deleted = $2
+		WHERE short_url = $1;`, shortURL, deleted)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return wrapDBError(err)
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		op := changeEnable
+		if deleted {
+			op = changeDisable
+		}
+		return publishChange(ctx, tx, op, []string{shortURL})
+	})
+}
+
+// ReassignURLs передаёт ссылки пользователя from пользователю to.
+func (s *DBStorage) ReassignURLs(ctx context.Context, from, to string, shortURLs []string) (int, error) {
+	query := `UPDATE urls SET user_id = $2, updated_at = now() WHERE user_id = $1`
+	args := []any{from, to}
+	if len(shortURLs) > 0 {
+		query += ` AND short_url = ANY($3)`
+		args = append(args, shortURLs)
+	}
+	var moved []string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		moved, err = queryShortURLs(ctx, tx, query+" RETURNING short_url;", args...)
+		if err != nil || len(moved) == 0 {
+			return err
+		}
+		return publishChange(ctx, tx, changeUpdate, moved)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(moved), nil
+}
+
+// PurgeDeleted удаляет ссылки, помеченные удалёнными раньше before.
+func (s *DBStorage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	var purged []string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		purged, err = queryShortURLs(ctx, tx,
+			`DELETE FROM urls WHERE is_deleted AND (deleted_at IS NULL OR deleted_at < $1) RETURNING short_url;`, before)
+		if err != nil || len(purged) == 0 {
+			return err
+		}
+		return publishChange(ctx, tx, changeDelete, purged)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// inTx выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку.
+func (s *DBStorage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return wrapDBError(tx.Commit())
+}
+
+// queryShortURLs выполняет в tx запрос, возвращающий столбец short_url, и собирает его значения.
+func queryShortURLs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, wrapDBError(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+	return ids, nil
+}
+
+// nullTime преобразует нулевое время в NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// SaveClicks сохраняет пакет переходов в одной транзакции.
+func (s *DBStorage) SaveClicks(ctx context.Context, clicks []Click) error {
+	tx, err := s.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO clicks (short_url, clicked_at, referrer, user_agent, country) VALUES ($1, $2, $3, $4, $5);`)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer stmt.Close()
+
+	for _, c := range clicks {
+		if _, err := stmt.ExecContext(ctx, c.ShortURL, c.ClickedAt, c.Referrer, c.UserAgent, c.Country); err != nil {
+			return wrapDBError(err)
+		}
+	}
+
+	return wrapDBError(tx.Commit())
+}
+
+// GetLinkStats возвращает статистику переходов по короткой ссылке.
+func (s *DBStorage) GetLinkStats(ctx context.Context, shortURL string) (LinkStats, error) {
+	stats := NewLinkStats(shortURL)
+
+	var first, last sql.NullTime
+	err := s.Database.QueryRowContext(ctx,
+		`SELECT COUNT(*), MIN(clicked_at), MAX(clicked_at) FROM clicks WHERE short_url = $1;`, shortURL,
+	).Scan(&stats.Clicks, &first, &last)
+	if err != nil {
+		return LinkStats{}, wrapDBError(err)
+	}
+	stats.FirstClick = first.Time
+	stats.LastClick = last.Time
+
+	breakdowns := []struct {
+		column string
+		into   map[string]int
+	}{
+		{"referrer", stats.Referrers},
+		{"user_agent", stats.UserAgents},
+		{"country", stats.Countries},
+	}
+	for _, b := range breakdowns {
+		if err := s.countClicksBy(ctx, shortURL, b.column, b.into); err != nil {
+			return LinkStats{}, err
+		}
+	}
+
+	return stats, nil
+}
+
+// countClicksBy заполняет into числом переходов по значениям столбца column.
+// column подставляется в запрос напрямую и должен быть константой.
+func (s *DBStorage) countClicksBy(ctx context.Context, shortURL, column string, into map[string]int) error {
+	rows, err := s.Database.QueryContext(ctx,
+		`SELECT `+column+`, COUNT(*) FROM clicks WHERE short_url = $1 AND `+column+` <> '' GROUP BY `+column+`;`, shortURL)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			key   string
+			count int
+		)
+		if err := rows.Scan(&key, &count); err != nil {
+			return wrapDBError(err)
+		}
+		into[key] = count
+	}
+	return wrapDBError(rows.Err())
+}
+
+// SaveAPIKey сохраняет новый API-ключ.
+func (s *DBStorage) SaveAPIKey(ctx context.Context, key APIKey) error {
+	_, err := s.Database.ExecContext(ctx,
+		`INSERT INTO api_keys (id, user_id, name, key_hash, created_at) VALUES ($1, $2, $3, $4, $5);`,
+		key.ID, key.UserID, key.Name, key.Hash, key.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return ErrConflict
+	}
+	return wrapDBError(err)
+}
+
+// GetAPIKeyByHash возвращает API-ключ по хешу.
+func (s *DBStorage) GetAPIKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	var (
+		key       APIKey
+		revokedAt sql.NullTime
+	)
+	err := s.Database.QueryRowContext(ctx,
+		`SELECT id, user_id, name, key_hash, created_at, revoked_at FROM api_keys WHERE key_hash = $1;`, hash,
+	).Scan(&key.ID, &key.UserID, &key.Name, &key.Hash, &key.CreatedAt, &revokedAt)
+	if err != nil {
+		return APIKey{}, wrapDBError(err)
+	}
+	key.RevokedAt = revokedAt.Time
+	return key, nil
+}
+
+// ListAPIKeys возвращает API-ключи пользователя.
+func (s *DBStorage) ListAPIKeys(ctx context.Context, userID string) ([]APIKey, error) {
+	rows, err := s.Database.QueryContext(ctx,
+		`SELECT id, user_id, name, key_hash, created_at, revoked_at FROM api_keys WHERE user_id = $1 ORDER BY created_at, id;`, userID)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var (
+			key       APIKey
+			revokedAt sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Hash, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, wrapDBError(err)
+		}
+		key.RevokedAt = revokedAt.Time
+		keys = append(keys, key)
+	}
+	return keys, wrapDBError(rows.Err())
+}
+
+// RevokeAPIKey отзывает API-ключ пользователя.
+func (s *DBStorage) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	var owner string
+	err := s.Database.QueryRowContext(ctx, `SELECT user_id FROM api_keys WHERE id = $1;`, id).Scan(&owner)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if owner != userID {
+		return ErrForbidden
+	}
+	_, err = s.Database.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL;`, id)
+	return wrapDBError(err)
+}
@@ -1,11 +1,16 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/mi4r/go-url-shortener/internal/logger"
 	"github.com/stretchr/testify/require"
@@ -22,7 +27,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 	if err := db.Ping(); err != nil {
 		t.Skipf("Skipping test due to database connection error: %v", err)
 	}
-	_, err = db.Exec(`DROP TABLE IF EXISTS urls;`)
+	_, err = db.Exec(`DROP TABLE IF EXISTS urls, clicks, api_keys, schema_migrations;`)
 	if err != nil {
 		t.Fatalf("failed to clean test database: %v", err)
 	}
@@ -49,7 +54,7 @@ func TestDBStorage(t *testing.T) {
 	defer db.Close()
 
 	storage, err := NewDBStorage(testDSN)
-	if err := storage.Ping(); err != nil {
+	if err := storage.Ping(context.Background()); err != nil {
 		t.Skipf("Skipping test due to database connection error: %v", err)
 	}
 	if err != nil {
@@ -65,14 +70,14 @@ func TestDBStorage(t *testing.T) {
 			UserID:        "user1",
 		}
 
-		_, err := storage.Save(url)
+		_, err := storage.Save(context.Background(), url)
 		if err != nil {
 			t.Errorf("Save failed: %v", err)
 		}
 
-		savedURL, exists := storage.Get("short1")
-		if !exists {
-			t.Errorf("expected URL to exist")
+		savedURL, err := storage.Get(context.Background(), "short1")
+		if err != nil {
+			t.Errorf("expected URL to exist: %v", err)
 		}
 		if savedURL.OriginalURL != url.OriginalURL {
 			t.Errorf("expected %s, got %s", url.OriginalURL, savedURL.OriginalURL)
@@ -85,7 +90,7 @@ func TestDBStorage(t *testing.T) {
 			{CorrelationID: "3", OriginalURL: "https://example3.com", UserID: "user1"},
 		}
 
-		ids, err := storage.SaveBatch(urls)
+		ids, err := storage.SaveBatch(context.Background(), urls)
 		if err != nil {
 			t.Errorf("SaveBatch failed: %v", err)
 		}
@@ -96,9 +101,9 @@ func TestDBStorage(t *testing.T) {
 	})
 
 	t.Run("Get", func(t *testing.T) {
-		url, exists := storage.Get("short1")
-		if !exists {
-			t.Errorf("Get failed: expected URL to exist")
+		url, err := storage.Get(context.Background(), "short1")
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
 		}
 		if url.OriginalURL != "https://example.com" {
 			t.Errorf("expected %s, got %s", "https://example.com", url.OriginalURL)
@@ -106,7 +111,7 @@ func TestDBStorage(t *testing.T) {
 	})
 
 	t.Run("GetURLsByUserID", func(t *testing.T) {
-		urls, err := storage.GetURLsByUserID("user1")
+		urls, err := storage.GetURLsByUserID(context.Background(), "user1")
 		if err != nil {
 			t.Errorf("GetURLsByUserID failed: %v", err)
 		}
@@ -116,7 +121,7 @@ func TestDBStorage(t *testing.T) {
 	})
 
 	t.Run("GetNextID", func(t *testing.T) {
-		nextID, err := storage.GetNextID()
+		nextID, err := storage.GetNextID(context.Background())
 		if err != nil {
 			t.Errorf("GetNextID failed: %v", err)
 		}
@@ -126,24 +131,24 @@ func TestDBStorage(t *testing.T) {
 	})
 
 	t.Run("MarkURLsAsDeleted", func(t *testing.T) {
-		err := storage.MarkURLsAsDeleted("user1", []string{"short1"})
+		err := storage.MarkURLsAsDeleted(context.Background(), "user1", []string{"short1"})
 		if err != nil {
 			t.Errorf("MarkURLsAsDeleted failed: %v", err)
 		}
 
-		// err = storage.MarkURLsAsDeleted("user2", []string{"short1", "bad"})
+		// err = storage.MarkURLsAsDeleted(context.Background(), "user2", []string{"short1", "bad"})
 		// if err == nil {
 		// 	t.Errorf("MarkURLsAsDeleted should be failed")
 		// }
 
-		url, exists := storage.Get("short1")
-		if !exists || !url.DeletedFlag {
+		url, err := storage.Get(context.Background(), "short1")
+		if !errors.Is(err, ErrGone) || !url.DeletedFlag {
 			t.Errorf("expected URL to be marked as deleted")
 		}
 	})
 
 	t.Run("Ping", func(t *testing.T) {
-		err := storage.Ping()
+		err := storage.Ping(context.Background())
 		if err != nil {
 			t.Errorf("Ping failed: %v", err)
 		}
@@ -192,7 +197,7 @@ func TestDBStorage_URLCount(t *testing.T) {
 			tt.mock()
 
 			// Вызываем тестируемую функцию
-			got, err := storage.URLCount()
+			got, err := storage.URLCount(context.Background())
 
 			// Проверяем ошибку
 			if tt.wantErr {
@@ -252,7 +257,7 @@ func TestDBStorage_UserCount(t *testing.T) {
 			tt.mock()
 
 			// Вызываем тестируемую функцию
-			got, err := storage.UserCount()
+			got, err := storage.UserCount(context.Background())
 
 			// Проверяем ошибку
 			if tt.wantErr {
@@ -269,3 +274,216 @@ func TestDBStorage_UserCount(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapDBError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "no rows", err: sql.ErrNoRows, want: ErrNotFound},
+		{name: "bad connection", err: driver.ErrBadConn, want: ErrUnavailable},
+		{name: "connection exception", err: &pgconn.PgError{Code: pgerrcode.ConnectionFailure}, want: ErrUnavailable},
+		{name: "too many connections", err: &pgconn.PgError{Code: pgerrcode.TooManyConnections}, want: ErrUnavailable},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: pgerrcode.AdminShutdown}, want: ErrUnavailable},
+		{name: "syntax error", err: &pgconn.PgError{Code: pgerrcode.SyntaxError}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapDBError(tt.err)
+			if tt.want == nil {
+				require.ErrorIs(t, got, tt.err)
+				require.NotErrorIs(t, got, ErrUnavailable)
+				return
+			}
+			require.ErrorIs(t, got, tt.want)
+		})
+	}
+}
+
+func TestDBStorage_ContextDeadline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+
+	// Запрос выполняется дольше дедлайна контекста
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM urls;`).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = storage.URLCount(ctx)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestDBStorage_DeleteExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE urls SET is_deleted = TRUE, deleted_at = \$1, updated_at = \$1\s+WHERE expires_at IS NOT NULL AND expires_at <= \$1 AND NOT is_deleted RETURNING short_url;`).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("a1").AddRow("a2").AddRow("a3"))
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"delete","ids":["a1","a2","a3"]}`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	deleted, err := storage.DeleteExpired(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_SaveClicks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(`INSERT INTO clicks`)
+	prep.ExpectExec().WithArgs("abc", now, "https://ya.ru", "curl", "RU").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = storage.SaveClicks(context.Background(), []Click{
+		{ShortURL: "abc", ClickedAt: now, Referrer: "https://ya.ru", UserAgent: "curl", Country: "RU"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_GetLinkStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+	first := time.Now().Add(-time.Hour)
+	last := time.Now()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\), MIN\(clicked_at\), MAX\(clicked_at\) FROM clicks`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "min", "max"}).AddRow(3, first, last))
+	mock.ExpectQuery(`SELECT referrer, COUNT\(\*\) FROM clicks`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"referrer", "count"}).AddRow("https://ya.ru", 2))
+	mock.ExpectQuery(`SELECT user_agent, COUNT\(\*\) FROM clicks`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"user_agent", "count"}))
+	mock.ExpectQuery(`SELECT country, COUNT\(\*\) FROM clicks`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"country", "count"}).AddRow("RU", 3))
+
+	stats, err := storage.GetLinkStats(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.Clicks)
+	require.Equal(t, first, stats.FirstClick)
+	require.Equal(t, 2, stats.Referrers["https://ya.ru"])
+	require.Equal(t, 3, stats.Countries["RU"])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_ListURLsByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"correlation_id", "short_url", "original_url", "user_id", "is_deleted", "expires_at", "created_at", "updated_at", "deleted_at"}
+
+	mock.ExpectQuery(`SELECT correlation_id, short_url, original_url, user_id, is_deleted, expires_at, created_at, updated_at, deleted_at FROM urls `+
+		`WHERE user_id = \$1 AND NOT is_deleted AND strpos\(lower\(original_url\), lower\(\$2\)\) > 0 `+
+		`ORDER BY created_at DESC, short_url DESC LIMIT \$3;`).
+		WithArgs("user1", "example", 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("2", "b", "https://example.com/b", "user1", false, nil, created, created, nil).
+			AddRow("1", "a", "https://example.com/a", "user1", false, nil, created, created, nil))
+
+	page, err := storage.ListURLsByUserID(context.Background(), "user1",
+		ListQuery{Limit: 1, Desc: true, State: StateActive, Search: "example"})
+	require.NoError(t, err)
+	require.Len(t, page.URLs, 1)
+	require.Equal(t, "b", page.URLs[0].ShortURL)
+	require.Equal(t, "2", page.URLs[0].CorrelationID)
+	require.NotEmpty(t, page.NextCursor)
+
+	mock.ExpectQuery(`WHERE user_id = \$1 AND \(created_at, short_url\) < \(\$2, \$3\) `+
+		`ORDER BY created_at DESC, short_url DESC LIMIT \$4;`).
+		WithArgs("user1", created, "b", 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("1", "a", "https://example.com/a", "user1", false, nil, created, created, nil))
+
+	page, err = storage.ListURLsByUserID(context.Background(), "user1",
+		ListQuery{Limit: 1, Desc: true, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.URLs, 1)
+	require.Empty(t, page.NextCursor)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = storage.ListURLsByUserID(context.Background(), "user1", ListQuery{Cursor: "%%%"})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestDBStorage_APIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db}
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	key := APIKey{ID: "k1", UserID: "user1", Name: "ci", Hash: "hash1", CreatedAt: created}
+
+	mock.ExpectExec(`INSERT INTO api_keys \(id, user_id, name, key_hash, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs("k1", "user1", "ci", "hash1", created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, storage.SaveAPIKey(ctx, key))
+
+	mock.ExpectExec(`INSERT INTO api_keys`).
+		WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+	require.ErrorIs(t, storage.SaveAPIKey(ctx, key), ErrConflict)
+
+	mock.ExpectQuery(`SELECT id, user_id, name, key_hash, created_at, revoked_at FROM api_keys WHERE key_hash = \$1;`).
+		WithArgs("hash1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "key_hash", "created_at", "revoked_at"}).
+			AddRow("k1", "user1", "ci", "hash1", created, nil))
+	stored, err := storage.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, key, stored)
+
+	mock.ExpectQuery(`SELECT id, user_id, name, key_hash, created_at, revoked_at FROM api_keys WHERE key_hash = \$1;`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	_, err = storage.GetAPIKeyByHash(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	mock.ExpectQuery(`SELECT user_id FROM api_keys WHERE id = \$1;`).
+		WithArgs("k1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user1"))
+	require.ErrorIs(t, storage.RevokeAPIKey(ctx, "user2", "k1"), ErrForbidden)
+
+	mock.ExpectQuery(`SELECT user_id FROM api_keys WHERE id = \$1;`).
+		WithArgs("k1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user1"))
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = now\(\) WHERE id = \$1 AND revoked_at IS NULL;`).
+		WithArgs("k1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, storage.RevokeAPIKey(ctx, "user1", "k1"))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
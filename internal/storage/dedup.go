@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// DedupMode определяет область, в которой одинаковые оригинальные URL
+// сокращаются в одну ссылку.
+type DedupMode int
+
+// Режимы дедупликации. Нулевое значение — дедупликация по пользователю.
+const (
+	// DedupPerUser возвращает пользователю его же ссылку на тот же URL;
+	// разные пользователи получают разные ссылки.
+	DedupPerUser DedupMode = iota
+	// DedupGlobal возвращает существующую ссылку на тот же URL, кем бы она ни была создана.
+	DedupGlobal
+	// DedupNone создаёт новую ссылку при каждом сокращении.
+	DedupNone
+)
+
+// String возвращает название режима, принимаемое ParseDedupMode.
+func (m DedupMode) String() string {
+	switch m {
+	case DedupPerUser:
+		return "user"
+	case DedupGlobal:
+		return "global"
+	case DedupNone:
+		return "none"
+	default:
+		return fmt.Sprintf("DedupMode(%d)", int(m))
+	}
+}
+
+// ParseDedupMode разбирает название режима: "user", "global" или "none".
+// Пустая строка означает режим по умолчанию DedupPerUser.
+func ParseDedupMode(s string) (DedupMode, error) {
+	switch s {
+	case "", "user":
+		return DedupPerUser, nil
+	case "global":
+		return DedupGlobal, nil
+	case "none":
+		return DedupNone, nil
+	default:
+		return 0, fmt.Errorf("unknown dedup mode %q, want user, global or none", s)
+	}
+}
+
+// key возвращает ключ дедупликации URL. ok равен false, если дедупликация отключена.
+func (m DedupMode) key(url URL) (key string, ok bool) {
+	switch m {
+	case DedupGlobal:
+		return url.OriginalURL, true
+	case DedupPerUser:
+		return url.UserID + "\x00" + url.OriginalURL, true
+	default:
+		return "", false
+	}
+}
+
+// active сообщает, может ли запись быть результатом дедупликации: удалённые
+// и истёкшие ссылки не переиспользуются.
+func (u URL) active(now time.Time) bool {
+	return !u.DeletedFlag && !u.Expired(now)
+}
+
+// dedupIndex находит действующую запись с тем же ключом дедупликации.
+// Нулевое значение использует режим DedupPerUser.
+type dedupIndex struct {
+	mode DedupMode
+	ids  map[string]string // Ключ дедупликации -> короткий идентификатор.
+}
+
+// find возвращает короткий идентификатор действующей записи из data с тем же
+// ключом, что у url. Устаревшие элементы индекса удаляются при обращении.
+func (d *dedupIndex) find(data map[string]URL, url URL, now time.Time) (string, bool) {
+	key, ok := d.mode.key(url)
+	if !ok {
+		return "", false
+	}
+	shortID, ok := d.ids[key]
+	if !ok {
+		return "", false
+	}
+	existing, ok := data[shortID]
+	if !ok || !existing.active(now) {
+		delete(d.ids, key)
+		return "", false
+	}
+	return shortID, true
+}
+
+// add добавляет запись в индекс, заменяя прежнюю запись с тем же ключом.
+func (d *dedupIndex) add(url URL) {
+	key, ok := d.mode.key(url)
+	if !ok {
+		return
+	}
+	if d.ids == nil {
+		d.ids = make(map[string]string)
+	}
+	d.ids[key] = url.ShortURL
+}
+
+// remove удаляет запись из индекса, если ключ указывает на неё.
+func (d *dedupIndex) remove(url URL) {
+	key, ok := d.mode.key(url)
+	if ok && d.ids[key] == url.ShortURL {
+		delete(d.ids, key)
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+func TestParseDedupMode(t *testing.T) {
+	for _, mode := range []DedupMode{DedupPerUser, DedupGlobal, DedupNone} {
+		parsed, err := ParseDedupMode(mode.String())
+		require.NoError(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+	parsed, err := ParseDedupMode("")
+	require.NoError(t, err)
+	assert.Equal(t, DedupPerUser, parsed)
+	_, err = ParseDedupMode("tenant")
+	assert.Error(t, err)
+}
+
+func TestDBStorage_SaveDedup(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectPrepare(`INSERT INTO urls`)
+	saveStmt, err := db.Prepare("INSERT INTO urls (correlation_id, short_url, original_url, user_id, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7);")
+	require.NoError(t, err)
+	storage := &DBStorage{Database: db}
+	storage.statements.save = saveStmt
+
+	url := URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://example.com", UserID: "alice",
+		CreatedAt: time.Now()}
+
+	// Ссылка пользователя на тот же URL уже есть.
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtextextended\(\$1, 0\)\);`).
+		WithArgs("alice\x00https://example.com").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT short_url FROM urls WHERE original_url = \$1 AND NOT is_deleted .* AND user_id = \$2 ORDER BY id LIMIT 1;`).
+		WithArgs("https://example.com", "alice").
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("old"))
+	mock.ExpectRollback()
+
+	existing, err := storage.Save(context.Background(), url)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "old", existing)
+
+	// В глобальном режиме ищется ссылка любого пользователя; новой ссылки нет.
+	storage.dedup = DedupGlobal
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+		WithArgs("https://example.com").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT short_url FROM urls WHERE original_url = \$1 AND NOT is_deleted AND \(expires_at IS NULL OR expires_at > now\(\)\) ORDER BY id LIMIT 1;`).
+		WithArgs("https://example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+	mock.ExpectExec(`INSERT INTO urls`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"create","ids":["a1"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	existing, err = storage.Save(context.Background(), url)
+	require.NoError(t, err)
+	assert.Empty(t, existing)
+
+	// Без дедупликации сразу выполняется вставка.
+	storage.dedup = DedupNone
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO urls`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"create","ids":["a1"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	_, err = storage.Save(context.Background(), url)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_SaveBatchOnConflict(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	storage := &DBStorage{Database: db, dedup: DedupNone}
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(`INSERT INTO urls .* ON CONFLICT DO NOTHING RETURNING short_url;`)
+	prep.ExpectQuery().WithArgs("1", "promo", "https://example.com", "alice", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+	prep.ExpectQuery().WithArgs("2", "fresh", "https://example.org", "alice", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("fresh"))
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(urlChangesChannel, `{"op":"create","ids":["fresh"]}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	results, err := storage.SaveBatch(context.Background(), []URL{
+		{CorrelationID: "1", ShortURL: "promo", OriginalURL: "https://example.com", UserID: "alice"},
+		{CorrelationID: "2", ShortURL: "fresh", OriginalURL: "https://example.org", UserID: "alice"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []BatchResult{
+		{CorrelationID: "1", Status: BatchInvalid, Err: ErrAliasTaken},
+		{CorrelationID: "2", ShortURL: "fresh", Status: BatchCreated},
+	}, results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,76 +1,132 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"sync"
+	"time"
 
 	"github.com/mi4r/go-url-shortener/internal/logger"
 )
 
-// FileStorage представляет файловое хранилище сокращённых URL.
+// FileStorage представляет файловое хранилище сокращённых URL. Ссылки хранятся
+// в памяти, а изменения дописываются в журнал (см. file_wal.go).
 type FileStorage struct {
-	filePath string              // Путь к файлу хранилища.
-	data     map[string]URL      // Карта сокращённых URL с данными.
-	userURLs map[string][]string // Карта сокращённых URL для каждого пользователя.
-	nextID   int                 // Следующий уникальный идентификатор.
+	filePath string               // Путь к файлу хранилища.
+	data     map[string]URL       // Карта сокращённых URL с данными.
+	userURLs map[string][]string  // Карта сокращённых URL для каждого пользователя.
+	nextID   int                  // Следующий уникальный идентификатор.
+	stats    map[string]LinkStats // Статистика переходов по коротким ссылкам.
+	keys     apiKeySet            // API-ключи пользователей.
+	dedup    dedupIndex           // Индекс дедупликации оригинальных URL.
+
+	log        walFile       // Журнал, открытый на дописывание; nil после Close.
+	logSize    int64         // Размер журнала после последней успешной записи.
+	logRecords int           // Число записей в журнале.
+	compactMin int           // Минимальное число записей в журнале для его сжатия.
+	sync       SyncPolicy    // Политика сброса журнала на диск.
+	unsynced   bool          // В журнале есть записи, не сброшенные на диск.
+	stopSync   chan struct{} // Закрывается в Close для остановки периодического сброса.
+
+	// mu защищает журнал, data, userURLs, nextID, stats, keys и dedup. Чтение (редиректы) берёт
+	// разделяемую блокировку и не конкурирует с другими читателями.
+	mu sync.RWMutex
 }
 
 // NewFileStorage создаёт новый экземпляр файлового хранилища и загружает данные из файла.
-func NewFileStorage(filePath string) (*FileStorage, error) {
+func NewFileStorage(filePath string, opts ...Option) (*FileStorage, error) {
+	o := newOptions(opts)
 	fs := &FileStorage{
-		filePath: filePath,
-		data:     make(map[string]URL),
-		userURLs: make(map[string][]string),
-		nextID:   1,
+		filePath:   filePath,
+		sync:       o.sync,
+		compactMin: compactMinRecords,
+		data:       make(map[string]URL),
+		userURLs:   make(map[string][]string),
+		nextID:     1,
+		stats:      make(map[string]LinkStats),
+		keys:       newAPIKeySet(),
+		dedup:      dedupIndex{mode: o.dedup},
 	}
 	err := fs.loadFromFile()
 	if err != nil {
 		return nil, err
 	}
+	if err := fs.loadClicks(); err != nil {
+		fs.log.Close()
+		return nil, err
+	}
+	if err := fs.loadAPIKeys(); err != nil {
+		fs.log.Close()
+		return nil, err
+	}
+	if fs.sync.interval > 0 {
+		fs.stopSync = make(chan struct{})
+		go fs.syncLoop(fs.sync.interval, fs.stopSync)
+	}
 	return fs, nil
 }
 
 // Save сохраняет URL в файловое хранилище.
-func (s *FileStorage) Save(url URL) (string, error) {
-	s.data[url.ShortURL] = url
-	s.userURLs[url.UserID] = append(s.userURLs[url.UserID], url.ShortURL)
-	s.nextID++
-	return "", s.saveToFile(url)
+func (s *FileStorage) Save(_ context.Context, url URL) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if shortID, ok := s.dedup.find(s.data, url, now); ok {
+		return shortID, ErrConflict
+	}
+	if _, exists := s.data[url.ShortURL]; exists {
+		return "", ErrAliasTaken
+	}
+	url.stampCreated(now)
+	return "", s.commit(walRecord{Op: walCreate, At: now, URL: &url})
 }
 
 // SaveBatch сохраняет пакет URL в файловое хранилище.
-func (s *FileStorage) SaveBatch(urls []URL) ([]string, error) {
-	ids := make([]string, 0, len(urls))
+func (s *FileStorage) SaveBatch(_ context.Context, urls []URL) ([]BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	fresh, results := planBatch(s.data, &s.dedup, urls, now)
 
-	for i := range urls {
-		shortID := generateShortID()
-		urls[i].ShortURL = shortID
-		s.data[shortID] = urls[i]
-		s.userURLs[urls[i].UserID] = append(s.userURLs[urls[i].UserID], shortID)
-		s.nextID++
-		ids = append(ids, shortID)
+	recs := make([]walRecord, len(fresh))
+	for i := range fresh {
+		fresh[i].stampCreated(now)
+		recs[i] = walRecord{Op: walCreate, At: now, URL: &fresh[i]}
 	}
 
-	if err := s.saveBatchToFile(urls); err != nil {
+	if err := s.commit(recs...); err != nil {
 		return nil, err
 	}
 
-	return ids, nil
+	return results, nil
 }
 
 // Get возвращает URL по сокращённому идентификатору.
-func (s *FileStorage) Get(shortURL string) (URL, bool) {
+func (s *FileStorage) Get(_ context.Context, shortURL string) (URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	url, exists := s.data[shortURL]
 	if !exists {
-		return URL{}, false
+		return URL{}, ErrNotFound
 	}
-	return url, true
+	if url.DeletedFlag {
+		return url, ErrGone
+	}
+	return url, nil
 }
 
 // GetURLsByUserID возвращает все URL, связанные с указанным идентификатором пользователя.
-func (s *FileStorage) GetURLsByUserID(userID string) ([]URL, error) {
+func (s *FileStorage) GetURLsByUserID(_ context.Context, userID string) ([]URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	shortURLs, exists := s.userURLs[userID]
 	if !exists || len(shortURLs) == 0 {
 		return nil, nil
@@ -86,113 +142,330 @@ func (s *FileStorage) GetURLsByUserID(userID string) ([]URL, error) {
 	return urls, nil
 }
 
+// ListURLsByUserID возвращает страницу URL пользователя.
+func (s *FileStorage) ListURLsByUserID(ctx context.Context, userID string, query ListQuery) (URLPage, error) {
+	urls, err := s.GetURLsByUserID(ctx, userID)
+	if err != nil {
+		return URLPage{}, err
+	}
+	return listURLs(urls, query)
+}
+
 // GetNextID возвращает следующий уникальный идентификатор.
-func (s *FileStorage) GetNextID() (int, error) {
+func (s *FileStorage) GetNextID(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.nextID, nil
 }
 
-// Close завершает работу файлового хранилища. Не требует особых действий.
-func (s *FileStorage) Close() error {
+// Ping проверяет доступность файла хранилища.
+func (s *FileStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(s.filePath); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
 	return nil
 }
 
-// saveToFile записывает данные URL в файл.
-func (s *FileStorage) saveToFile(url URL) error {
-	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-	if err != nil {
-		return err
+// Close останавливает периодический сброс, сбрасывает журнал на диск и закрывает его.
+func (s *FileStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return nil
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Sugar.Error(err)
+	if s.stopSync != nil {
+		close(s.stopSync)
+		s.stopSync = nil
+	}
+	err := s.log.Sync()
+	if closeErr := s.log.Close(); err == nil {
+		err = closeErr
+	}
+	s.log = nil
+	return err
+}
+
+// MarkURLsAsDeleted помечает указанные сокращённые URL как удалённые для указанного пользователя.
+func (s *FileStorage) MarkURLsAsDeleted(_ context.Context, userID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var (
+		forbidden bool
+		deleted   []string
+	)
+	for _, id := range ids {
+		url, exists := s.data[id]
+		if !exists {
+			continue
+		}
+		if url.UserID != userID {
+			forbidden = true
+			continue
 		}
-	}()
+		deleted = append(deleted, id)
+	}
+	if len(deleted) > 0 {
+		if err := s.commit(walRecord{Op: walDelete, At: now, IDs: deleted}); err != nil {
+			return err
+		}
+	}
+	if forbidden {
+		return ErrForbidden
+	}
+	return nil
+}
 
-	encoder := json.NewEncoder(file)
-	return encoder.Encode(url)
+// URLCount возвращает число всех загруженных URL
+func (s *FileStorage) URLCount(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.data), nil
+}
+
+// UserCount возвращает количество пользователей в хранилище
+func (s *FileStorage) UserCount(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.userURLs), nil
+}
+
+// DeleteExpired помечает удалёнными URL с истёкшим сроком действия и записывает изменение в журнал.
+func (s *FileStorage) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := expiredURLs(s.data, now)
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+	if err := s.commit(walRecord{Op: walDelete, At: now, IDs: deleted}); err != nil {
+		return 0, err
+	}
+	return len(deleted), nil
 }
 
-// saveBatchToFile записывает пакет URL в файл.
-func (s *FileStorage) saveBatchToFile(batch []URL) error {
-	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+// clicksFilePath возвращает путь к файлу с переходами, который хранится рядом с файлом URL.
+func (s *FileStorage) clicksFilePath() string {
+	return s.filePath + ".clicks"
+}
+
+// SaveClicks дописывает переходы в файл переходов и учитывает их в статистике.
+func (s *FileStorage) SaveClicks(_ context.Context, clicks []Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.clicksFilePath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Sugar.Error(err)
-		}
-	}()
+	defer file.Close()
 
 	encoder := json.NewEncoder(file)
-	return encoder.Encode(batch)
+	for _, c := range clicks {
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	}
+	addClicks(s.stats, clicks)
+	return nil
+}
+
+// GetLinkStats возвращает статистику переходов по короткой ссылке.
+func (s *FileStorage) GetLinkStats(_ context.Context, shortURL string) (LinkStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.stats[shortURL]
+	if !ok {
+		return NewLinkStats(shortURL), nil
+	}
+	return st.Clone(), nil
 }
 
-// loadFromFile загружает данные из файла в память.
-func (s *FileStorage) loadFromFile() error {
-	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_RDONLY, 0666)
+// loadClicks загружает переходы из файла и агрегирует их в статистику.
+func (s *FileStorage) loadClicks() error {
+	file, err := os.Open(s.clicksFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Sugar.Error(err)
-		}
-	}()
+	defer file.Close()
 
 	decoder := json.NewDecoder(file)
 	for {
-		var url URL
-		if err := decoder.Decode(&url); err != nil {
+		var c Click
+		if err := decoder.Decode(&c); err != nil {
 			if err == io.EOF {
 				break
 			}
 			return err
 		}
-		s.data[url.ShortURL] = url
-		s.userURLs[url.UserID] = append(s.userURLs[url.UserID], url.ShortURL)
-		if urlID, _ := strconv.Atoi(url.CorrelationID); urlID >= s.nextID {
-			s.nextID = urlID + 1
-		}
+		addClicks(s.stats, []Click{c})
 	}
-	logger.Sugar.Infoln(s.userURLs)
 	return nil
 }
 
-// MarkURLsAsDeleted помечает указанные сокращённые URL как удалённые для указанного пользователя.
-func (s *FileStorage) MarkURLsAsDeleted(userID string, ids []string) error {
-	for _, id := range ids {
-		if url, exists := s.data[id]; exists && url.UserID == userID {
-			url.DeletedFlag = true
-			s.data[id] = url
+// keysFilePath возвращает путь к файлу с API-ключами, который хранится рядом с файлом URL.
+func (s *FileStorage) keysFilePath() string {
+	return s.filePath + ".keys"
+}
+
+// appendAPIKey дописывает состояние ключа в файл ключей и сбрасывает его на
+// диск. При загрузке последняя запись с тем же ID заменяет предыдущие. Если
+// запись не удалась, недописанная строка отрезается.
+func (s *FileStorage) appendAPIKey(key APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.keysFilePath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		if truncErr := file.Truncate(info.Size()); truncErr != nil {
+			logger.Sugar.Errorf("%s: failed to truncate after write error: %v", s.keysFilePath(), truncErr)
 		}
+		return err
+	}
+	return nil
+}
+
+// SaveAPIKey сохраняет новый API-ключ.
+func (s *FileStorage) SaveAPIKey(_ context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.keys.checkAdd(key); err != nil {
+		return err
+	}
+	// Ключ становится действительным только после записи на диск.
+	if err := s.appendAPIKey(key); err != nil {
+		return err
+	}
+	s.keys.put(key)
+	return nil
+}
+
+// GetAPIKeyByHash возвращает API-ключ по хешу.
+func (s *FileStorage) GetAPIKeyByHash(_ context.Context, hash string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keys.getByHash(hash)
+}
+
+// ListAPIKeys возвращает API-ключи пользователя.
+func (s *FileStorage) ListAPIKeys(_ context.Context, userID string) ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keys.list(userID), nil
+}
+
+// RevokeAPIKey отзывает API-ключ пользователя.
+func (s *FileStorage) RevokeAPIKey(_ context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.keys.revoked(userID, id, time.Now())
+	if err != nil {
+		return err
 	}
-	return s.saveAllToFile()
+	if err := s.appendAPIKey(key); err != nil {
+		return err
+	}
+	s.keys.put(key)
+	return nil
 }
 
-// saveAllToFile перезаписывает файл хранилища со всеми данными.
-func (s *FileStorage) saveAllToFile() error {
-	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+// loadAPIKeys загружает API-ключи из файла.
+func (s *FileStorage) loadAPIKeys() error {
+	file, err := os.Open(s.keysFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	for _, url := range s.data {
-		if err := encoder.Encode(url); err != nil {
+	decoder := json.NewDecoder(file)
+	for {
+		var key APIKey
+		if err := decoder.Decode(&key); err != nil {
+			if err == io.EOF {
+				break
+			}
 			return err
 		}
+		s.keys.put(key)
 	}
 	return nil
 }
 
-// URLCount возвращает число всех загруженных URL
-func (s *FileStorage) URLCount() (int, error) {
-	return len(s.data), nil
+// SetURLDeleted помечает ссылку удалённой или восстанавливает её и записывает изменение в журнал.
+func (s *FileStorage) SetURLDeleted(_ context.Context, shortURL string, deleted bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data[shortURL]; !ok {
+		return ErrNotFound
+	}
+	op := walRestore
+	if deleted {
+		op = walDelete
+	}
+	return s.commit(walRecord{Op: op, At: time.Now(), IDs: []string{shortURL}})
 }
 
-// UserCount возвращает количество пользователей в хранилище
-func (s *FileStorage) UserCount() (int, error) {
-	return len(s.userURLs), nil
+// ReassignURLs передаёт ссылки пользователя from пользователю to и записывает изменение в журнал.
+func (s *FileStorage) ReassignURLs(_ context.Context, from, to string, shortURLs []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	moved := ownedURLs(s.data, s.userURLs, from, shortURLs)
+	if len(moved) == 0 {
+		return 0, nil
+	}
+	if err := s.commit(walRecord{Op: walReassign, At: time.Now(), IDs: moved, From: from, To: to}); err != nil {
+		return 0, err
+	}
+	return len(moved), nil
+}
+
+// PurgeDeleted удаляет ссылки, помеченные удалёнными раньше before, и записывает изменение в журнал.
+func (s *FileStorage) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := deletedBefore(s.data, before)
+	if len(purged) == 0 {
+		return 0, nil
+	}
+	if err := s.commit(walRecord{Op: walRemove, At: time.Now(), IDs: purged}); err != nil {
+		return 0, err
+	}
+	return len(purged), nil
 }
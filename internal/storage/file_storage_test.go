@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/mi4r/go-url-shortener/internal/logger"
 	"go.uber.org/zap"
@@ -34,7 +37,7 @@ func TestFileStorage(t *testing.T) {
 			OriginalURL:   "https://example.com",
 			UserID:        "user1",
 		}
-		_, err := fs.Save(url)
+		_, err := fs.Save(context.Background(), url)
 		if err != nil {
 			t.Errorf("failed to save URL: %v", err)
 		}
@@ -46,7 +49,7 @@ func TestFileStorage(t *testing.T) {
 			{CorrelationID: "2", OriginalURL: "https://example2.com", UserID: "user1"},
 			{CorrelationID: "3", OriginalURL: "https://example3.com", UserID: "user1"},
 		}
-		ids, err := fs.SaveBatch(urls)
+		ids, err := fs.SaveBatch(context.Background(), urls)
 		if err != nil {
 			t.Errorf("failed to save batch: %v", err)
 		}
@@ -57,9 +60,9 @@ func TestFileStorage(t *testing.T) {
 
 	// Тесты Get
 	t.Run("Get", func(t *testing.T) {
-		url, exists := fs.Get("short1")
-		if !exists {
-			t.Errorf("failed to get URL")
+		url, err := fs.Get(context.Background(), "short1")
+		if err != nil {
+			t.Errorf("failed to get URL: %v", err)
 		}
 		if url.OriginalURL != "https://example.com" {
 			t.Errorf("expected https://example.com, got %s", url.OriginalURL)
@@ -68,7 +71,7 @@ func TestFileStorage(t *testing.T) {
 
 	// Тесты GetURLsByUserID
 	t.Run("GetURLsByUserID", func(t *testing.T) {
-		urls, err := fs.GetURLsByUserID("user1")
+		urls, err := fs.GetURLsByUserID(context.Background(), "user1")
 		if err != nil {
 			t.Errorf("failed to get URLs by user ID: %v", err)
 		}
@@ -79,7 +82,7 @@ func TestFileStorage(t *testing.T) {
 
 	// Тесты GetNextID
 	t.Run("GetNextID", func(t *testing.T) {
-		nextID, err := fs.GetNextID()
+		nextID, err := fs.GetNextID(context.Background())
 		if err != nil {
 			t.Errorf("failed to get next ID: %v", err)
 		}
@@ -90,16 +93,63 @@ func TestFileStorage(t *testing.T) {
 
 	// Тесты MarkURLsAsDeleted
 	t.Run("MarkURLsAsDeleted", func(t *testing.T) {
-		err := fs.MarkURLsAsDeleted("user1", []string{"short1"})
+		err := fs.MarkURLsAsDeleted(context.Background(), "user1", []string{"short1"})
 		if err != nil {
 			t.Errorf("failed to mark URLs as deleted: %v", err)
 		}
-		url, exists := fs.Get("short1")
-		if !exists || !url.DeletedFlag {
+		url, err := fs.Get(context.Background(), "short1")
+		if !errors.Is(err, ErrGone) || !url.DeletedFlag {
 			t.Errorf("expected URL to be marked as deleted")
 		}
 	})
 
+	// Тесты DeleteExpired
+	t.Run("DeleteExpired", func(t *testing.T) {
+		url := URL{
+			CorrelationID: "3",
+			ShortURL:      "expired",
+			OriginalURL:   "https://expired.com",
+			UserID:        "user1",
+			ExpiresAt:     time.Now().Add(-time.Minute),
+		}
+		if _, err := fs.Save(context.Background(), url); err != nil {
+			t.Fatalf("failed to save URL: %v", err)
+		}
+		deleted, err := fs.DeleteExpired(context.Background(), time.Now())
+		if err != nil || deleted != 1 {
+			t.Errorf("expected 1 deleted url, got %d (%v)", deleted, err)
+		}
+
+		reloaded, err := NewFileStorage(tempFile.Name())
+		if err != nil {
+			t.Fatalf("failed to reload file storage: %v", err)
+		}
+		if _, err := reloaded.Get(context.Background(), "expired"); !errors.Is(err, ErrGone) {
+			t.Errorf("expected expired URL to stay deleted in file, got %v", err)
+		}
+	})
+
+	// Тесты SaveClicks
+	t.Run("SaveClicks", func(t *testing.T) {
+		defer os.Remove(tempFile.Name() + ".clicks")
+		clicks := []Click{
+			{ShortURL: "short1", ClickedAt: time.Now(), Country: "RU"},
+			{ShortURL: "short1", ClickedAt: time.Now(), Country: "DE"},
+		}
+		if err := fs.SaveClicks(context.Background(), clicks); err != nil {
+			t.Fatalf("failed to save clicks: %v", err)
+		}
+
+		reloaded, err := NewFileStorage(tempFile.Name())
+		if err != nil {
+			t.Fatalf("failed to reload file storage: %v", err)
+		}
+		stats, err := reloaded.GetLinkStats(context.Background(), "short1")
+		if err != nil || stats.Clicks != 2 || stats.Countries["DE"] != 1 {
+			t.Errorf("unexpected stats after reload: %+v (%v)", stats, err)
+		}
+	})
+
 	// Тесты Close
 	t.Run("Close", func(t *testing.T) {
 		err := fs.Close()
@@ -146,7 +196,7 @@ func TestFileURLCount(t *testing.T) {
 			}
 
 			// Проверяем результат
-			got, err := storage.URLCount()
+			got, err := storage.URLCount(context.Background())
 			if err != nil {
 				t.Errorf("URLCount() error = %v, wantErr false", err)
 				return
@@ -200,7 +250,7 @@ func TestFileUserCount(t *testing.T) {
 				userURLs: tt.userData,
 			}
 
-			got, err := storage.UserCount()
+			got, err := storage.UserCount(context.Background())
 			if err != nil {
 				t.Errorf("UserCount() error = %v, wantErr false", err)
 				return
@@ -211,3 +261,142 @@ func TestFileUserCount(t *testing.T) {
 		})
 	}
 }
+
+func TestFileStorage_LegacyRecords(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	tempFile, err := os.CreateTemp("", "storage_legacy_*.json")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	// Строки в формате ранних версий: без временных меток и пакет одной строкой-массивом.
+	legacy := `{"correlation_id":"1","short_url":"old1","original_url":"https://a.com","user_id":"user1","is_deleted":false}
+[{"correlation_id":"2","short_url":"old2","original_url":"https://b.com","user_id":"user1","is_deleted":false},{"correlation_id":"3","short_url":"old3","original_url":"https://c.com","user_id":"user2","is_deleted":true}]
+`
+	if _, err := tempFile.WriteString(legacy); err != nil {
+		t.Fatalf("failed to write legacy file: %v", err)
+	}
+
+	fs, err := NewFileStorage(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to load legacy file: %v", err)
+	}
+
+	url, err := fs.Get(context.Background(), "old1")
+	if err != nil || !url.CreatedAt.IsZero() {
+		t.Errorf("unexpected legacy url: %+v (%v)", url, err)
+	}
+	if _, err := fs.Get(context.Background(), "old3"); !errors.Is(err, ErrGone) {
+		t.Errorf("expected ErrGone for legacy deleted url, got %v", err)
+	}
+	urls, _ := fs.GetURLsByUserID(context.Background(), "user1")
+	if len(urls) != 2 {
+		t.Errorf("expected 2 urls for user1, got %d", len(urls))
+	}
+	if nextID, _ := fs.GetNextID(context.Background()); nextID != 4 {
+		t.Errorf("expected next ID 4, got %d", nextID)
+	}
+}
+
+func TestFileStorage_APIKeys(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	tempFile, err := os.CreateTemp("", "storage_keys_*.json")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer os.Remove(tempFile.Name() + ".keys")
+
+	ctx := context.Background()
+	fs, err := NewFileStorage(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	key := APIKey{ID: "k1", UserID: "user1", Name: "ci", Hash: "hash1", CreatedAt: time.Now()}
+	if err := fs.SaveAPIKey(ctx, key); err != nil {
+		t.Fatalf("SaveAPIKey failed: %v", err)
+	}
+	if err := fs.SaveAPIKey(ctx, APIKey{ID: "k2", UserID: "user1", Hash: "hash1"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for duplicate hash, got %v", err)
+	}
+	if err := fs.RevokeAPIKey(ctx, "user2", "k1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+	if err := fs.RevokeAPIKey(ctx, "user1", "k1"); err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+
+	// Отзыв должен сохраниться после перезагрузки.
+	reloaded, err := NewFileStorage(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to reload file storage: %v", err)
+	}
+	stored, err := reloaded.GetAPIKeyByHash(ctx, "hash1")
+	if err != nil {
+		t.Fatalf("GetAPIKeyByHash failed: %v", err)
+	}
+	if stored.UserID != "user1" || !stored.Revoked() {
+		t.Errorf("unexpected key after reload: %+v", stored)
+	}
+	if _, err := reloaded.GetAPIKeyByHash(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	keys, _ := reloaded.ListAPIKeys(ctx, "user1")
+	if len(keys) != 1 {
+		t.Errorf("expected 1 key, got %d", len(keys))
+	}
+}
+
+func TestFileStorage_APIKeyWriteFailure(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	path := t.TempDir() + "/urls.json"
+	ctx := context.Background()
+	fs, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	defer fs.Close()
+	if err := fs.SaveAPIKey(ctx, APIKey{ID: "k1", UserID: "user1", Hash: "hash1", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveAPIKey failed: %v", err)
+	}
+
+	// Каталог на месте файла ключей делает запись невозможной.
+	keysPath := fs.keysFilePath()
+	if err := os.Rename(keysPath, keysPath+".saved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(keysPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.SaveAPIKey(ctx, APIKey{ID: "k2", UserID: "user1", Hash: "hash2", CreatedAt: time.Now()}); err == nil {
+		t.Error("expected SaveAPIKey to fail")
+	}
+	if _, err := fs.GetAPIKeyByHash(ctx, "hash2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unsaved key must not authenticate, got %v", err)
+	}
+	if err := fs.RevokeAPIKey(ctx, "user1", "k1"); err == nil {
+		t.Error("expected RevokeAPIKey to fail")
+	}
+	if key, _ := fs.GetAPIKeyByHash(ctx, "hash1"); key.Revoked() {
+		t.Error("failed revoke must not take effect in memory")
+	}
+
+	// После восстановления файла состояние на диске совпадает с памятью.
+	if err := os.Remove(keysPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(keysPath+".saved", keysPath); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("failed to reload file storage: %v", err)
+	}
+	defer reloaded.Close()
+	keys, _ := reloaded.ListAPIKeys(ctx, "user1")
+	if len(keys) != 1 || keys[0].Revoked() {
+		t.Errorf("unexpected keys after reload: %+v", keys)
+	}
+}
@@ -0,0 +1,477 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// Файл FileStorage — журнал упреждающей записи: каждая строка содержит одну
+// типизированную запись в виде "<crc32c в hex> <json>\n". Состояние хранилища
+// восстанавливается последовательным применением записей, а журнал
+// периодически сжимается до одной записи create на каждую ссылку.
+
+// walOp — тип записи журнала.
+type walOp string
+
+// Типы записей журнала.
+const (
+	walCreate   walOp = "create"   // Новая ссылка; URL содержит её полное состояние.
+	walDelete   walOp = "delete"   // Пометка ссылок IDs удалёнными.
+	walRestore  walOp = "restore"  // Восстановление удалённых ссылок IDs.
+	walReassign walOp = "reassign" // Передача ссылок IDs от From к To.
+	walRemove   walOp = "remove"   // Безвозвратное удаление ссылок IDs.
+)
+
+// Порог сжатия журнала: журнал переписывается, когда в нём не меньше
+// compactMinRecords записей и их больше, чем compactRatio записей на ссылку.
+const (
+	compactMinRecords = 1024
+	compactRatio      = 2
+)
+
+// walRecord — запись журнала.
+type walRecord struct {
+	Op   walOp     `json:"op"`             // Тип записи.
+	At   time.Time `json:"at"`             // Время операции.
+	URL  *URL      `json:"url,omitempty"`  // Ссылка для записи create.
+	IDs  []string  `json:"ids,omitempty"`  // Короткие идентификаторы для остальных записей.
+	From string    `json:"from,omitempty"` // Прежний владелец для записи reassign.
+	To   string    `json:"to,omitempty"`   // Новый владелец для записи reassign.
+}
+
+// walTable — таблица CRC-32C (Castagnoli) для контрольных сумм записей.
+var walTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorruptRecord возвращается для строки журнала с неверной контрольной суммой или форматом.
+var errCorruptRecord = errors.New("corrupt record")
+
+// SyncPolicy определяет, когда журнал FileStorage сбрасывается на диск (fsync).
+// Нулевое значение — SyncAlways.
+type SyncPolicy struct {
+	interval time.Duration // 0 — после каждой записи, >0 — периодически, <0 — никогда.
+}
+
+// Политики сброса журнала.
+var (
+	// SyncAlways сбрасывает журнал перед возвратом из каждой изменяющей операции.
+	SyncAlways = SyncPolicy{}
+	// SyncNever оставляет сброс операционной системе: при сбое питания
+	// теряются последние записи, но не целостность журнала.
+	SyncNever = SyncPolicy{interval: -1}
+)
+
+// SyncEvery сбрасывает журнал не реже раза в d. При сбое теряются записи
+// не более чем за d. Неположительное d означает SyncAlways.
+func SyncEvery(d time.Duration) SyncPolicy {
+	if d <= 0 {
+		return SyncAlways
+	}
+	return SyncPolicy{interval: d}
+}
+
+// String возвращает название политики, принимаемое ParseSyncPolicy.
+func (p SyncPolicy) String() string {
+	switch {
+	case p.interval == 0:
+		return "always"
+	case p.interval < 0:
+		return "never"
+	default:
+		return p.interval.String()
+	}
+}
+
+// ParseSyncPolicy разбирает политику сброса: "always", "never" или период
+// в формате time.ParseDuration, например "1s". Пустая строка означает SyncAlways.
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "", "always":
+		return SyncAlways, nil
+	case "never":
+		return SyncNever, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return SyncPolicy{}, fmt.Errorf("unknown sync policy %q, want always, never or a positive duration", s)
+	}
+	return SyncEvery(d), nil
+}
+
+// encodeWALRecord кодирует запись в строку журнала.
+func encodeWALRecord(buf *bytes.Buffer, rec walRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "%08x ", crc32.Checksum(data, walTable))
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// decodeWALLine разбирает строку журнала без завершающего перевода строки.
+// Строки, начинающиеся с '{' или '[', записаны ранними версиями без журнала
+// и превращаются в записи create.
+func decodeWALLine(line []byte) ([]walRecord, error) {
+	if len(line) > 0 && (line[0] == '{' || line[0] == '[') {
+		urls, err := decodeFileRecord(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errCorruptRecord, err)
+		}
+		recs := make([]walRecord, len(urls))
+		for i := range urls {
+			recs[i] = walRecord{Op: walCreate, URL: &urls[i]}
+		}
+		return recs, nil
+	}
+
+	sum, data, ok := bytes.Cut(line, []byte{' '})
+	if !ok || len(sum) != 8 {
+		return nil, fmt.Errorf("%w: missing checksum", errCorruptRecord)
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCorruptRecord, err)
+	}
+	if crc32.Checksum(data, walTable) != uint32(want) {
+		return nil, fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+	}
+	var rec walRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("%w: %w", errCorruptRecord, err)
+	}
+	if rec.Op == walCreate && rec.URL == nil {
+		return nil, fmt.Errorf("%w: create without url", errCorruptRecord)
+	}
+	return []walRecord{rec}, nil
+}
+
+// decodeFileRecord разбирает строку файла хранилища ранних версий. Они
+// сохраняли пакеты URL одной строкой-массивом и не записывали временные метки.
+func decodeFileRecord(raw []byte) ([]URL, error) {
+	if len(raw) > 0 && raw[0] == '[' {
+		var urls []URL
+		err := json.Unmarshal(raw, &urls)
+		return urls, err
+	}
+	var url URL
+	if err := json.Unmarshal(raw, &url); err != nil {
+		return nil, err
+	}
+	return []URL{url}, nil
+}
+
+// loadFromFile восстанавливает состояние из журнала и открывает его для записи.
+// Повреждённая или недописанная последняя строка — след прерванной записи —
+// отбрасывается, повреждение в середине журнала считается ошибкой. Журнал
+// в формате ранних версий сразу переписывается в текущий формат.
+func (s *FileStorage) loadFromFile() error {
+	// Временный файл остаётся только после сбоя во время сжатия; журнал при этом цел.
+	if err := os.Remove(s.compactPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_RDONLY, 0666)
+	if err != nil {
+		return err
+	}
+	var (
+		reader       = bufio.NewReader(file)
+		offset       int64 // Конец последней целой строки.
+		lineNum      int
+		legacy       bool
+		unterminated bool  // Последняя строка цела, но без перевода строки.
+		tail         error // Ошибка последней прочитанной строки.
+	)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			file.Close()
+			return readErr
+		}
+		if len(line) == 0 {
+			break
+		}
+		lineNum++
+		if tail != nil {
+			file.Close()
+			return fmt.Errorf("%s: line %d: %w", s.filePath, lineNum-1, tail)
+		}
+		if content := bytes.TrimRight(line, "\r\n"); len(bytes.TrimSpace(content)) > 0 {
+			recs, err := decodeWALLine(content)
+			if err != nil {
+				tail = err
+				continue
+			}
+			legacy = legacy || content[0] == '{' || content[0] == '['
+			for _, rec := range recs {
+				s.apply(rec)
+			}
+			s.logRecords += len(recs)
+		}
+		offset += int64(len(line))
+		unterminated = readErr == io.EOF
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if tail != nil {
+		logger.Sugar.Warnf("%s: dropping damaged last record at line %d: %v", s.filePath, lineNum, tail)
+		if err := os.Truncate(s.filePath, offset); err != nil {
+			return err
+		}
+	}
+	if legacy {
+		return s.compact()
+	}
+	if err := s.openLog(); err != nil {
+		return err
+	}
+	if unterminated {
+		return s.writeLog([]byte{'\n'})
+	}
+	return nil
+}
+
+// apply применяет запись журнала к состоянию в памяти.
+func (s *FileStorage) apply(rec walRecord) {
+	switch rec.Op {
+	case walCreate:
+		s.load(*rec.URL)
+	case walDelete, walRestore:
+		for _, id := range rec.IDs {
+			// Отсутствующая ссылка могла быть удалена сжатым ранее журналом.
+			_ = setURLDeleted(s.data, &s.dedup, id, rec.Op == walDelete, rec.At)
+		}
+	case walReassign:
+		reassignURLs(s.data, s.userURLs, &s.dedup, rec.From, rec.To, rec.IDs, rec.At)
+	case walRemove:
+		for _, id := range rec.IDs {
+			removeURL(s.data, s.userURLs, id)
+		}
+	default:
+		logger.Sugar.Warnf("%s: skipping record with unknown op %q", s.filePath, rec.Op)
+	}
+}
+
+// load добавляет в память URL, прочитанный из файла. Если URL встречается
+// повторно, используется последняя версия. Следующий идентификатор, как и при
+// сохранении, растёт с каждой новой записью и не меньше числовых
+// корреляционных идентификаторов.
+func (s *FileStorage) load(url URL) {
+	old, exists := s.data[url.ShortURL]
+	if !exists {
+		s.nextID++
+	}
+	if !exists || old.UserID != url.UserID {
+		if exists {
+			removeUserURL(s.userURLs, old.UserID, url.ShortURL)
+		}
+		s.userURLs[url.UserID] = append(s.userURLs[url.UserID], url.ShortURL)
+	}
+	s.data[url.ShortURL] = url
+	if !url.DeletedFlag {
+		s.dedup.add(url)
+	}
+	if urlID, _ := strconv.Atoi(url.CorrelationID); urlID >= s.nextID {
+		s.nextID = urlID + 1
+	}
+}
+
+// walFile — открытый на дописывание файл журнала.
+type walFile interface {
+	io.WriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// openLog открывает журнал для дописывания.
+func (s *FileStorage) openLog() error {
+	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.log = file
+	s.logSize = info.Size()
+	return nil
+}
+
+// commit дописывает записи в журнал одной операцией записи, сбрасывает его
+// на диск согласно политике и только затем применяет записи к состоянию в
+// памяти. Если запись не удалась, журнал обрезается до прежнего размера, а
+// память не меняется. Вызывается под s.mu. После записи журнал сжимается,
+// если превысил порог.
+func (s *FileStorage) commit(recs ...walRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	if s.log == nil {
+		return fmt.Errorf("%w: file storage is closed", ErrUnavailable)
+	}
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		if err := encodeWALRecord(&buf, rec); err != nil {
+			return err
+		}
+	}
+	if err := s.writeLog(buf.Bytes()); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		s.apply(rec)
+	}
+	s.logRecords += len(recs)
+
+	if s.logRecords >= s.compactMin && s.logRecords > compactRatio*len(s.data) {
+		if err := s.compact(); err != nil {
+			// Записи уже в журнале, поэтому операция считается выполненной.
+			logger.Sugar.Errorf("%s: compaction failed: %v", s.filePath, err)
+		}
+	}
+	return nil
+}
+
+// writeLog дописывает data в журнал и сбрасывает его на диск согласно
+// политике. При ошибке недописанный хвост отрезается, чтобы следующая
+// запись не оказалась за повреждённой строкой.
+func (s *FileStorage) writeLog(data []byte) error {
+	_, err := s.log.Write(data)
+	if err == nil && s.sync.interval == 0 {
+		err = s.log.Sync()
+	}
+	if err != nil {
+		if truncErr := s.log.Truncate(s.logSize); truncErr != nil {
+			logger.Sugar.Errorf("%s: failed to truncate log after write error: %v", s.filePath, truncErr)
+		}
+		return err
+	}
+	s.logSize += int64(len(data))
+	if s.sync.interval > 0 {
+		s.unsynced = true
+	}
+	return nil
+}
+
+// Compact переписывает журнал, оставляя по одной записи на каждую ссылку.
+func (s *FileStorage) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.compact()
+}
+
+// compact записывает текущее состояние во временный файл, сбрасывает его на
+// диск и атомарно заменяет им журнал. При сбое на любом шаге на диске
+// остаётся либо прежний журнал, либо новый. Вызывается под s.mu.
+func (s *FileStorage) compact() error {
+	tmp, err := os.OpenFile(s.compactPath(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	var buf bytes.Buffer
+	users := make([]string, 0, len(s.userURLs))
+	for userID := range s.userURLs {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	now := time.Now()
+	for _, userID := range users {
+		for _, shortID := range s.userURLs[userID] {
+			url := s.data[shortID]
+			buf.Reset()
+			if err := encodeWALRecord(&buf, walRecord{Op: walCreate, At: now, URL: &url}); err != nil {
+				tmp.Close()
+				return err
+			}
+			if _, err := writer.Write(buf.Bytes()); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.filePath); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(s.filePath)); err != nil {
+		return err
+	}
+	if s.log != nil {
+		if err := s.log.Close(); err != nil {
+			logger.Sugar.Error(err)
+		}
+		s.log = nil
+	}
+	s.logRecords = len(s.data)
+	s.unsynced = false
+	return s.openLog()
+}
+
+// compactPath возвращает путь к временному файлу сжатия журнала.
+func (s *FileStorage) compactPath() string {
+	return s.filePath + ".compact"
+}
+
+// syncDir сбрасывает на диск каталог, чтобы переименование файла пережило сбой.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// syncLoop периодически сбрасывает журнал на диск до закрытия done.
+func (s *FileStorage) syncLoop(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.unsynced && s.log != nil {
+				if err := s.log.Sync(); err != nil {
+					logger.Sugar.Errorf("%s: sync failed: %v", s.filePath, err)
+				} else {
+					s.unsynced = false
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mi4r/go-url-shortener/internal/logger"
+)
+
+// newWALStorage создаёт файловое хранилище во временном каталоге.
+func newWALStorage(t *testing.T, opts ...Option) (*FileStorage, string) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	path := filepath.Join(t.TempDir(), "urls.json")
+	s, err := NewFileStorage(path, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+// logLines возвращает непустые строки журнала.
+func logLines(t *testing.T, path string) [][]byte {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return bytes.Split(bytes.TrimSuffix(data, []byte{'\n'}), []byte{'\n'})
+}
+
+func TestFileStorage_WALReplay(t *testing.T) {
+	ctx := context.Background()
+	s, path := newWALStorage(t)
+
+	_, err := s.Save(ctx, URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	require.NoError(t, err)
+	_, err = s.SaveBatch(ctx, []URL{
+		{CorrelationID: "2", ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"},
+		{CorrelationID: "3", ShortURL: "b1", OriginalURL: "https://c.com", UserID: "bob"},
+		{CorrelationID: "4", ShortURL: "b2", OriginalURL: "https://d.com", UserID: "bob", ExpiresAt: time.Now().Add(-time.Minute)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a1"}))
+	require.NoError(t, s.SetURLDeleted(ctx, "a1", false))
+	_, err = s.ReassignURLs(ctx, "alice", "carol", []string{"a2"})
+	require.NoError(t, err)
+	_, err = s.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.SetURLDeleted(ctx, "b1", true))
+	_, err = s.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Каждая операция — одна запись, пакет — по записи на ссылку.
+	assert.Len(t, logLines(t, path), 10)
+
+	reloaded, err := NewFileStorage(path)
+	require.NoError(t, err)
+	defer reloaded.Close()
+	for _, id := range []string{"a1", "a2"} {
+		want, _ := s.Get(ctx, id)
+		got, err := reloaded.Get(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), id)
+		assert.Equal(t, want.UserID, got.UserID, id)
+	}
+	for _, id := range []string{"b1", "b2"} {
+		_, err := reloaded.Get(ctx, id)
+		assert.ErrorIs(t, err, ErrNotFound, id)
+	}
+	urls, err := reloaded.GetURLsByUserID(ctx, "carol")
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+	nextID, err := reloaded.GetNextID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, nextID)
+
+	// Восстановленная ссылка участвует в дедупликации и после перезагрузки.
+	existing, err := reloaded.Save(ctx, URL{ShortURL: "a3", OriginalURL: "https://a.com", UserID: "alice"})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "a1", existing)
+}
+
+func TestFileStorage_WALRecovery(t *testing.T) {
+	ctx := context.Background()
+	s, path := newWALStorage(t)
+	for _, id := range []string{"a1", "a2", "a3"} {
+		_, err := s.Save(ctx, URL{ShortURL: id, OriginalURL: "https://" + id + ".com", UserID: "alice"})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Close())
+	intact, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := logLines(t, path)
+
+	t.Run("truncated last line", func(t *testing.T) {
+		torn := append(append([]byte(nil), intact...), lines[0][:20]...)
+		require.NoError(t, os.WriteFile(path, torn, 0666))
+
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		count, _ := s.URLCount(ctx)
+		assert.Equal(t, 3, count)
+		_, err = s.Save(ctx, URL{ShortURL: "a4", OriginalURL: "https://a4.com", UserID: "alice"})
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+
+		// Недописанная строка отброшена, новая запись читается.
+		reloaded, err := NewFileStorage(path)
+		require.NoError(t, err)
+		defer reloaded.Close()
+		count, _ = reloaded.URLCount(ctx)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("checksum mismatch in last line", func(t *testing.T) {
+		damaged := append([]byte(nil), intact...)
+		damaged[len(damaged)-3] ^= 0x01
+		require.NoError(t, os.WriteFile(path, damaged, 0666))
+
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		defer s.Close()
+		_, err = s.Get(ctx, "a3")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Len(t, logLines(t, path), 2)
+	})
+
+	t.Run("corruption in the middle", func(t *testing.T) {
+		damaged := append([]byte(nil), intact...)
+		damaged[len(lines[0])-3] ^= 0x01
+		require.NoError(t, os.WriteFile(path, damaged, 0666))
+
+		_, err := NewFileStorage(path)
+		assert.ErrorIs(t, err, errCorruptRecord)
+		assert.ErrorContains(t, err, "line 1")
+	})
+}
+
+// failingLog дописывает в журнал только первые n байт записи и возвращает ошибку.
+type failingLog struct {
+	*os.File
+	n int
+}
+
+func (f *failingLog) Write(p []byte) (int, error) {
+	n, _ := f.File.Write(p[:min(f.n, len(p))])
+	return n, errors.New("disk full")
+}
+
+func TestFileStorage_WALWriteFailure(t *testing.T) {
+	ctx := context.Background()
+	s, path := newWALStorage(t)
+	_, err := s.SaveBatch(ctx, []URL{
+		{ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"},
+		{ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"},
+	})
+	require.NoError(t, err)
+
+	file := s.log.(*os.File)
+	s.log = &failingLog{File: file, n: 10}
+	_, err = s.Save(ctx, URL{ShortURL: "a3", OriginalURL: "https://c.com", UserID: "alice"})
+	assert.Error(t, err)
+	_, err = s.SaveBatch(ctx, []URL{{ShortURL: "b1", OriginalURL: "https://d.com", UserID: "bob"}})
+	assert.Error(t, err)
+	assert.Error(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a1"}))
+	assert.Error(t, s.SetURLDeleted(ctx, "a2", true))
+	_, err = s.ReassignURLs(ctx, "alice", "bob", nil)
+	assert.Error(t, err)
+
+	// Память не изменилась, недописанные строки отрезаны.
+	for _, id := range []string{"a3", "b1"} {
+		_, err := s.Get(ctx, id)
+		assert.ErrorIs(t, err, ErrNotFound, id)
+	}
+	for _, id := range []string{"a1", "a2"} {
+		url, err := s.Get(ctx, id)
+		require.NoError(t, err, id)
+		assert.Equal(t, "alice", url.UserID, id)
+	}
+	assert.Len(t, logLines(t, path), 2)
+
+	// После сбоя журнал принимает новые записи, и они переживают перезагрузку.
+	s.log = file
+	_, err = s.Save(ctx, URL{ShortURL: "a3", OriginalURL: "https://c.com", UserID: "alice"})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	reloaded, err := NewFileStorage(path)
+	require.NoError(t, err)
+	defer reloaded.Close()
+	count, err := reloaded.URLCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestFileStorage_Compact(t *testing.T) {
+	ctx := context.Background()
+	s, path := newWALStorage(t)
+	s.compactMin = 8
+
+	for _, id := range []string{"a1", "a2"} {
+		_, err := s.Save(ctx, URL{ShortURL: id, OriginalURL: "https://" + id + ".com", UserID: "alice"})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.SetURLDeleted(ctx, "a1", i%2 == 1))
+	}
+	// Журнал сжимается по порогу и не разрастается сверх compactMin записей.
+	assert.LessOrEqual(t, len(logLines(t, path)), s.compactMin)
+	assert.NoFileExists(t, path+".compact")
+
+	require.NoError(t, s.Compact())
+	assert.Len(t, logLines(t, path), 2)
+	_, err := s.Save(ctx, URL{ShortURL: "a3", OriginalURL: "https://a3.com", UserID: "alice"})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	reloaded, err := NewFileStorage(path)
+	require.NoError(t, err)
+	defer reloaded.Close()
+	url, err := reloaded.Get(ctx, "a1")
+	assert.ErrorIs(t, err, ErrGone)
+	assert.False(t, url.DeletedAt.IsZero())
+	urls, err := reloaded.GetURLsByUserID(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, urls, 3)
+}
+
+func TestFileStorage_LegacyMigration(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	path := filepath.Join(t.TempDir(), "urls.json")
+	legacy := `{"correlation_id":"1","short_url":"old1","original_url":"https://a.com","user_id":"user1","is_deleted":false}
+[{"correlation_id":"2","short_url":"old2","original_url":"https://b.com","user_id":"user1","is_deleted":false}]`
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0666))
+
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Файл ранней версии переписан в формат журнала.
+	lines := logLines(t, path)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		recs, err := decodeWALLine(line)
+		require.NoError(t, err)
+		assert.Equal(t, walCreate, recs[0].Op)
+		assert.NotEqual(t, byte('{'), line[0])
+	}
+}
+
+func TestFileStorage_SyncPolicy(t *testing.T) {
+	ctx := context.Background()
+	for _, policy := range []SyncPolicy{SyncAlways, SyncEvery(time.Millisecond), SyncNever} {
+		s, path := newWALStorage(t, WithSyncPolicy(policy))
+		_, err := s.Save(ctx, URL{ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+		require.NoError(t, err, policy)
+		require.NoError(t, s.Close(), policy)
+
+		_, err = s.Save(ctx, URL{ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"})
+		assert.ErrorIs(t, err, ErrUnavailable, policy)
+		assert.Len(t, logLines(t, path), 1, policy)
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for in, want := range map[string]SyncPolicy{
+		"":       SyncAlways,
+		"always": SyncAlways,
+		"never":  SyncNever,
+		"250ms":  SyncEvery(250 * time.Millisecond),
+	} {
+		got, err := ParseSyncPolicy(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	assert.Equal(t, "250ms", SyncEvery(250*time.Millisecond).String())
+	for _, in := range []string{"sometimes", "-1s", "0s"} {
+		_, err := ParseSyncPolicy(in)
+		assert.Error(t, err, in)
+	}
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLState задаёт фильтр URL по состоянию.
+type URLState int
+
+// Значения URLState.
+const (
+	StateAll     URLState = iota // Все URL.
+	StateActive                  // Только неудалённые URL.
+	StateDeleted                 // Только удалённые URL.
+)
+
+// ListQuery описывает запрос страницы URL пользователя.
+type ListQuery struct {
+	Limit  int      // Максимальное число URL на странице, 0 — без ограничения.
+	Cursor string   // Курсор из URLPage.NextCursor предыдущей страницы.
+	Desc   bool     // Сортировка от новых к старым.
+	State  URLState // Фильтр по состоянию.
+	Search string   // Подстрока оригинального URL без учёта регистра.
+}
+
+// URLPage содержит страницу URL и курсор следующей страницы.
+type URLPage struct {
+	URLs       []URL
+	NextCursor string // Пустой для последней страницы.
+}
+
+// cursorKey — ключ сортировки, после которого начинается следующая страница.
+// Время хранится с точностью до микросекунд, как в PostgreSQL.
+type cursorKey struct {
+	createdAt int64 // Unix-время создания в микросекундах.
+	shortURL  string
+}
+
+// keyOf возвращает ключ сортировки URL.
+func keyOf(url URL) cursorKey {
+	return cursorKey{createdAt: url.CreatedAt.UnixMicro(), shortURL: url.ShortURL}
+}
+
+// compare сравнивает ключи по времени создания, затем по короткому идентификатору.
+func (k cursorKey) compare(other cursorKey) int {
+	if k.createdAt != other.createdAt {
+		if k.createdAt < other.createdAt {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(k.shortURL, other.shortURL)
+}
+
+// time возвращает время создания из ключа.
+func (k cursorKey) time() time.Time {
+	return time.UnixMicro(k.createdAt).UTC()
+}
+
+// encodeCursor кодирует ключ в непрозрачную строку.
+func encodeCursor(k cursorKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(k.createdAt, 10) + "|" + k.shortURL))
+}
+
+// decodeCursor разбирает курсор. Для пустого курсора ok равен false.
+func decodeCursor(cursor string) (k cursorKey, ok bool, err error) {
+	if cursor == "" {
+		return cursorKey{}, false, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return cursorKey{}, false, ErrInvalidCursor
+	}
+	micros, shortURL, found := strings.Cut(string(raw), "|")
+	if !found || shortURL == "" {
+		return cursorKey{}, false, ErrInvalidCursor
+	}
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return cursorKey{}, false, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return cursorKey{createdAt: createdAt, shortURL: shortURL}, true, nil
+}
+
+// matches сообщает, проходит ли URL фильтры запроса.
+func (q ListQuery) matches(url URL) bool {
+	switch {
+	case q.State == StateActive && url.DeletedFlag,
+		q.State == StateDeleted && !url.DeletedFlag:
+		return false
+	case q.Search != "":
+		return strings.Contains(strings.ToLower(url.OriginalURL), strings.ToLower(q.Search))
+	default:
+		return true
+	}
+}
+
+// listURLs применяет запрос к URL пользователя из памяти.
+func listURLs(urls []URL, query ListQuery) (URLPage, error) {
+	after, hasCursor, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return URLPage{}, err
+	}
+
+	var page []URL
+	for _, url := range urls {
+		if !query.matches(url) {
+			continue
+		}
+		if hasCursor {
+			cmp := keyOf(url).compare(after)
+			if (!query.Desc && cmp <= 0) || (query.Desc && cmp >= 0) {
+				continue
+			}
+		}
+		page = append(page, url)
+	}
+	slices.SortFunc(page, func(a, b URL) int {
+		if query.Desc {
+			return keyOf(b).compare(keyOf(a))
+		}
+		return keyOf(a).compare(keyOf(b))
+	})
+	return newURLPage(page, query.Limit), nil
+}
+
+// newURLPage обрезает отсортированные URL до limit и формирует курсор,
+// если за страницей есть ещё записи.
+func newURLPage(urls []URL, limit int) URLPage {
+	if limit <= 0 || len(urls) <= limit {
+		return URLPage{URLs: urls}
+	}
+	urls = urls[:limit]
+	return URLPage{URLs: urls, NextCursor: encodeCursor(keyOf(urls[limit-1]))}
+}
@@ -1,58 +1,97 @@
 package storage
 
-import "github.com/mi4r/go-url-shortener/internal/logger"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 // MemoryStorage представляет хранилище данных в оперативной памяти.
 type MemoryStorage struct {
-	data     map[string]URL      // Карта сокращённых URL с данными.
-	userURLs map[string][]string // Карта сокращённых URL для каждого пользователя.
-	nextID   int                 // Следующий уникальный идентификатор.
+	data     map[string]URL       // Карта сокращённых URL с данными.
+	userURLs map[string][]string  // Карта сокращённых URL для каждого пользователя.
+	nextID   int                  // Следующий уникальный идентификатор.
+	stats    map[string]LinkStats // Статистика переходов по коротким ссылкам.
+	keys     apiKeySet            // API-ключи пользователей.
+	dedup    dedupIndex           // Индекс дедупликации оригинальных URL.
+
+	// mu защищает data, userURLs, nextID, stats, keys и dedup. Чтение (редиректы) берёт
+	// разделяемую блокировку и не конкурирует с другими читателями.
+	mu sync.RWMutex
 }
 
 // NewMemoryStorage создаёт новый экземпляр хранилища данных в памяти.
-func NewMemoryStorage() *MemoryStorage {
+func NewMemoryStorage(opts ...Option) *MemoryStorage {
 	return &MemoryStorage{
 		data:     make(map[string]URL),
 		userURLs: make(map[string][]string),
 		nextID:   1,
+		stats:    make(map[string]LinkStats),
+		keys:     newAPIKeySet(),
+		dedup:    dedupIndex{mode: newOptions(opts).dedup},
 	}
 }
 
 // Save сохраняет URL в памяти.
-func (s *MemoryStorage) Save(url URL) (string, error) {
+func (s *MemoryStorage) Save(_ context.Context, url URL) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if shortID, ok := s.dedup.find(s.data, url, now); ok {
+		return shortID, ErrConflict
+	}
+	if _, exists := s.data[url.ShortURL]; exists {
+		return "", ErrAliasTaken
+	}
+	url.stampCreated(now)
 	s.data[url.ShortURL] = url
+	s.dedup.add(url)
 	s.userURLs[url.UserID] = append(s.userURLs[url.UserID], url.ShortURL)
 	s.nextID++
 	return "", nil
 }
 
 // SaveBatch сохраняет пакет URL в памяти.
-func (s *MemoryStorage) SaveBatch(urls []URL) ([]string, error) {
-	ids := make([]string, 0, len(urls))
-
-	for i := range urls {
-		shortID := generateShortID()
-		urls[i].ShortURL = shortID
-		s.data[shortID] = urls[i]
-		s.userURLs[urls[i].UserID] = append(s.userURLs[urls[i].UserID], shortID)
+func (s *MemoryStorage) SaveBatch(_ context.Context, urls []URL) ([]BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	fresh, results := planBatch(s.data, &s.dedup, urls, now)
+
+	for i := range fresh {
+		fresh[i].stampCreated(now)
+		shortID := fresh[i].ShortURL
+		s.data[shortID] = fresh[i]
+		s.dedup.add(fresh[i])
+		s.userURLs[fresh[i].UserID] = append(s.userURLs[fresh[i].UserID], shortID)
 		s.nextID++
-		ids = append(ids, shortID)
 	}
 
-	return ids, nil
+	return results, nil
 }
 
 // Get возвращает URL по сокращённому идентификатору.
-func (s *MemoryStorage) Get(shortURL string) (URL, bool) {
+func (s *MemoryStorage) Get(_ context.Context, shortURL string) (URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	url, exists := s.data[shortURL]
 	if !exists {
-		return URL{}, false
+		return URL{}, ErrNotFound
 	}
-	return url, true
+	if url.DeletedFlag {
+		return url, ErrGone
+	}
+	return url, nil
 }
 
 // GetURLsByUserID возвращает все URL, связанные с указанным идентификатором пользователя.
-func (s *MemoryStorage) GetURLsByUserID(userID string) ([]URL, error) {
+func (s *MemoryStorage) GetURLsByUserID(_ context.Context, userID string) ([]URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var urls []URL
 	shortIDs, exists := s.userURLs[userID]
 	if !exists {
@@ -69,35 +108,153 @@ func (s *MemoryStorage) GetURLsByUserID(userID string) ([]URL, error) {
 	return urls, nil
 }
 
+// ListURLsByUserID возвращает страницу URL пользователя.
+func (s *MemoryStorage) ListURLsByUserID(ctx context.Context, userID string, query ListQuery) (URLPage, error) {
+	urls, err := s.GetURLsByUserID(ctx, userID)
+	if err != nil {
+		return URLPage{}, err
+	}
+	return listURLs(urls, query)
+}
+
 // GetNextID возвращает следующий уникальный идентификатор.
-func (s *MemoryStorage) GetNextID() (int, error) {
+func (s *MemoryStorage) GetNextID(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.nextID, nil
 }
 
+// Ping сообщает о доступности хранилища. Хранилище в памяти доступно всегда.
+func (s *MemoryStorage) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
 // Close завершает работу хранилища. Не требует особых действий.
 func (s *MemoryStorage) Close() error {
 	return nil
 }
 
 // MarkURLsAsDeleted помечает указанные сокращённые URL как удалённые для указанного пользователя.
-func (s *MemoryStorage) MarkURLsAsDeleted(userID string, ids []string) error {
+func (s *MemoryStorage) MarkURLsAsDeleted(_ context.Context, userID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var err error
 	for _, id := range ids {
 		url, exists := s.data[id]
-		if exists && url.UserID == userID {
-			url.DeletedFlag = true
-			s.data[id] = url
+		if !exists {
+			continue
+		}
+		if url.UserID != userID {
+			err = ErrForbidden
+			continue
 		}
+		url.markDeleted(now)
+		s.data[id] = url
 	}
-	logger.Sugar.Info(s.data)
-	return nil
+	return err
 }
 
 // URLCount возвращает число всех загруженных URL
-func (s *MemoryStorage) URLCount() (int, error) {
+func (s *MemoryStorage) URLCount(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return len(s.data), nil
 }
 
 // UserCount возвращает количество пользователей в хранилище
-func (s *MemoryStorage) UserCount() (int, error) {
+func (s *MemoryStorage) UserCount(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return len(s.userURLs), nil
 }
+
+// DeleteExpired помечает удалёнными URL с истёкшим сроком действия.
+func (s *MemoryStorage) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(deleteExpired(s.data, now)), nil
+}
+
+// SaveClicks учитывает переходы в статистике.
+func (s *MemoryStorage) SaveClicks(_ context.Context, clicks []Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	addClicks(s.stats, clicks)
+	return nil
+}
+
+// GetLinkStats возвращает статистику переходов по короткой ссылке.
+func (s *MemoryStorage) GetLinkStats(_ context.Context, shortURL string) (LinkStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.stats[shortURL]
+	if !ok {
+		return NewLinkStats(shortURL), nil
+	}
+	return st.Clone(), nil
+}
+
+// SaveAPIKey сохраняет новый API-ключ.
+func (s *MemoryStorage) SaveAPIKey(_ context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys.add(key)
+}
+
+// GetAPIKeyByHash возвращает API-ключ по хешу.
+func (s *MemoryStorage) GetAPIKeyByHash(_ context.Context, hash string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keys.getByHash(hash)
+}
+
+// ListAPIKeys возвращает API-ключи пользователя.
+func (s *MemoryStorage) ListAPIKeys(_ context.Context, userID string) ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keys.list(userID), nil
+}
+
+// RevokeAPIKey отзывает API-ключ пользователя.
+func (s *MemoryStorage) RevokeAPIKey(_ context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.keys.revoke(userID, id, time.Now())
+	return err
+}
+
+// SetURLDeleted помечает ссылку удалённой или восстанавливает её.
+func (s *MemoryStorage) SetURLDeleted(_ context.Context, shortURL string, deleted bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return setURLDeleted(s.data, &s.dedup, shortURL, deleted, time.Now())
+}
+
+// ReassignURLs передаёт ссылки пользователя from пользователю to.
+func (s *MemoryStorage) ReassignURLs(_ context.Context, from, to string, shortURLs []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(reassignURLs(s.data, s.userURLs, &s.dedup, from, to, shortURLs, time.Now())), nil
+}
+
+// PurgeDeleted удаляет ссылки, помеченные удалёнными раньше before.
+func (s *MemoryStorage) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(purgeDeleted(s.data, s.userURLs, before)), nil
+}
@@ -1,10 +1,15 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/mi4r/go-url-shortener/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -17,14 +22,14 @@ func TestMemoryStorage_SaveAndGet(t *testing.T) {
 		UserID:      "user1",
 	}
 
-	_, err := storage.Save(url)
+	_, err := storage.Save(context.Background(), url)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	retrievedURL, exists := storage.Get("short123")
-	if !exists {
-		t.Fatalf("URL not found")
+	retrievedURL, err := storage.Get(context.Background(), "short123")
+	if err != nil {
+		t.Fatalf("URL not found: %v", err)
 	}
 
 	if retrievedURL.OriginalURL != url.OriginalURL {
@@ -40,7 +45,7 @@ func TestMemoryStorage_SaveBatch(t *testing.T) {
 		{OriginalURL: "https://example2.com", UserID: "user1"},
 	}
 
-	ids, err := storage.SaveBatch(urls)
+	ids, err := storage.SaveBatch(context.Background(), urls)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -50,8 +55,11 @@ func TestMemoryStorage_SaveBatch(t *testing.T) {
 	}
 
 	for _, id := range ids {
-		if _, exists := storage.Get(id); !exists {
-			t.Errorf("URL with ID %s not found", id)
+		if id.Status != BatchCreated {
+			t.Errorf("expected created status, got %v", id.Status)
+		}
+		if _, err := storage.Get(context.Background(), id.ShortURL); err != nil {
+			t.Errorf("URL with ID %s not found", id.ShortURL)
 		}
 	}
 }
@@ -66,10 +74,10 @@ func TestMemoryStorage_GetURLsByUserID(t *testing.T) {
 	}
 
 	for _, url := range urls {
-		_, _ = storage.Save(url)
+		_, _ = storage.Save(context.Background(), url)
 	}
 
-	user1URLs, err := storage.GetURLsByUserID("user1")
+	user1URLs, err := storage.GetURLsByUserID(context.Background(), "user1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -78,7 +86,7 @@ func TestMemoryStorage_GetURLsByUserID(t *testing.T) {
 		t.Errorf("expected 2 URLs for user1, got %d", len(user1URLs))
 	}
 
-	user2URLs, err := storage.GetURLsByUserID("user2")
+	user2URLs, err := storage.GetURLsByUserID(context.Background(), "user2")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -91,7 +99,7 @@ func TestMemoryStorage_GetURLsByUserID(t *testing.T) {
 func TestMemoryStorage_GetNextID(t *testing.T) {
 	storage := NewMemoryStorage()
 
-	id, err := storage.GetNextID()
+	id, err := storage.GetNextID(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -101,9 +109,9 @@ func TestMemoryStorage_GetNextID(t *testing.T) {
 	}
 
 	// Save a URL to increment the ID.
-	_, _ = storage.Save(URL{ShortURL: "short1", OriginalURL: "https://example.com", UserID: "user1"})
+	_, _ = storage.Save(context.Background(), URL{ShortURL: "short1", OriginalURL: "https://example.com", UserID: "user1"})
 
-	id, err = storage.GetNextID()
+	id, err = storage.GetNextID(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -123,16 +131,16 @@ func TestMemoryStorage_MarkURLsAsDeleted(t *testing.T) {
 		UserID:      "user1",
 	}
 
-	_, _ = storage.Save(url)
+	_, _ = storage.Save(context.Background(), url)
 
-	err := storage.MarkURLsAsDeleted("user1", []string{"short123"})
+	err := storage.MarkURLsAsDeleted(context.Background(), "user1", []string{"short123"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	retrievedURL, exists := storage.Get("short123")
-	if !exists {
-		t.Fatalf("URL not found")
+	retrievedURL, err := storage.Get(context.Background(), "short123")
+	if !errors.Is(err, ErrGone) {
+		t.Fatalf("expected ErrGone, got %v", err)
 	}
 
 	if !retrievedURL.DeletedFlag {
@@ -140,6 +148,24 @@ func TestMemoryStorage_MarkURLsAsDeleted(t *testing.T) {
 	}
 }
 
+func TestMemoryStorage_Errors(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	storage := NewMemoryStorage()
+	_, _ = storage.Save(context.Background(), URL{ShortURL: "short1", OriginalURL: "https://example.com", UserID: "user1"})
+
+	if _, err := storage.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := storage.MarkURLsAsDeleted(context.Background(), "user2", []string{"short1"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+
+	if _, err := storage.Get(context.Background(), "short1"); err != nil {
+		t.Errorf("foreign delete must not affect URL: %v", err)
+	}
+}
+
 func TestMemoryStorage_Close(t *testing.T) {
 	s := NewMemoryStorage()
 	err := s.Close()
@@ -185,7 +211,7 @@ func TestURLCount(t *testing.T) {
 			}
 
 			// Проверяем результат
-			got, err := storage.URLCount()
+			got, err := storage.URLCount(context.Background())
 			if err != nil {
 				t.Errorf("URLCount() error = %v, wantErr false", err)
 				return
@@ -239,7 +265,7 @@ func TestUserCount(t *testing.T) {
 				userURLs: tt.userData,
 			}
 
-			got, err := storage.UserCount()
+			got, err := storage.UserCount(context.Background())
 			if err != nil {
 				t.Errorf("UserCount() error = %v, wantErr false", err)
 				return
@@ -250,3 +276,180 @@ func TestUserCount(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryStorage_Alias(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	_, _ = storage.Save(ctx, URL{ShortURL: "promo", OriginalURL: "https://example.com", UserID: "user1"})
+
+	if _, err := storage.Save(ctx, URL{ShortURL: "promo", OriginalURL: "https://other.com", UserID: "user2"}); !errors.Is(err, ErrAliasTaken) {
+		t.Errorf("expected ErrAliasTaken, got %v", err)
+	}
+
+	ids, err := storage.SaveBatch(ctx, []URL{
+		{OriginalURL: "https://a.com", ShortURL: "alias-a"},
+		{OriginalURL: "https://b.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids[0].ShortURL != "alias-a" || ids[1].ShortURL == "" {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+
+	// Занятый алиас отклоняет только свой элемент.
+	results, err := storage.SaveBatch(ctx, []URL{
+		{OriginalURL: "https://c.com", ShortURL: "alias-c"},
+		{OriginalURL: "https://d.com", ShortURL: "promo"},
+		{OriginalURL: "https://e.com", ShortURL: "alias-c"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Status != BatchCreated || results[0].ShortURL != "alias-c" {
+		t.Errorf("expected alias-c to be created, got %+v", results[0])
+	}
+	for _, r := range results[1:] {
+		if r.Status != BatchInvalid || !errors.Is(r.Err, ErrAliasTaken) || r.ShortURL != "" {
+			t.Errorf("expected ErrAliasTaken for taken alias, got %+v", r)
+		}
+	}
+	if _, err := storage.Get(ctx, "alias-c"); err != nil {
+		t.Errorf("valid items of a batch must be saved, got %v", err)
+	}
+	if url, _ := storage.Get(ctx, "promo"); url.OriginalURL != "https://example.com" {
+		t.Errorf("taken alias must not be overwritten, got %s", url.OriginalURL)
+	}
+}
+
+func TestMemoryStorage_DeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	storage := NewMemoryStorage()
+	_, _ = storage.Save(ctx, URL{ShortURL: "expired", OriginalURL: "https://a.com", UserID: "user1", ExpiresAt: now.Add(-time.Minute)})
+	_, _ = storage.Save(ctx, URL{ShortURL: "alive", OriginalURL: "https://b.com", UserID: "user1", ExpiresAt: now.Add(time.Minute)})
+	_, _ = storage.Save(ctx, URL{ShortURL: "forever", OriginalURL: "https://c.com", UserID: "user2"})
+
+	deleted, err := storage.DeleteExpired(ctx, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted url, got %d", deleted)
+	}
+	if _, err := storage.Get(ctx, "expired"); !errors.Is(err, ErrGone) {
+		t.Errorf("expected ErrGone for expired url, got %v", err)
+	}
+	if _, err := storage.Get(ctx, "alive"); err != nil {
+		t.Errorf("unexpected error for alive url: %v", err)
+	}
+}
+
+func TestMemoryStorage_LinkStats(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	first := time.Now().Add(-time.Hour)
+
+	err := storage.SaveClicks(ctx, []Click{
+		{ShortURL: "abc", ClickedAt: first, Referrer: "https://ya.ru", Country: "RU"},
+		{ShortURL: "abc", ClickedAt: first.Add(time.Minute), UserAgent: "curl", Country: "RU"},
+		{ShortURL: "other", ClickedAt: first},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats, err := storage.GetLinkStats(ctx, "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Clicks != 2 || !stats.FirstClick.Equal(first) || !stats.LastClick.Equal(first.Add(time.Minute)) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Countries["RU"] != 2 || stats.Referrers["https://ya.ru"] != 1 || stats.UserAgents["curl"] != 1 {
+		t.Errorf("unexpected breakdowns: %+v", stats)
+	}
+
+	// Возвращается копия: её изменение не влияет на хранилище.
+	stats.Countries["RU"] = 100
+	again, _ := storage.GetLinkStats(ctx, "abc")
+	if again.Countries["RU"] != 2 {
+		t.Errorf("stats must be copied, got %d", again.Countries["RU"])
+	}
+
+	empty, err := storage.GetLinkStats(ctx, "none")
+	if err != nil || empty.Clicks != 0 {
+		t.Errorf("expected empty stats, got %+v (%v)", empty, err)
+	}
+}
+
+func TestMemoryStorage_Timestamps(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	before := time.Now()
+
+	_, _ = storage.Save(ctx, URL{ShortURL: "short1", OriginalURL: "https://example.com", UserID: "user1"})
+	url, _ := storage.Get(ctx, "short1")
+	if url.CreatedAt.Before(before) || !url.UpdatedAt.Equal(url.CreatedAt) || !url.DeletedAt.IsZero() {
+		t.Errorf("unexpected timestamps after save: %+v", url)
+	}
+
+	_ = storage.MarkURLsAsDeleted(ctx, "user1", []string{"short1"})
+	url, _ = storage.Get(ctx, "short1")
+	if url.DeletedAt.IsZero() || !url.UpdatedAt.Equal(url.DeletedAt) || url.DeletedAt.Before(url.CreatedAt) {
+		t.Errorf("unexpected timestamps after delete: %+v", url)
+	}
+
+	deletedAt := url.DeletedAt
+	_ = storage.MarkURLsAsDeleted(ctx, "user1", []string{"short1"})
+	url, _ = storage.Get(ctx, "short1")
+	if !url.DeletedAt.Equal(deletedAt) {
+		t.Errorf("repeated delete must keep deletion time")
+	}
+}
+
+func TestMemoryStorage_ListURLsByUserID(t *testing.T) {
+	logger.Sugar = *zap.NewNop().Sugar()
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		_, err := storage.Save(ctx, URL{
+			ShortURL:    id,
+			OriginalURL: "https://example.com/" + id,
+			UserID:      "user1",
+			CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+	// Две записи с одинаковым временем упорядочиваются по короткому идентификатору.
+	_, err := storage.Save(ctx, URL{ShortURL: "cc", OriginalURL: "https://other.org/cc", UserID: "user1", CreatedAt: base.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	require.NoError(t, storage.MarkURLsAsDeleted(ctx, "user1", []string{"b"}))
+
+	collect := func(query ListQuery) []string {
+		var ids []string
+		for {
+			page, err := storage.ListURLsByUserID(ctx, "user1", query)
+			require.NoError(t, err)
+			for _, url := range page.URLs {
+				ids = append(ids, url.ShortURL)
+			}
+			if page.NextCursor == "" {
+				return ids
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "cc", "d", "e"}, collect(ListQuery{Limit: 2}))
+	assert.Equal(t, []string{"e", "d", "cc", "c", "b", "a"}, collect(ListQuery{Limit: 4, Desc: true}))
+	assert.Equal(t, []string{"a", "c", "cc", "d", "e"}, collect(ListQuery{Limit: 3, State: StateActive}))
+	assert.Equal(t, []string{"b"}, collect(ListQuery{State: StateDeleted}))
+	assert.Equal(t, []string{"cc"}, collect(ListQuery{Search: "OTHER"}))
+
+	_, err = storage.ListURLsByUserID(ctx, "user1", ListQuery{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INT);")},
+	"0001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+	"0002_add_name.up.sql":       {Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;")},
+	"0002_add_name.down.sql":     {Data: []byte("ALTER TABLE items DROP COLUMN name;")},
+}
+
+// expectLocked ожидает захват блокировки и создание таблицы schema_migrations.
+func expectLocked(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\);`).
+		WithArgs(migrationLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectUnlocked ожидает снятие блокировки.
+func expectUnlocked(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\);`).
+		WithArgs(migrationLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(testMigrations)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_items", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE items;", migrations[0].Down)
+	assert.Equal(t, 2, migrations[1].Version)
+
+	_, err = loadMigrations(fstest.MapFS{"create.up.sql": {}})
+	assert.Error(t, err)
+	_, err = loadMigrations(fstest.MapFS{"0001_create.down.sql": {Data: []byte("DROP TABLE items;")}})
+	assert.Error(t, err)
+
+	// Встроенные миграции должны читаться без ошибок.
+	embedded, err := NewMigrator(nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, embedded.migrations)
+
+	// Миграции не удаляют ссылки: при дубликатах они прерываются с ошибкой.
+	for _, m := range embedded.migrations {
+		assert.NotContains(t, m.Up, "DELETE FROM urls", m.Name)
+		assert.NotContains(t, m.Down, "DELETE FROM urls", m.Name)
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := newMigrator(db, testMigrations)
+	require.NoError(t, err)
+
+	expectLocked(mock)
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations;`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE items ADD COLUMN name TEXT;`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations \(version, name\) VALUES \(\$1, \$2\);`).
+		WithArgs(2, "add_name").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlocked(mock)
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := newMigrator(db, testMigrations)
+	require.NoError(t, err)
+
+	expectLocked(mock)
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations;`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE items`).WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	expectUnlocked(mock)
+
+	applied, err := migrator.Up(context.Background())
+	assert.ErrorContains(t, err, "0001")
+	assert.Zero(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := newMigrator(db, testMigrations)
+	require.NoError(t, err)
+
+	expectLocked(mock)
+	mock.ExpectQuery(`SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1;`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE items DROP COLUMN name;`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM schema_migrations WHERE version = \$1;`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlocked(mock)
+
+	migration, err := migrator.Down(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, migration.Version)
+
+	expectLocked(mock)
+	mock.ExpectQuery(`SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1;`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	expectUnlocked(mock)
+
+	_, err = migrator.Down(context.Background())
+	assert.ErrorIs(t, err, ErrNoMigrations)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := newMigrator(db, testMigrations)
+	require.NoError(t, err)
+
+	appliedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expectLocked(mock)
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations;`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+	expectUnlocked(mock)
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Applied)
+	assert.Equal(t, appliedAt, statuses[0].AppliedAt)
+	assert.False(t, statuses[1].Applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS urls (
+    id SERIAL PRIMARY KEY,
+    correlation_id VARCHAR(255) NOT NULL,
+    short_url VARCHAR(255) NOT NULL UNIQUE,
+    original_url TEXT NOT NULL UNIQUE,
+    user_id VARCHAR(255),
+    is_deleted BOOLEAN DEFAULT FALSE
+);
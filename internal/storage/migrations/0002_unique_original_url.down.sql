@@ -0,0 +1 @@
+DROP INDEX IF EXISTS unique_original_url_idx;
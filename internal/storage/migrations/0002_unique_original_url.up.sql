@@ -0,0 +1,12 @@
+-- Базы, созданные до появления индекса, могли накопить дубликаты. Они не
+-- удаляются автоматически: миграция прерывается, пока дубликаты не разобраны.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM urls GROUP BY original_url HAVING COUNT(*) > 1) THEN
+        RAISE EXCEPTION 'urls has duplicate original_url values; merge or delete them before creating unique_original_url_idx'
+            USING HINT = 'SELECT original_url, array_agg(short_url) FROM urls GROUP BY original_url HAVING COUNT(*) > 1;';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_original_url_idx ON urls (original_url);
@@ -0,0 +1,2 @@
+-- Индекс для постраничной выборки URL пользователя.
+CREATE INDEX IF NOT EXISTS urls_user_created_idx ON urls (user_id, created_at, short_url);
@@ -0,0 +1,13 @@
+-- Без глобальной уникальности могли появиться дубликаты. Откат не удаляет
+-- чужие ссылки: он прерывается, пока дубликаты не разобраны.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM urls GROUP BY original_url HAVING COUNT(*) > 1) THEN
+        RAISE EXCEPTION 'urls has duplicate original_url values; merge or delete them before rolling back to global deduplication'
+            USING HINT = 'SELECT original_url, array_agg(short_url) FROM urls GROUP BY original_url HAVING COUNT(*) > 1;';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS urls_original_url_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_original_url_idx ON urls (original_url);
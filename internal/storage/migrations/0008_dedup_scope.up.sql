@@ -0,0 +1,6 @@
+-- Дедупликация оригинальных URL выполняется приложением в выбранном режиме
+-- (глобально, по пользователю или отключена), поэтому глобальная уникальность снимается.
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_original_url_key;
+DROP INDEX IF EXISTS unique_original_url_idx;
+
+CREATE INDEX IF NOT EXISTS urls_original_url_idx ON urls (original_url, user_id);
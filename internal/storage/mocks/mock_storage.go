@@ -5,6 +5,9 @@
 package mocks
 
 import (
+	"context"
+	"time"
+
 	"github.com/mi4r/go-url-shortener/internal/storage"
 	"github.com/stretchr/testify/mock"
 )
@@ -20,27 +23,30 @@ type MockStorage struct {
 // and can be configured to return specific values or errors during tests.
 //
 // Parameters:
+//   - ctx: The request context.
 //   - shortID: The short ID of the URL to retrieve.
 //
 // Returns:
 //   - storage.URL: The URL object corresponding to the short ID.
-//   - bool: True if the URL exists, false otherwise.
-func (m *MockStorage) Get(shortID string) (storage.URL, bool) {
-	args := m.Called(shortID)
-	return args.Get(0).(storage.URL), args.Bool(1)
+//   - error: storage.ErrNotFound if the URL does not exist, storage.ErrGone
+//     if it was deleted, or any other error configured for the test.
+func (m *MockStorage) Get(ctx context.Context, shortID string) (storage.URL, error) {
+	args := m.Called(ctx, shortID)
+	return args.Get(0).(storage.URL), args.Error(1)
 }
 
 // Save stores a URL in the mock storage. This method is a mock implementation
 // and can be configured to return specific short IDs or errors during tests.
 //
 // Parameters:
+//   - ctx: The request context.
 //   - url: The URL object to be saved.
 //
 // Returns:
 //   - string: The short ID generated for the URL.
 //   - error: An error if the save operation fails.
-func (m *MockStorage) Save(url storage.URL) (string, error) {
-	args := m.Called(url)
+func (m *MockStorage) Save(ctx context.Context, url storage.URL) (string, error) {
+	args := m.Called(ctx, url)
 	return args.String(0), args.Error(1)
 }
 
@@ -48,27 +54,31 @@ func (m *MockStorage) Save(url storage.URL) (string, error) {
 // is a mock implementation and can be configured to return specific IDs or
 // errors during tests.
 //
+// Parameters:
+//   - ctx: The request context.
+//
 // Returns:
 //   - int: The next available ID.
 //   - error: An error if the operation fails.
-func (m *MockStorage) GetNextID() (int, error) {
-	args := m.Called()
+func (m *MockStorage) GetNextID(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
 	return args.Int(0), args.Error(1)
 }
 
 // SaveBatch stores a batch of URLs in the mock storage. This method is a mock
-// implementation and can be configured to return specific short IDs or errors
-// during tests.
+// implementation and can be configured to return specific per-item results or
+// errors during tests.
 //
 // Parameters:
+//   - ctx: The request context.
 //   - urls: A slice of URL objects to be saved.
 //
 // Returns:
-//   - []string: A slice of short IDs generated for the URLs.
-//   - error: An error if the save operation fails.
-func (m *MockStorage) SaveBatch(urls []storage.URL) ([]string, error) {
-	args := m.Called(urls)
-	return args.Get(0).([]string), args.Error(1)
+//   - []storage.BatchResult: The result for each URL in the batch.
+//   - error: An error if the whole batch fails.
+func (m *MockStorage) SaveBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	args := m.Called(ctx, urls)
+	return args.Get(0).([]storage.BatchResult), args.Error(1)
 }
 
 // GetURLsByUserID retrieves all URLs associated with a specific user ID.
@@ -76,28 +86,47 @@ func (m *MockStorage) SaveBatch(urls []storage.URL) ([]string, error) {
 // URL lists or errors during tests.
 //
 // Parameters:
+//   - ctx: The request context.
 //   - userID: The ID of the user whose URLs are to be retrieved.
 //
 // Returns:
 //   - []storage.URL: A slice of URL objects associated with the user.
 //   - error: An error if the retrieval operation fails.
-func (m *MockStorage) GetURLsByUserID(userID string) ([]storage.URL, error) {
-	args := m.Called(userID)
+func (m *MockStorage) GetURLsByUserID(ctx context.Context, userID string) ([]storage.URL, error) {
+	args := m.Called(ctx, userID)
 	return args.Get(0).([]storage.URL), args.Error(1)
 }
 
+// ListURLsByUserID retrieves a page of URLs associated with a specific user ID.
+// This method is a mock implementation and can be configured to return specific
+// pages or errors during tests.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - userID: The ID of the user whose URLs are to be retrieved.
+//   - query: Pagination, sorting and filtering options.
+//
+// Returns:
+//   - storage.URLPage: The page of URLs and the cursor of the next page.
+//   - error: An error if the retrieval operation fails.
+func (m *MockStorage) ListURLsByUserID(ctx context.Context, userID string, query storage.ListQuery) (storage.URLPage, error) {
+	args := m.Called(ctx, userID, query)
+	return args.Get(0).(storage.URLPage), args.Error(1)
+}
+
 // MarkURLsAsDeleted marks a list of URLs as deleted for a specific user.
 // This method is a mock implementation and can be configured to return specific
 // errors during tests.
 //
 // Parameters:
+//   - ctx: The request context.
 //   - userID: The ID of the user whose URLs are to be marked as deleted.
 //   - urls: A slice of short IDs of the URLs to be marked as deleted.
 //
 // Returns:
 //   - error: An error if the operation fails.
-func (m *MockStorage) MarkURLsAsDeleted(userID string, urls []string) error {
-	args := m.Called(userID, urls)
+func (m *MockStorage) MarkURLsAsDeleted(ctx context.Context, userID string, urls []string) error {
+	args := m.Called(ctx, userID, urls)
 	return args.Error(0)
 }
 
@@ -114,31 +143,136 @@ func (m *MockStorage) Close() error {
 // Ping checks the connection to the mock storage. This method is a mock
 // implementation and can be configured to return specific errors during tests.
 //
+// Parameters:
+//   - ctx: The request context.
+//
 // Returns:
 //   - error: An error if the ping operation fails.
-func (m *MockStorage) Ping() error {
-	args := m.Called()
+func (m *MockStorage) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
 	return args.Error(0)
 }
 
 // URLCount returns count of shorten URLs
 //
+// Parameters:
+//   - ctx: The request context.
+//
 // Returns:
 //
 //	-int: number of URLs
 //	- error: An error if the operation fails.
-func (m *MockStorage) URLCount() (int, error) {
-	args := m.Called()
+func (m *MockStorage) URLCount(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
 	return args.Int(0), args.Error(1)
 }
 
 // UserCount returns count of users
 //
+// Parameters:
+//   - ctx: The request context.
+//
 // Returns:
 //
 //	-int: number of users
 //	- error: An error if the operation fails.
-func (m *MockStorage) UserCount() (int, error) {
-	args := m.Called()
+func (m *MockStorage) UserCount(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
 	return args.Int(0), args.Error(1)
 }
+
+// DeleteExpired mocks the DeleteExpired method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - now: The moment to compare expiration times with.
+//
+// Returns:
+//   - int: The number of deleted URLs.
+//   - error: An error if the operation fails.
+func (m *MockStorage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+// SaveClicks mocks the SaveClicks method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - clicks: The clicks to be saved.
+//
+// Returns:
+//   - error: An error if the operation fails.
+func (m *MockStorage) SaveClicks(ctx context.Context, clicks []storage.Click) error {
+	args := m.Called(ctx, clicks)
+	return args.Error(0)
+}
+
+// GetLinkStats mocks the GetLinkStats method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - shortURL: The short ID of the URL.
+//
+// Returns:
+//   - storage.LinkStats: The click statistics of the URL.
+//   - error: An error if the operation fails.
+func (m *MockStorage) GetLinkStats(ctx context.Context, shortURL string) (storage.LinkStats, error) {
+	args := m.Called(ctx, shortURL)
+	return args.Get(0).(storage.LinkStats), args.Error(1)
+}
+
+// SaveAPIKey mocks the SaveAPIKey method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - key: The API key to be saved.
+//
+// Returns:
+//   - error: An error if the operation fails.
+func (m *MockStorage) SaveAPIKey(ctx context.Context, key storage.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// GetAPIKeyByHash mocks the GetAPIKeyByHash method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - hash: The hash of the API key.
+//
+// Returns:
+//   - storage.APIKey: The stored API key.
+//   - error: An error if the key is not found or the operation fails.
+func (m *MockStorage) GetAPIKeyByHash(ctx context.Context, hash string) (storage.APIKey, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(storage.APIKey), args.Error(1)
+}
+
+// ListAPIKeys mocks the ListAPIKeys method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - userID: The ID of the user whose keys are to be retrieved.
+//
+// Returns:
+//   - []storage.APIKey: The API keys of the user.
+//   - error: An error if the operation fails.
+func (m *MockStorage) ListAPIKeys(ctx context.Context, userID string) ([]storage.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.APIKey), args.Error(1)
+}
+
+// RevokeAPIKey mocks the RevokeAPIKey method of the Storage interface.
+//
+// Parameters:
+//   - ctx: The request context.
+//   - userID: The ID of the key owner.
+//   - id: The ID of the key to be revoked.
+//
+// Returns:
+//   - error: An error if the operation fails.
+func (m *MockStorage) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
@@ -0,0 +1,33 @@
+package storage
+
+// Option настраивает хранилище при создании.
+type Option func(*options)
+
+// options содержит параметры, общие для реализаций Storage.
+type options struct {
+	dedup DedupMode
+	sync  SyncPolicy
+}
+
+// WithDedupMode задаёт режим дедупликации оригинальных URL. По умолчанию DedupPerUser.
+func WithDedupMode(mode DedupMode) Option {
+	return func(o *options) {
+		o.dedup = mode
+	}
+}
+
+// WithSyncPolicy задаёт политику сброса журнала FileStorage на диск.
+// По умолчанию SyncAlways. Другие хранилища параметр не используют.
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(o *options) {
+		o.sync = policy
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
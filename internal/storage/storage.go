@@ -1,6 +1,14 @@
 package storage
 
-import "golang.org/x/exp/rand"
+import (
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"time"
+
+	"golang.org/x/exp/rand"
+)
 
 // Константы для генерации короткого идентификатора.
 const (
@@ -8,41 +16,193 @@ const (
 	charset  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789" // Набор символов для идентификатора.
 )
 
+// Ошибки хранилища. Все реализации Storage возвращают их (возможно, обёрнутыми),
+// чтобы вызывающий код мог различать их через errors.Is.
+var (
+	// ErrNotFound возвращается, если запись с указанным идентификатором отсутствует.
+	ErrNotFound = errors.New("url not found")
+	// ErrGone возвращается для записей, помеченных как удалённые.
+	ErrGone = errors.New("url deleted")
+	// ErrConflict возвращается при попытке сохранить уже существующий URL.
+	ErrConflict = errors.New("url already exists")
+	// ErrAliasTaken возвращается, если запрошенный короткий идентификатор уже занят.
+	ErrAliasTaken = errors.New("alias already taken")
+	// ErrForbidden возвращается при попытке изменить чужие записи.
+	ErrForbidden = errors.New("access denied")
+	// ErrUnavailable возвращается при временной недоступности хранилища.
+	ErrUnavailable = errors.New("storage unavailable")
+	// ErrInvalidCursor возвращается, если курсор страницы не удаётся разобрать.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
 // Storage определяет интерфейс для работы с хранилищем URL.
+// Все методы, кроме Close, принимают контекст запроса: его отмена или истечение
+// дедлайна прерывает обращение к хранилищу.
 type Storage interface {
-	// Save сохраняет URL в хранилище.
-	Save(url URL) (string, error)
-	// SaveBatch сохраняет пакет URL в хранилище.
-	SaveBatch(urls []URL) ([]string, error)
-	// Get возвращает URL по короткому идентификатору.
-	Get(shortURL string) (URL, bool)
+	Pinger
+	// Save сохраняет URL в хранилище. Если оригинальный URL уже сохранён в
+	// области дедупликации (см. DedupMode) и ссылка не удалена и не истекла,
+	// возвращает её короткий идентификатор и ErrConflict. Если занят
+	// короткий идентификатор, возвращает ErrAliasTaken.
+	Save(ctx context.Context, url URL) (string, error)
+	// SaveBatch сохраняет пакет URL в хранилище и возвращает результат для
+	// каждого элемента в исходном порядке. Для элементов с заполненным
+	// ShortURL используется он (пользовательский алиас), для остальных
+	// генерируется случайный идентификатор. Элементы, дублирующие действующие
+	// записи или предыдущие элементы пакета, не сохраняются и получают их
+	// идентификаторы со статусом BatchExisting. Элемент с занятым алиасом
+	// получает статус BatchInvalid и ErrAliasTaken, остальные сохраняются.
+	// Ошибка возвращается, только если пакет не удалось обработать целиком.
+	SaveBatch(ctx context.Context, urls []URL) ([]BatchResult, error)
+	// Get возвращает URL по короткому идентификатору. Для отсутствующих
+	// записей возвращает ErrNotFound, для удалённых — запись и ErrGone.
+	Get(ctx context.Context, shortURL string) (URL, error)
 	// GetURLsByUserID возвращает все URL, связанные с указанным идентификатором пользователя.
-	GetURLsByUserID(userID string) ([]URL, error)
+	GetURLsByUserID(ctx context.Context, userID string) ([]URL, error)
+	// ListURLsByUserID возвращает страницу URL пользователя, упорядоченных по
+	// времени создания и короткому идентификатору. Для некорректного курсора
+	// возвращает ErrInvalidCursor.
+	ListURLsByUserID(ctx context.Context, userID string, query ListQuery) (URLPage, error)
 	// GetNextID возвращает следующий уникальный идентификатор для новой записи.
-	GetNextID() (int, error)
+	GetNextID(ctx context.Context) (int, error)
 	// Close закрывает хранилище.
 	Close() error
 	// MarkURLsAsDeleted помечает список URL как удаленные для указанного пользователя.
-	MarkURLsAsDeleted(userID string, shortIDs []string) error
+	// Если среди идентификаторов есть чужие URL, они пропускаются и возвращается ErrForbidden.
+	MarkURLsAsDeleted(ctx context.Context, userID string, shortIDs []string) error
 	// URLCount возвращает число всех загруженных URL
-	URLCount() (int, error)
+	URLCount(ctx context.Context) (int, error)
 	// UserCount возвращает количество пользователей в хранилище
-	UserCount() (int, error)
+	UserCount(ctx context.Context) (int, error)
+	// DeleteExpired помечает удалёнными URL, срок действия которых истёк к
+	// моменту now, и возвращает число помеченных записей. Записи остаются в
+	// хранилище, чтобы Get возвращал для них ErrGone; безвозвратно их удаляет
+	// Admin.PurgeDeleted.
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+	// SaveClicks сохраняет пакет переходов по коротким ссылкам.
+	SaveClicks(ctx context.Context, clicks []Click) error
+	// GetLinkStats возвращает статистику переходов по короткой ссылке.
+	// Для ссылки без переходов возвращает статистику с нулевым счётчиком.
+	GetLinkStats(ctx context.Context, shortURL string) (LinkStats, error)
+	// SaveAPIKey сохраняет новый API-ключ. Если ID или хеш заняты, возвращает ErrConflict.
+	SaveAPIKey(ctx context.Context, key APIKey) error
+	// GetAPIKeyByHash возвращает API-ключ по хешу или ErrNotFound.
+	GetAPIKeyByHash(ctx context.Context, hash string) (APIKey, error)
+	// ListAPIKeys возвращает API-ключи пользователя, включая отозванные.
+	ListAPIKeys(ctx context.Context, userID string) ([]APIKey, error)
+	// RevokeAPIKey отзывает API-ключ пользователя. Для отсутствующего ключа
+	// возвращает ErrNotFound, для чужого — ErrForbidden.
+	RevokeAPIKey(ctx context.Context, userID, id string) error
 }
 
 // Pinger определяет интерфейс для проверки доступности соединения.
 type Pinger interface {
 	// Ping проверяет доступность соединения с хранилищем.
-	Ping() error
+	Ping(ctx context.Context) error
+}
+
+// BulkImporter реализуется хранилищами, которые сохраняют большие пакеты
+// быстрее, чем SaveBatch. Семантика ImportBatch совпадает с SaveBatch.
+type BulkImporter interface {
+	ImportBatch(ctx context.Context, urls []URL) ([]BatchResult, error)
 }
 
 // URL представляет структуру данных для хранения информации об URL.
 type URL struct {
-	CorrelationID string `json:"correlation_id"` // Корреляционный идентификатор.
-	ShortURL      string `json:"short_url"`      // Короткий URL.
-	OriginalURL   string `json:"original_url"`   // Оригинальный URL.
-	UserID        string `json:"user_id"`        // Идентификатор пользователя.
-	DeletedFlag   bool   `json:"is_deleted"`     // Флаг удаления URL.
+	CorrelationID string    `json:"correlation_id"` // Корреляционный идентификатор.
+	ShortURL      string    `json:"short_url"`      // Короткий URL.
+	OriginalURL   string    `json:"original_url"`   // Оригинальный URL.
+	UserID        string    `json:"user_id"`        // Идентификатор пользователя.
+	DeletedFlag   bool      `json:"is_deleted"`     // Флаг удаления URL.
+	ExpiresAt     time.Time `json:"expires_at"`     // Момент истечения срока действия, нулевое значение — бессрочно.
+	CreatedAt     time.Time `json:"created_at"`     // Время создания.
+	UpdatedAt     time.Time `json:"updated_at"`     // Время последнего изменения.
+	DeletedAt     time.Time `json:"deleted_at"`     // Время удаления, нулевое значение — URL не удалён.
+}
+
+// stampCreated заполняет время создания и изменения новой записи, если они не заданы.
+func (u *URL) stampCreated(now time.Time) {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = now
+	}
+	if u.UpdatedAt.IsZero() {
+		u.UpdatedAt = u.CreatedAt
+	}
+}
+
+// markDeleted помечает запись удалённой. Время удаления уже удалённой записи не меняется.
+func (u *URL) markDeleted(now time.Time) {
+	if u.DeletedFlag {
+		return
+	}
+	u.DeletedFlag = true
+	u.DeletedAt = now
+	u.UpdatedAt = now
+}
+
+// Click описывает один переход по короткой ссылке.
+type Click struct {
+	ShortURL  string    `json:"short_url"`  // Короткий идентификатор.
+	ClickedAt time.Time `json:"clicked_at"` // Время перехода.
+	Referrer  string    `json:"referrer"`   // Значение заголовка Referer.
+	UserAgent string    `json:"user_agent"` // Значение заголовка User-Agent.
+	Country   string    `json:"country"`    // Код страны, определённый по IP-адресу.
+	IP        net.IP    `json:"-"`          // Адрес клиента; не сохраняется, используется для определения страны.
+}
+
+// LinkStats содержит агрегированную статистику переходов по короткой ссылке.
+// Пустые значения реферера, User-Agent и страны в разбивки не попадают.
+type LinkStats struct {
+	ShortURL   string         // Короткий идентификатор.
+	Clicks     int            // Число переходов.
+	FirstClick time.Time      // Время первого перехода.
+	LastClick  time.Time      // Время последнего перехода.
+	Referrers  map[string]int // Число переходов по рефереру.
+	UserAgents map[string]int // Число переходов по User-Agent.
+	Countries  map[string]int // Число переходов по стране.
+}
+
+// NewLinkStats создаёт пустую статистику для короткой ссылки.
+func NewLinkStats(shortURL string) LinkStats {
+	return LinkStats{
+		ShortURL:   shortURL,
+		Referrers:  make(map[string]int),
+		UserAgents: make(map[string]int),
+		Countries:  make(map[string]int),
+	}
+}
+
+// Add учитывает переход в статистике.
+func (st *LinkStats) Add(c Click) {
+	st.Clicks++
+	if st.FirstClick.IsZero() || c.ClickedAt.Before(st.FirstClick) {
+		st.FirstClick = c.ClickedAt
+	}
+	if c.ClickedAt.After(st.LastClick) {
+		st.LastClick = c.ClickedAt
+	}
+	if c.Referrer != "" {
+		st.Referrers[c.Referrer]++
+	}
+	if c.UserAgent != "" {
+		st.UserAgents[c.UserAgent]++
+	}
+	if c.Country != "" {
+		st.Countries[c.Country]++
+	}
+}
+
+// Clone возвращает копию статистики, не разделяющую карты с исходной.
+func (st LinkStats) Clone() LinkStats {
+	st.Referrers = maps.Clone(st.Referrers)
+	st.UserAgents = maps.Clone(st.UserAgents)
+	st.Countries = maps.Clone(st.Countries)
+	return st
+}
+
+// Expired сообщает, истёк ли срок действия URL к моменту now.
+func (u URL) Expired(now time.Time) bool {
+	return !u.ExpiresAt.IsZero() && !now.Before(u.ExpiresAt)
 }
 
 func generateShortID() string {
@@ -52,3 +212,50 @@ func generateShortID() string {
 	}
 	return string(b)
 }
+
+// uniqueShortID генерирует короткий идентификатор, не занятый ни в data, ни в taken.
+func uniqueShortID(data map[string]URL, taken map[string]struct{}) string {
+	for {
+		shortID := generateShortID()
+		_, inData := data[shortID]
+		_, inTaken := taken[shortID]
+		if !inData && !inTaken {
+			return shortID
+		}
+	}
+}
+
+// deleteExpired помечает удалёнными записи data с истёкшим сроком действия
+// и возвращает их идентификаторы. Уже удалённые записи не меняются.
+func deleteExpired(data map[string]URL, now time.Time) []string {
+	deleted := expiredURLs(data, now)
+	for _, shortID := range deleted {
+		url := data[shortID]
+		url.markDeleted(now)
+		data[shortID] = url
+	}
+	return deleted
+}
+
+// expiredURLs возвращает идентификаторы неудалённых записей data с истёкшим сроком действия.
+func expiredURLs(data map[string]URL, now time.Time) []string {
+	var expired []string
+	for shortID, url := range data {
+		if !url.DeletedFlag && url.Expired(now) {
+			expired = append(expired, shortID)
+		}
+	}
+	return expired
+}
+
+// addClicks учитывает переходы в статистике stats.
+func addClicks(stats map[string]LinkStats, clicks []Click) {
+	for _, c := range clicks {
+		st, ok := stats[c.ShortURL]
+		if !ok {
+			st = NewLinkStats(c.ShortURL)
+		}
+		st.Add(c)
+		stats[c.ShortURL] = st
+	}
+}
@@ -0,0 +1,724 @@
+// Package storagetest содержит общий набор проверок контракта storage.Storage.
+// Каждая реализация хранилища запускает его из своих тестов:
+//
+//	storagetest.Run(t, storagetest.Backend{
+//		Open: func(t *testing.T, dir string, opts ...storage.Option) storage.Storage {
+//			s, err := storage.NewFileStorage(filepath.Join(dir, "urls.json"), opts...)
+//			require.NoError(t, err)
+//			return s
+//		},
+//		Persistent: true,
+//	})
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mi4r/go-url-shortener/internal/storage"
+)
+
+// Backend описывает проверяемую реализацию хранилища.
+type Backend struct {
+	// Open открывает хранилище с данными в каталоге dir и параметрами opts.
+	// Для нового каталога хранилище должно быть пустым.
+	Open func(t *testing.T, dir string, opts ...storage.Option) storage.Storage
+	// Persistent сообщает, что данные сохраняются после Close и повторного
+	// Open с тем же каталогом. Только для таких хранилищ проверяется перезапуск.
+	Persistent bool
+}
+
+// Параметры проверки параллельного доступа.
+const (
+	concurrentWorkers = 8
+	concurrentOps     = 100
+)
+
+// timePrecision — допустимое расхождение сохранённого времени: базы данных
+// хранят время с точностью до микросекунд.
+const timePrecision = time.Millisecond
+
+// Run проверяет, что backend выполняет контракт storage.Storage.
+func Run(t *testing.T, backend Backend) {
+	open := func(t *testing.T, opts ...storage.Option) storage.Storage {
+		s := backend.Open(t, t.TempDir(), opts...)
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+
+	t.Run("SaveGet", func(t *testing.T) { testSaveGet(t, open(t)) })
+	t.Run("Dedup", func(t *testing.T) { testDedup(t, open) })
+	t.Run("Batch", func(t *testing.T) { testBatch(t, open(t)) })
+	t.Run("DeleteOwnership", func(t *testing.T) { testDeleteOwnership(t, open(t)) })
+	t.Run("Counts", func(t *testing.T) { testCounts(t, open(t)) })
+	t.Run("Expiry", func(t *testing.T) { testExpiry(t, open(t)) })
+	t.Run("List", func(t *testing.T) { testList(t, open(t)) })
+	t.Run("NextID", func(t *testing.T) { testNextID(t, open(t)) })
+	t.Run("Clicks", func(t *testing.T) { testClicks(t, open(t)) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, open(t)) })
+	t.Run("Admin", func(t *testing.T) { testAdmin(t, open(t)) })
+	t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, open(t)) })
+	if backend.Persistent {
+		t.Run("Restart", func(t *testing.T) { testRestart(t, backend) })
+	}
+}
+
+// save сохраняет ссылку и проверяет, что она создана.
+func save(t *testing.T, s storage.Storage, url storage.URL) {
+	t.Helper()
+	_, err := s.Save(context.Background(), url)
+	require.NoError(t, err, "save %s", url.ShortURL)
+}
+
+// assertSameURL сравнивает сохранённую ссылку с ожидаемой с учётом точности времени.
+func assertSameURL(t *testing.T, want, got storage.URL) {
+	t.Helper()
+	assert.Equal(t, want.CorrelationID, got.CorrelationID, "correlation id")
+	assert.Equal(t, want.ShortURL, got.ShortURL, "short url")
+	assert.Equal(t, want.OriginalURL, got.OriginalURL, "original url")
+	assert.Equal(t, want.UserID, got.UserID, "user id")
+	assert.Equal(t, want.DeletedFlag, got.DeletedFlag, "deleted flag")
+	assert.Equal(t, want.ExpiresAt.IsZero(), got.ExpiresAt.IsZero(), "expires at")
+	if !want.ExpiresAt.IsZero() {
+		assert.WithinDuration(t, want.ExpiresAt, got.ExpiresAt, timePrecision, "expires at")
+	}
+}
+
+func testSaveGet(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	before := time.Now()
+	url := storage.URL{
+		CorrelationID: "1",
+		ShortURL:      "a1",
+		OriginalURL:   "https://a.com",
+		UserID:        "alice",
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	save(t, s, url)
+
+	got, err := s.Get(ctx, "a1")
+	require.NoError(t, err)
+	assertSameURL(t, url, got)
+	assert.WithinDuration(t, before, got.CreatedAt, time.Second)
+	assert.WithinDuration(t, got.CreatedAt, got.UpdatedAt, timePrecision)
+	assert.True(t, got.DeletedAt.IsZero())
+
+	_, err = s.Get(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Занятый алиас не перезаписывается.
+	_, err = s.Save(ctx, storage.URL{CorrelationID: "2", ShortURL: "a1", OriginalURL: "https://b.com", UserID: "bob"})
+	assert.ErrorIs(t, err, storage.ErrAliasTaken)
+	got, err = s.Get(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://a.com", got.OriginalURL)
+
+	// Время создания, заданное при сохранении, не заменяется.
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	save(t, s, storage.URL{CorrelationID: "3", ShortURL: "a2", OriginalURL: "https://c.com", UserID: "alice", CreatedAt: created})
+	got, err = s.Get(ctx, "a2")
+	require.NoError(t, err)
+	assert.WithinDuration(t, created, got.CreatedAt, timePrecision)
+}
+
+func testDedup(t *testing.T, open func(t *testing.T, opts ...storage.Option) storage.Storage) {
+	ctx := context.Background()
+	saveExample := func(s storage.Storage, shortID, userID string) (string, error) {
+		return s.Save(ctx, storage.URL{CorrelationID: shortID, ShortURL: shortID, OriginalURL: "https://example.com", UserID: userID})
+	}
+
+	t.Run("per user", func(t *testing.T) {
+		s := open(t)
+		_, err := saveExample(s, "a1", "alice")
+		require.NoError(t, err)
+
+		existing, err := saveExample(s, "a2", "alice")
+		assert.ErrorIs(t, err, storage.ErrConflict)
+		assert.Equal(t, "a1", existing)
+
+		_, err = saveExample(s, "b1", "bob")
+		require.NoError(t, err)
+		urls, err := s.GetURLsByUserID(ctx, "bob")
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "b1", urls[0].ShortURL)
+
+		// Удалённая ссылка не переиспользуется.
+		require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a1"}))
+		_, err = saveExample(s, "a3", "alice")
+		assert.NoError(t, err)
+
+		results, err := s.SaveBatch(ctx, []storage.URL{
+			{CorrelationID: "1", OriginalURL: "https://example.com", UserID: "bob"},
+			{CorrelationID: "2", OriginalURL: "https://example.org", UserID: "bob"},
+			{CorrelationID: "3", OriginalURL: "https://example.org", UserID: "bob"},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, storage.BatchResult{CorrelationID: "1", ShortURL: "b1", Status: storage.BatchExisting}, results[0])
+		assert.Equal(t, storage.BatchCreated, results[1].Status)
+		assert.Equal(t, storage.BatchExisting, results[2].Status)
+		assert.Equal(t, results[1].ShortURL, results[2].ShortURL)
+		assert.NotEqual(t, "b1", results[1].ShortURL)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		s := open(t, storage.WithDedupMode(storage.DedupGlobal))
+		_, err := saveExample(s, "a1", "alice")
+		require.NoError(t, err)
+
+		existing, err := saveExample(s, "b1", "bob")
+		assert.ErrorIs(t, err, storage.ErrConflict)
+		assert.Equal(t, "a1", existing)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		s := open(t, storage.WithDedupMode(storage.DedupNone))
+		_, err := saveExample(s, "a1", "alice")
+		require.NoError(t, err)
+		_, err = saveExample(s, "a2", "alice")
+		require.NoError(t, err)
+
+		results, err := s.SaveBatch(ctx, []storage.URL{
+			{CorrelationID: "1", OriginalURL: "https://example.com", UserID: "alice"},
+			{CorrelationID: "2", OriginalURL: "https://example.com", UserID: "alice"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, storage.BatchCreated, results[0].Status)
+		assert.Equal(t, storage.BatchCreated, results[1].Status)
+		assert.NotEqual(t, results[0].ShortURL, results[1].ShortURL)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s := open(t)
+		save(t, s, storage.URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://example.com", UserID: "alice",
+			ExpiresAt: time.Now().Add(-time.Minute)})
+
+		// Истёкшая ссылка не переиспользуется.
+		_, err := saveExample(s, "a2", "alice")
+		assert.NoError(t, err)
+	})
+
+	t.Run("alias taken", func(t *testing.T) {
+		s := open(t)
+		_, err := saveExample(s, "a1", "alice")
+		require.NoError(t, err)
+		_, err = s.Save(ctx, storage.URL{CorrelationID: "a1", ShortURL: "a1", OriginalURL: "https://example.org", UserID: "alice"})
+		assert.ErrorIs(t, err, storage.ErrAliasTaken)
+	})
+}
+
+func testBatch(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	save(t, s, storage.URL{CorrelationID: "0", ShortURL: "taken", OriginalURL: "https://taken.com", UserID: "alice"})
+
+	results, err := s.SaveBatch(ctx, []storage.URL{
+		{CorrelationID: "c1", OriginalURL: "https://a.com", UserID: "alice"},
+		{CorrelationID: "c2", OriginalURL: "https://a.com", UserID: "alice"},
+		{CorrelationID: "c3", ShortURL: "taken", OriginalURL: "https://b.com", UserID: "alice"},
+		{CorrelationID: "c4", ShortURL: "custom", OriginalURL: "https://c.com", UserID: "alice"},
+		{CorrelationID: "c5", ShortURL: "custom", OriginalURL: "https://d.com", UserID: "alice"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	// Результаты идут в порядке элементов пакета.
+	for i, result := range results {
+		assert.Equal(t, fmt.Sprintf("c%d", i+1), result.CorrelationID)
+	}
+	assert.Equal(t, storage.BatchCreated, results[0].Status)
+	assert.NotEmpty(t, results[0].ShortURL)
+	assert.Equal(t, storage.BatchResult{CorrelationID: "c2", ShortURL: results[0].ShortURL, Status: storage.BatchExisting}, results[1])
+	assert.Equal(t, storage.BatchInvalid, results[2].Status)
+	assert.ErrorIs(t, results[2].Err, storage.ErrAliasTaken)
+	assert.Empty(t, results[2].ShortURL)
+	assert.Equal(t, storage.BatchResult{CorrelationID: "c4", ShortURL: "custom", Status: storage.BatchCreated}, results[3])
+	assert.Equal(t, storage.BatchInvalid, results[4].Status)
+	assert.ErrorIs(t, results[4].Err, storage.ErrAliasTaken)
+
+	url, err := s.Get(ctx, results[0].ShortURL)
+	require.NoError(t, err)
+	assertSameURL(t, storage.URL{CorrelationID: "c1", ShortURL: results[0].ShortURL, OriginalURL: "https://a.com", UserID: "alice"}, url)
+	assert.False(t, url.CreatedAt.IsZero())
+	url, err = s.Get(ctx, "taken")
+	require.NoError(t, err)
+	assert.Equal(t, "https://taken.com", url.OriginalURL)
+
+	count, err := s.URLCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	results, err = s.SaveBatch(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testDeleteOwnership(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	save(t, s, storage.URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "2", ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "3", ShortURL: "b1", OriginalURL: "https://c.com", UserID: "bob"})
+
+	// Чужие ссылки пропускаются, свои удаляются, отсутствующие игнорируются.
+	err := s.MarkURLsAsDeleted(ctx, "alice", []string{"a1", "b1", "missing"})
+	assert.ErrorIs(t, err, storage.ErrForbidden)
+
+	url, err := s.Get(ctx, "a1")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	assert.Equal(t, "a1", url.ShortURL)
+	assert.True(t, url.DeletedFlag)
+	assert.False(t, url.DeletedAt.IsZero())
+	_, err = s.Get(ctx, "b1")
+	assert.NoError(t, err)
+
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a2", "missing"}))
+	_, err = s.Get(ctx, "a2")
+	assert.ErrorIs(t, err, storage.ErrGone)
+
+	// Повторное удаление не меняет время удаления.
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a1"}))
+	again, err := s.Get(ctx, "a1")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	assert.WithinDuration(t, url.DeletedAt, again.DeletedAt, timePrecision)
+
+	// Удалённые ссылки остаются в списке пользователя.
+	urls, err := s.GetURLsByUserID(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, urls, 2)
+}
+
+func testCounts(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	assertCounts := func(urls, users int) {
+		t.Helper()
+		gotURLs, err := s.URLCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, urls, gotURLs, "urls")
+		gotUsers, err := s.UserCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, users, gotUsers, "users")
+	}
+
+	assertCounts(0, 0)
+	save(t, s, storage.URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "2", ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "3", ShortURL: "b1", OriginalURL: "https://c.com", UserID: "bob"})
+	assertCounts(3, 2)
+
+	// Удалённые ссылки учитываются, пока не удалены безвозвратно.
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "bob", []string{"b1"}))
+	assertCounts(3, 2)
+
+	// Повтор существующей ссылки не создаёт запись.
+	_, err := s.Save(ctx, storage.URL{CorrelationID: "4", ShortURL: "a3", OriginalURL: "https://a.com", UserID: "alice"})
+	assert.ErrorIs(t, err, storage.ErrConflict)
+	assertCounts(3, 2)
+}
+
+func testExpiry(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	now := time.Now()
+	save(t, s, storage.URL{CorrelationID: "1", ShortURL: "e1", OriginalURL: "https://a.com", UserID: "alice", ExpiresAt: now.Add(-time.Minute)})
+	save(t, s, storage.URL{CorrelationID: "2", ShortURL: "k1", OriginalURL: "https://b.com", UserID: "alice", ExpiresAt: now.Add(time.Hour)})
+	save(t, s, storage.URL{CorrelationID: "3", ShortURL: "n1", OriginalURL: "https://c.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "4", ShortURL: "e2", OriginalURL: "https://d.com", UserID: "bob", ExpiresAt: now.Add(-time.Minute)})
+
+	// До очистки истёкшая ссылка возвращается со сроком действия.
+	url, err := s.Get(ctx, "e1")
+	require.NoError(t, err)
+	assert.True(t, url.Expired(now))
+
+	deleted, err := s.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	// Истёкшая ссылка остаётся в хранилище удалённой, чтобы переход по ней получал 410.
+	url, err = s.Get(ctx, "e1")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	assert.True(t, url.DeletedFlag)
+	assert.False(t, url.DeletedAt.IsZero())
+	assert.WithinDuration(t, now.Add(-time.Minute), url.ExpiresAt, timePrecision)
+	for _, id := range []string{"k1", "n1"} {
+		_, err = s.Get(ctx, id)
+		assert.NoError(t, err, id)
+	}
+
+	count, err := s.URLCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	deleted, err = s.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	// Безвозвратно истёкшие ссылки удаляет PurgeDeleted.
+	if admin, ok := s.(storage.Admin); ok {
+		purged, err := admin.PurgeDeleted(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 2, purged)
+		_, err = s.Get(ctx, "e1")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		users, err := s.UserCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, users)
+	}
+}
+
+func testList(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []storage.URL{
+		{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://example.com/1", UserID: "alice", CreatedAt: base},
+		{CorrelationID: "2", ShortURL: "a2", OriginalURL: "https://other.com/2", UserID: "alice", CreatedAt: base.Add(time.Minute),
+			ExpiresAt: time.Now().Add(time.Hour)},
+		{CorrelationID: "3", ShortURL: "a3", OriginalURL: "https://example.com/3", UserID: "alice", CreatedAt: base.Add(2 * time.Minute)},
+	}
+	for _, url := range want {
+		save(t, s, url)
+	}
+	save(t, s, storage.URL{CorrelationID: "4", ShortURL: "b1", OriginalURL: "https://example.com/4", UserID: "bob"})
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a3"}))
+	want[2].DeletedFlag = true
+
+	// GetURLsByUserID возвращает полные записи пользователя.
+	urls, err := s.GetURLsByUserID(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, urls, len(want))
+	byID := make(map[string]storage.URL)
+	for _, url := range urls {
+		byID[url.ShortURL] = url
+	}
+	for _, url := range want {
+		assertSameURL(t, url, byID[url.ShortURL])
+	}
+	urls, err = s.GetURLsByUserID(ctx, "nobody")
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+
+	shortIDs := func(page storage.URLPage) []string {
+		var ids []string
+		for _, url := range page.URLs {
+			ids = append(ids, url.ShortURL)
+		}
+		return ids
+	}
+
+	page, err := s.ListURLsByUserID(ctx, "alice", storage.ListQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2"}, shortIDs(page))
+	require.NotEmpty(t, page.NextCursor)
+	page, err = s.ListURLsByUserID(ctx, "alice", storage.ListQuery{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a3"}, shortIDs(page))
+	assert.Empty(t, page.NextCursor)
+
+	page, err = s.ListURLsByUserID(ctx, "alice", storage.ListQuery{Desc: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a3", "a2", "a1"}, shortIDs(page))
+
+	page, err = s.ListURLsByUserID(ctx, "alice", storage.ListQuery{State: storage.StateActive, Search: "EXAMPLE"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1"}, shortIDs(page))
+	page, err = s.ListURLsByUserID(ctx, "alice", storage.ListQuery{State: storage.StateDeleted})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a3"}, shortIDs(page))
+
+	_, err = s.ListURLsByUserID(ctx, "alice", storage.ListQuery{Cursor: "%%%"})
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+}
+
+func testNextID(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	prev, err := s.GetNextID(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, prev)
+
+	for i := 0; i < 3; i++ {
+		shortID := fmt.Sprintf("n%d", i)
+		save(t, s, storage.URL{CorrelationID: shortID, ShortURL: shortID, OriginalURL: "https://" + shortID + ".com", UserID: "alice"})
+		next, err := s.GetNextID(ctx)
+		require.NoError(t, err)
+		assert.Greater(t, next, prev)
+		prev = next
+	}
+}
+
+func testClicks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	save(t, s, storage.URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "2", ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"})
+
+	empty, err := s.GetLinkStats(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "a1", empty.ShortURL)
+	assert.Zero(t, empty.Clicks)
+	assert.True(t, empty.FirstClick.IsZero())
+
+	first := time.Now().Add(-time.Hour).UTC()
+	last := first.Add(30 * time.Minute)
+	require.NoError(t, s.SaveClicks(ctx, []storage.Click{
+		{ShortURL: "a1", ClickedAt: last, Referrer: "https://news.example", Country: "DE"},
+		{ShortURL: "a1", ClickedAt: first, UserAgent: "curl/8.0", Country: "DE"},
+		{ShortURL: "a2", ClickedAt: first},
+	}))
+	require.NoError(t, s.SaveClicks(ctx, []storage.Click{
+		{ShortURL: "a1", ClickedAt: first.Add(time.Minute), Referrer: "https://news.example"},
+	}))
+
+	stats, err := s.GetLinkStats(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Clicks)
+	assert.WithinDuration(t, first, stats.FirstClick, timePrecision)
+	assert.WithinDuration(t, last, stats.LastClick, timePrecision)
+	assert.Equal(t, map[string]int{"https://news.example": 2}, stats.Referrers)
+	assert.Equal(t, map[string]int{"curl/8.0": 1}, stats.UserAgents)
+	assert.Equal(t, map[string]int{"DE": 2}, stats.Countries)
+
+	stats, err = s.GetLinkStats(ctx, "a2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Clicks)
+	assert.Empty(t, stats.Referrers)
+}
+
+func testAPIKeys(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	created := time.Now().Add(-time.Hour).UTC()
+	k1 := storage.APIKey{ID: "k1", UserID: "alice", Name: "ci", Hash: "h1", CreatedAt: created}
+	k2 := storage.APIKey{ID: "k2", UserID: "alice", Name: "cron", Hash: "h2", CreatedAt: created.Add(time.Minute)}
+	require.NoError(t, s.SaveAPIKey(ctx, k2))
+	require.NoError(t, s.SaveAPIKey(ctx, k1))
+	assert.ErrorIs(t, s.SaveAPIKey(ctx, storage.APIKey{ID: "k1", UserID: "bob", Hash: "h3", CreatedAt: created}), storage.ErrConflict)
+	assert.ErrorIs(t, s.SaveAPIKey(ctx, storage.APIKey{ID: "k3", UserID: "bob", Hash: "h1", CreatedAt: created}), storage.ErrConflict)
+
+	key, err := s.GetAPIKeyByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.ID)
+	assert.Equal(t, "alice", key.UserID)
+	assert.Equal(t, "ci", key.Name)
+	assert.False(t, key.Revoked())
+	_, err = s.GetAPIKeyByHash(ctx, "h3")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	keys, err := s.ListAPIKeys(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "k1", keys[0].ID)
+	assert.Equal(t, "k2", keys[1].ID)
+	keys, err = s.ListAPIKeys(ctx, "bob")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	assert.ErrorIs(t, s.RevokeAPIKey(ctx, "bob", "k1"), storage.ErrForbidden)
+	assert.ErrorIs(t, s.RevokeAPIKey(ctx, "alice", "missing"), storage.ErrNotFound)
+	require.NoError(t, s.RevokeAPIKey(ctx, "alice", "k1"))
+	key, err = s.GetAPIKeyByHash(ctx, "h1")
+	require.NoError(t, err)
+	require.True(t, key.Revoked())
+
+	// Повторный отзыв не меняет время отзыва.
+	require.NoError(t, s.RevokeAPIKey(ctx, "alice", "k1"))
+	again, err := s.GetAPIKeyByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.WithinDuration(t, key.RevokedAt, again.RevokedAt, timePrecision)
+}
+
+func testAdmin(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	admin, ok := s.(storage.Admin)
+	if !ok {
+		t.Skipf("%T does not implement storage.Admin", s)
+	}
+
+	save(t, s, storage.URL{CorrelationID: "1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "2", ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "3", ShortURL: "b1", OriginalURL: "https://c.com", UserID: "bob"})
+
+	t.Run("disable and enable", func(t *testing.T) {
+		require.NoError(t, admin.SetURLDeleted(ctx, "a1", true))
+		_, err := s.Get(ctx, "a1")
+		assert.ErrorIs(t, err, storage.ErrGone)
+
+		require.NoError(t, admin.SetURLDeleted(ctx, "a1", false))
+		url, err := s.Get(ctx, "a1")
+		require.NoError(t, err)
+		assert.False(t, url.DeletedFlag)
+		assert.True(t, url.DeletedAt.IsZero())
+
+		// Восстановленная ссылка снова участвует в дедупликации.
+		existing, err := s.Save(ctx, storage.URL{CorrelationID: "4", ShortURL: "a3", OriginalURL: "https://a.com", UserID: "alice"})
+		assert.ErrorIs(t, err, storage.ErrConflict)
+		assert.Equal(t, "a1", existing)
+
+		assert.ErrorIs(t, admin.SetURLDeleted(ctx, "missing", true), storage.ErrNotFound)
+	})
+
+	t.Run("reassign", func(t *testing.T) {
+		moved, err := admin.ReassignURLs(ctx, "alice", "carol", []string{"a2", "b1"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, moved)
+
+		url, err := s.Get(ctx, "a2")
+		require.NoError(t, err)
+		assert.Equal(t, "carol", url.UserID)
+
+		moved, err = admin.ReassignURLs(ctx, "bob", "carol", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, moved)
+		urls, err := s.GetURLsByUserID(ctx, "carol")
+		require.NoError(t, err)
+		assert.Len(t, urls, 2)
+		users, err := s.UserCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, users)
+
+		// Новый владелец получает ссылку при повторном сокращении, прежний — нет.
+		existing, err := s.Save(ctx, storage.URL{CorrelationID: "5", ShortURL: "c1", OriginalURL: "https://b.com", UserID: "carol"})
+		assert.ErrorIs(t, err, storage.ErrConflict)
+		assert.Equal(t, "a2", existing)
+		_, err = s.Save(ctx, storage.URL{CorrelationID: "6", ShortURL: "a4", OriginalURL: "https://b.com", UserID: "alice"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		require.NoError(t, admin.SetURLDeleted(ctx, "a1", true))
+
+		purged, err := admin.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = admin.PurgeDeleted(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		_, err = s.Get(ctx, "a1")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+// testConcurrent параллельно вызывает Save, Get, GetURLsByUserID и
+// MarkURLsAsDeleted. Гонки данных обнаруживаются при запуске с -race.
+func testConcurrent(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrentWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			userID := fmt.Sprintf("user%d", w)
+			for i := 0; i < concurrentOps; i++ {
+				shortID := fmt.Sprintf("w%d-%d", w, i)
+				url := storage.URL{
+					CorrelationID: shortID,
+					ShortURL:      shortID,
+					OriginalURL:   "https://example.com/" + shortID,
+					UserID:        userID,
+				}
+				if _, err := s.Save(ctx, url); err != nil {
+					t.Errorf("save %s: %v", shortID, err)
+					return
+				}
+				if _, err := s.Get(ctx, shortID); err != nil {
+					t.Errorf("get %s: %v", shortID, err)
+				}
+				if i%10 == 0 {
+					if err := s.MarkURLsAsDeleted(ctx, userID, []string{shortID}); err != nil {
+						t.Errorf("delete %s: %v", shortID, err)
+					}
+				}
+				if _, err := s.GetURLsByUserID(ctx, userID); err != nil {
+					t.Errorf("get user urls: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	count, err := s.URLCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, concurrentWorkers*concurrentOps, count)
+	_, err = s.Get(ctx, "w0-0")
+	assert.ErrorIs(t, err, storage.ErrGone)
+}
+
+// testRestart проверяет, что данные и индексы хранилища переживают перезапуск.
+func testRestart(t *testing.T, backend Backend) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := backend.Open(t, dir)
+
+	url := storage.URL{CorrelationID: "c1", ShortURL: "a1", OriginalURL: "https://a.com", UserID: "alice", ExpiresAt: time.Now().Add(time.Hour)}
+	save(t, s, url)
+	save(t, s, storage.URL{CorrelationID: "c2", ShortURL: "a2", OriginalURL: "https://b.com", UserID: "alice"})
+	results, err := s.SaveBatch(ctx, []storage.URL{{CorrelationID: "c3", OriginalURL: "https://c.com", UserID: "bob"}})
+	require.NoError(t, err)
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a2"}))
+
+	// Изменения администратора тоже переживают перезапуск.
+	admin, ok := s.(storage.Admin)
+	require.True(t, ok, "%T does not implement storage.Admin", s)
+	save(t, s, storage.URL{CorrelationID: "c5", ShortURL: "a4", OriginalURL: "https://d.com", UserID: "alice"})
+	save(t, s, storage.URL{CorrelationID: "c6", ShortURL: "a5", OriginalURL: "https://e.com", UserID: "alice"})
+	require.NoError(t, admin.SetURLDeleted(ctx, "a4", true))
+	require.NoError(t, s.MarkURLsAsDeleted(ctx, "alice", []string{"a5"}))
+	require.NoError(t, admin.SetURLDeleted(ctx, "a5", false))
+	moved, err := admin.ReassignURLs(ctx, "alice", "carol", []string{"a5"})
+	require.NoError(t, err)
+	require.Equal(t, 1, moved)
+
+	require.NoError(t, s.SaveClicks(ctx, []storage.Click{{ShortURL: "a1", ClickedAt: time.Now(), Country: "DE"}}))
+	require.NoError(t, s.SaveAPIKey(ctx, storage.APIKey{ID: "k1", UserID: "alice", Hash: "h1", CreatedAt: time.Now()}))
+	nextID, err := s.GetNextID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s = backend.Open(t, dir)
+	t.Cleanup(func() { s.Close() })
+
+	got, err := s.Get(ctx, "a1")
+	require.NoError(t, err)
+	assertSameURL(t, url, got)
+	_, err = s.Get(ctx, "a2")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	got, err = s.Get(ctx, results[0].ShortURL)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", got.UserID)
+	got, err = s.Get(ctx, "a4")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	assert.True(t, got.DeletedFlag)
+	got, err = s.Get(ctx, "a5")
+	require.NoError(t, err)
+	assert.False(t, got.DeletedFlag)
+	assert.Equal(t, "carol", got.UserID)
+	carol, err := s.GetURLsByUserID(ctx, "carol")
+	require.NoError(t, err)
+	require.Len(t, carol, 1)
+	assert.Equal(t, "a5", carol[0].ShortURL)
+
+	count, err := s.URLCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	users, err := s.UserCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, users)
+	reopenedID, err := s.GetNextID(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, reopenedID, nextID)
+
+	stats, err := s.GetLinkStats(ctx, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Clicks)
+	key, err := s.GetAPIKeyByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.ID)
+
+	// Индекс дедупликации восстанавливается.
+	existing, err := s.Save(ctx, storage.URL{CorrelationID: "c4", ShortURL: "a3", OriginalURL: "https://a.com", UserID: "alice"})
+	assert.ErrorIs(t, err, storage.ErrConflict)
+	assert.Equal(t, "a1", existing)
+}